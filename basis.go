@@ -0,0 +1,16 @@
+package lp
+
+// basisFactor keeps a factorization of the basis matrix B of the simplex
+// method and solves the linear systems with B and B^T.
+type basisFactor interface {
+	// factorize computes a fresh factorization; columns[k] is the column
+	// of B at basis position k.
+	factorize(columns []sparseVector) bool
+	// ftran overwrites a with B^-1 a.
+	ftran(a []float64)
+	// btran overwrites c with B^-T c.
+	btran(c []float64)
+	// update replaces the column at basis position r by the entering column
+	// a_q; w must hold B^-1 a_q.
+	update(r int, w []float64) bool
+}
@@ -0,0 +1,174 @@
+package lp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFreeVariables(t *testing.T) {
+	fmt.Println("Test FreeVariables")
+
+	ls := NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	x, _ := ls.AddVariable(nil)
+	if !math.IsInf(x.Min(), -1) || !math.IsInf(x.Max(), 1) {
+		t.Errorf("range of a new variable = [%v, %v], want free", x.Min(), x.Max())
+	}
+
+	// values beyond the old limit of 20000
+	ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorGE, 50000)
+	ls.SetObjective1([]float64{1}, []*Variable{x}, OptMinimize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 50000)
+
+	x.SetRange(math.Inf(-1), 60000)
+	ls.SetObjective1([]float64{1}, []*Variable{x}, OptMaximize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 60000)
+
+	x.SetFree()
+	if result, _ := ls.Solve(); result != ResultUnbounded {
+		t.Errorf("result = %v, want %v", result, ResultUnbounded)
+	}
+}
+
+func TestActiveSetBounds(t *testing.T) {
+	fmt.Println("Test ActiveSet Bounds")
+
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{y, x}, OperatorEQ, 5000)
+
+	// bounds are no constraints of the specification
+	x.SetMin(30000)
+	x.SetRange(30000, 50000)
+	x.SetRange(30000, 40000)
+	if ls.Constraints().Len() != 1 {
+		t.Errorf("%v constraints, want 1", ls.Constraints().Len())
+	}
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if x.Value() < 30000-EqualsEpsilon || x.Value() > 40000+EqualsEpsilon {
+		t.Errorf("x = %v, want within [30000, 40000]", x.Value())
+	}
+	checkValue(t, "y - x", y.Value()-x.Value(), 5000)
+
+	// the soft constraint pulls y below the bounds of x
+	ls.AddConstraint4([]float64{1}, []*Variable{y}, OperatorGE, 0, 1, 1)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x at its lower bound", x.Value(), 30000)
+	if x.ReducedCost() <= 0 {
+		t.Errorf("reduced cost of x = %v, want > 0", x.ReducedCost())
+	}
+
+	x.SetFree()
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "free x", x.Value(), -5000)
+	checkValue(t, "free y", y.Value(), 0)
+}
+
+func TestLayoutOptimizerBounds(t *testing.T) {
+	fmt.Println("Test LayoutOptimizer Bounds")
+
+	// soft x >= 10 and y >= 10, whose penalties pull to 10 from both sides,
+	// with the hard x + y <= 30; the simplex solver adds no slacks to them
+	ls := NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	constraints := newConstraintList()
+	soft, _ := ls.AddConstraint4([]float64{1}, []*Variable{x}, OperatorGE, 10, 1, 1)
+	constraints.AddItem(soft)
+	soft, _ = ls.AddConstraint4([]float64{1}, []*Variable{y}, OperatorGE, 10, 1, 1)
+	constraints.AddItem(soft)
+	hard, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 30)
+	constraints.AddItem(hard)
+
+	// x starts at its lower bound and leaves it, y runs into its upper one
+	optimizer := NewLayoutOptimizer(constraints, 2)
+	optimizer.SetBounds([]float64{5, math.Inf(-1)}, []float64{20, 8})
+	values := []float64{5, 0}
+	if !optimizer.Solve(values) {
+		t.Fatal("not solved")
+	}
+	checkValue(t, "x", values[0], 10)
+	checkValue(t, "y", values[1], 8)
+	if len(optimizer.Duals()) != constraints.Len() {
+		t.Errorf("%v duals, want %v", len(optimizer.Duals()), constraints.Len())
+	}
+	duals := optimizer.BoundDuals()
+	checkValue(t, "dual of the lower bound of x", duals[0], 0)
+	if duals[1] >= 0 {
+		t.Errorf("dual of the upper bound of y = %v, want < 0", duals[1])
+	}
+	if optimizer.ActiveConstraints() != 1 {
+		t.Errorf("%v active constraints, want 1", optimizer.ActiveConstraints())
+	}
+
+	// a fixed variable keeps its value
+	optimizer.SetBounds([]float64{3, 2}, []float64{3, 8})
+	values = []float64{3, 2}
+	if !optimizer.Solve(values) {
+		t.Fatal("not solved")
+	}
+	checkValue(t, "fixed x", values[0], 3)
+	checkValue(t, "y", values[1], 8)
+}
+
+func TestEquationSystemBounds(t *testing.T) {
+	fmt.Println("Test EquationSystem Bounds")
+
+	// the upper bounds hold without rows of their own
+	system := NewEquationSystem(1, 3)
+	*system.A(0, 0) = 1
+	*system.A(0, 1) = 1
+	*system.A(0, 2) = 1
+	*system.B(0) = 15
+	system.SetUpper(0, 10)
+	system.SetUpper(1, 10)
+	system.SetUpper(2, 2)
+	if _, solved := solveEq(context.Background(), system); !solved {
+		t.Fatal("not solved")
+	}
+	results := make([]float64, 3)
+	system.Results(results, 3)
+	for i, upper := range []float64{10, 10, 2} {
+		if results[i] < -EqualsEpsilon || results[i] > upper+EqualsEpsilon {
+			t.Errorf("x%v = %v, want within [0, %v]", i, results[i], upper)
+		}
+	}
+	checkValue(t, "sum", results[0]+results[1]+results[2], 15)
+
+	// beyond the sum of the upper bounds
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, 10)
+	y.SetRange(-5, 10)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorGE, 25)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Fatalf("result = %v, want %v", result, ResultInfeasible)
+	}
+	if farkas, err := ls.FarkasCertificate(); err != nil || !farkas.Verify() {
+		t.Errorf("certificate against the upper bounds: %v", err)
+	}
+	ls.Constraints().GetAt(0).SetRightSide(20)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 10)
+	checkValue(t, "y", y.Value(), 10)
+}
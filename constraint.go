@@ -1,5 +1,7 @@
 package lp
 
+import "strconv"
+
 // Hard linear constraint, i.e. one that must be satisfied.
 // May render a specification infeasible.
 type Constraint struct {
@@ -12,6 +14,7 @@ type Constraint struct {
 	dPosObjSummand         *Summand
 	label                  string
 	isValid                bool
+	dual                   float64
 }
 
 func newConstraint(ls *LinearSpec, summands *SummandList, opType int,
@@ -42,9 +45,12 @@ func (self *Constraint) LeftSide() *SummandList {
 }
 
 // SetLeftSide sets the summands on the left side of the constraint
-func (self *Constraint) SetLeftSide(summands *SummandList) {
+func (self *Constraint) SetLeftSide(summands *SummandList) error {
 	if !self.isValid {
-		return
+		return &ConstraintError{self, ErrInvalidConstraint}
+	}
+	if err := self.ls.checkSummands(summands); err != nil {
+		return err
 	}
 
 	// check left side
@@ -60,22 +66,29 @@ func (self *Constraint) SetLeftSide(summands *SummandList) {
 		}
 	}
 	self.leftSide = summands
-	self.ls.updateLeftSide(self)
+	return self.ls.updateLeftSide(self)
 }
 
-func (self *Constraint) SetLeftSide1(coeffs []float64, vars []*Variable) {
+func (self *Constraint) SetLeftSide1(coeffs []float64, vars []*Variable) error {
 	if !self.isValid {
-		return
+		return &ConstraintError{self, ErrInvalidConstraint}
 	}
 	if len(coeffs) != len(vars) {
-		return
+		return ErrLengthMismatch
 	}
 
-	self.LeftSide().Clear()
+	summands := newSummandList()
 	for i, c := range coeffs {
-		self.leftSide.AddItem(NewSummand(c, vars[i]))
+		summands.AddItem(NewSummand(c, vars[i]))
+	}
+	if err := self.ls.checkSummands(summands); err != nil {
+		return err
+	}
+	self.LeftSide().Clear()
+	for i := 0; i < summands.Len(); i++ {
+		self.leftSide.AddItem(summands.GetAt(i))
 	}
-	self.SetLeftSide(self.leftSide)
+	return self.SetLeftSide(self.leftSide)
 }
 
 // Op gets the operator used for this constraint.
@@ -84,12 +97,15 @@ func (self *Constraint) Op() int {
 }
 
 // SetOp sets the operator used for this constraint.
-func (self *Constraint) SetOp(opType int) {
+func (self *Constraint) SetOp(opType int) error {
 	if !self.isValid {
-		return
+		return &ConstraintError{self, ErrInvalidConstraint}
+	}
+	if opType != OperatorLE && opType != OperatorGE && opType != OperatorEQ {
+		return ErrInvalidOperator
 	}
 	self.opType = opType
-	self.ls.updateOperator(self)
+	return self.ls.updateOperator(self)
 }
 
 // RightSide gets the constant value that is on the right side of the operator.
@@ -98,14 +114,15 @@ func (self *Constraint) RightSide() float64 {
 }
 
 // SetRightSide sets the constant value that is on the right side of the operator.
-func (self *Constraint) SetRightSide(value float64) {
+func (self *Constraint) SetRightSide(value float64) error {
 	if !self.isValid {
-		return
+		return &ConstraintError{self, ErrInvalidConstraint}
 	}
 	if self.rightSide == value {
-		return
+		return nil
 	}
-	self.ls.updateRightSide(self)
+	self.rightSide = value
+	return self.ls.updateRightSide(self)
 }
 
 // PenaltyNeg gets the penalty coefficient for negative deviations.
@@ -115,9 +132,9 @@ func (self *Constraint) PenaltyNeg() float64 {
 
 // SetPenaltyNeg sets the penalty coefficient for negative deviations from the soft
 // constraint's exact solution, i.e. if the left side is too large.
-func (self *Constraint) SetPenaltyNeg(value float64) {
+func (self *Constraint) SetPenaltyNeg(value float64) error {
 	self.penaltyNeg = value
-	self.ls.updateLeftSide(self)
+	return self.ls.updateLeftSide(self)
 }
 
 // PenaltyPos gets the penalty coefficient for positive deviations.
@@ -127,9 +144,16 @@ func (self *Constraint) PenaltyPos() float64 {
 
 // SetPenaltyPos sets the penalty coefficient for negative deviations from the soft
 // constraint's exact solution, i.e. if the left side is too small.
-func (self *Constraint) SetPenaltyPos(value float64) {
+func (self *Constraint) SetPenaltyPos(value float64) error {
 	self.penaltyPos = value
-	self.ls.updateLeftSide(self)
+	return self.ls.updateLeftSide(self)
+}
+
+// Dual gets the shadow price of the last successful solve: the change of
+// the objective per unit increase of the right side. It is 0 for a
+// constraint that is not binding.
+func (self *Constraint) Dual() float64 {
+	return self.dual
 }
 
 func (self *Constraint) Label() string {
@@ -178,6 +202,14 @@ func (self *Constraint) Invalidate() {
 	self.ls.RemoveConstraint(self)
 }
 
+// name returns the label of the constraint or its index.
+func (self *Constraint) name() string {
+	if self.label != "" {
+		return self.label
+	}
+	return "#" + strconv.Itoa(self.Index())
+}
+
 func (self *Constraint) String() string {
 	s := "Constraint "
 	s = s + self.label
@@ -0,0 +1,123 @@
+package lp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// countdownContext is canceled after a number of Err calls, i.e. after a
+// number of solver iterations.
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (self *countdownContext) Err() error {
+	if self.remaining <= 0 {
+		return context.Canceled
+	}
+	self.remaining--
+	return nil
+}
+
+func TestSolveContext(t *testing.T) {
+	fmt.Println("Test SolveContext")
+
+	ls, _ := newBealeSpec()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := ls.SolveContext(ctx)
+	if result != ResultUserAbort || !errors.Is(err, ErrUserAbort) {
+		t.Errorf("canceled: result = %v, err = %v", result, err)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	result, err = ls.SolveContext(ctx)
+	if result != ResultTimeout || !errors.Is(err, ErrTimeout) {
+		t.Errorf("deadline: result = %v, err = %v", result, err)
+	}
+
+	// interrupted in the simplex iterations
+	ls, _ = newBealeSpec()
+	result, _ = ls.SolveContext(&countdownContext{context.Background(), 2})
+	if result != ResultUserAbort {
+		t.Errorf("result = %v, want %v", result, ResultUserAbort)
+	}
+	if iterations := ls.Solver().(*SimplexSolver).Iterations(); iterations != 1 {
+		t.Errorf("%v iterations, want 1", iterations)
+	}
+
+	result, _ = ls.SolveContext(&countdownContext{context.Background(), 1000})
+	if result != ResultOptimal {
+		t.Errorf("result = %v, want %v", result, ResultOptimal)
+	}
+}
+
+func TestSolveContextBranchAndBound(t *testing.T) {
+	fmt.Println("Test SolveContext BranchAndBound")
+
+	ls, solver, x := newKnapsackSpec()
+	// stop the search as soon as there is an integer solution
+	var ctx *countdownContext
+	for remaining := 1; ; remaining++ {
+		ctx = &countdownContext{context.Background(), remaining}
+		result, _ := ls.SolveContext(ctx)
+		if result == ResultOptimal {
+			t.Fatal("no interrupted solve with an integer solution")
+		}
+		if !math.IsInf(solver.incumbentValue, 1) {
+			break
+		}
+	}
+
+	// the variables get the incumbent, a feasible integer solution
+	weight := 0.0
+	for i, w := range []float64{3, 4, 2, 3} {
+		value := x[i].Value()
+		if value != 0 && value != 1 {
+			t.Errorf("x%v = %v, want 0 or 1", i, value)
+		}
+		weight += w * value
+	}
+	if weight > 7 {
+		t.Errorf("weight = %v, want at most 7", weight)
+	}
+	if solver.BestBound() < ls.ObjectiveValue() {
+		t.Errorf("best bound %v below the incumbent %v", solver.BestBound(), ls.ObjectiveValue())
+	}
+}
+
+func TestSolveContextActiveSet(t *testing.T) {
+	fmt.Println("Test SolveContext ActiveSet")
+
+	ls := NewLinearSpec()
+	x1, _ := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1.0}, []*Variable{x1}, OperatorEQ, 0)
+	ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{x1, x2}, OperatorLE, -10)
+	ls.AddConstraint4([]float64{1.0}, []*Variable{x2}, OperatorEQ, 50, 1, 1)
+
+	for remaining := 1; remaining < 10; remaining++ {
+		x1.SetValue(-1)
+		x2.SetValue(-1)
+		result, _ := ls.SolveContext(&countdownContext{context.Background(), remaining})
+		if result == ResultOptimal {
+			break
+		}
+		if result != ResultUserAbort {
+			t.Fatalf("result = %v, want %v", result, ResultUserAbort)
+		}
+		// once there is a solution it satisfies the hard constraints
+		if x1.Value() != -1 && (x1.Value() != 0 || x1.Value()-x2.Value() > -10) {
+			t.Errorf("x1 = %v, x2 = %v is infeasible", x1.Value(), x2.Value())
+		}
+	}
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+}
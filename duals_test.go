@@ -0,0 +1,80 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestDualsSimplex(t *testing.T) {
+	fmt.Println("Test Duals Simplex")
+
+	for _, solver := range []string{"primal", "dual"} {
+		ls := NewLinearSpec()
+		if solver == "primal" {
+			ls.SetSolver(NewSimplexSolver(ls))
+		} else {
+			ls.SetSolver(NewDualSimplexSolver(ls))
+		}
+		x, _ := ls.AddVariable(nil)
+		y, _ := ls.AddVariable(nil)
+		x.SetRange(0, 3)
+		y.SetRange(0, math.Inf(1))
+		c1, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 4)
+		c2, _ := ls.AddConstraint2([]float64{1, 3}, []*Variable{x, y}, OperatorLE, 7)
+		ls.SetObjective1([]float64{3, 2}, []*Variable{x, y}, OptMaximize)
+
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("%v: result = %v, want %v", solver, result, ResultOptimal)
+		}
+		checkValue(t, solver+" dual of the binding row", c1.Dual(), 2)
+		checkValue(t, solver+" dual of the slack row", c2.Dual(), 0)
+		checkValue(t, solver+" reduced cost at the upper bound", x.ReducedCost(), 1)
+		checkValue(t, solver+" reduced cost of the basic variable", y.ReducedCost(), 0)
+	}
+
+	// minimization, the variable at its lower bound
+	ls := NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, math.Inf(1))
+	y.SetRange(0, math.Inf(1))
+	c, _ := ls.AddConstraint2([]float64{1, 2}, []*Variable{x, y}, OperatorGE, 4)
+	ls.SetObjective1([]float64{1, 1}, []*Variable{x, y}, OptMinimize)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "dual", c.Dual(), 0.5)
+	checkValue(t, "reduced cost", x.ReducedCost(), 0.5)
+
+	// infeasible solves clear the duals
+	c.SetRightSide(-1)
+	c.SetOp(OperatorLE)
+	ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorGE, 1)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Fatalf("result = %v, want %v", result, ResultInfeasible)
+	}
+	checkValue(t, "dual after an infeasible solve", c.Dual(), 0)
+	checkValue(t, "reduced cost after an infeasible solve", x.ReducedCost(), 0)
+}
+
+func TestDualsActiveSet(t *testing.T) {
+	fmt.Println("Test Duals ActiveSet")
+
+	// the penalty 1/2 (x - 8)^2 + 1/2 (y - 8)^2 is minimized at x = y = 5
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	c, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 10)
+	soft, _ := ls.AddConstraint4([]float64{1}, []*Variable{x}, OperatorGE, 8, 1, 1)
+	ls.AddConstraint4([]float64{1}, []*Variable{y}, OperatorGE, 8, 1, 1)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 5)
+	checkValue(t, "dual of the hard constraint", c.Dual(), -3)
+	checkValue(t, "dual of the soft constraint", soft.Dual(), 3)
+}
@@ -0,0 +1,781 @@
+package lp
+
+import (
+	"context"
+	"math"
+	"slices"
+)
+
+// setBasis installs a basis given by the status of the structural and
+// logical columns; the statuses of nonbasic columns are only hints and are
+// corrected by warmSolve. Returns false if the number of basic columns does
+// not match the number of rows or the basis is singular.
+func (self *simplex) setBasis(status []int) bool {
+	position := 0
+	for j := 0; j < self.n+self.m; j++ {
+		self.status[j] = status[j]
+		if status[j] != columnBasic {
+			continue
+		}
+		if position == self.m {
+			return false
+		}
+		self.head[position] = j
+		position++
+	}
+	if position != self.m {
+		return false
+	}
+	self.clearArtificials()
+	return self.refactor()
+}
+
+// clearArtificials fixes the artificial columns at zero.
+func (self *simplex) clearArtificials() {
+	for i := 0; i < self.m; i++ {
+		artificial := self.n + self.m + i
+		self.artificialSign[i] = 0
+		self.lower[artificial] = 0
+		self.upper[artificial] = 0
+		self.status[artificial] = columnAtLower
+		self.x[artificial] = 0
+	}
+}
+
+// restart prepares the simplex of an earlier solve for warmSolve from its
+// basis, after its model was changed in place.
+func (self *simplex) restart() {
+	self.clearArtificials()
+	self.iterations = 0
+	self.feasible = false
+	self.infeasibleRow = -1
+	self.ray = nil
+}
+
+// addColumn extends the simplex by the next column of its model, which
+// starts nonbasic. The basis matrix stays the same.
+func (self *simplex) addColumn() {
+	j := self.n
+	self.lower = slices.Insert(self.lower, j, self.model.lower[j])
+	self.upper = slices.Insert(self.upper, j, self.model.upper[j])
+	self.cost = slices.Insert(self.cost, j, 0)
+	self.x = slices.Insert(self.x, j, 0)
+	self.d = slices.Insert(self.d, j, 0)
+	self.status = slices.Insert(self.status, j, columnAtLower)
+	for r, k := range self.head {
+		if k >= j {
+			self.head[r]++
+		}
+	}
+	self.n++
+	self.setNonbasic(j)
+}
+
+// removeColumn removes structural column j from the simplex and its
+// model. A basic column is replaced by the nonbasic one with the largest
+// pivot in its row first. Returns false if the basis can not be
+// factorized.
+func (self *simplex) removeColumn(j int) bool {
+	r := slices.Index(self.head, j)
+	if r >= 0 {
+		rho := make([]float64, self.m)
+		rho[r] = 1
+		self.factor.btran(rho)
+		entering := -1
+		best := 0.0
+		for k := 0; k < self.n+self.m; k++ {
+			if self.status[k] == columnBasic {
+				continue
+			}
+			if alpha := math.Abs(self.dot(rho, k)); alpha > best {
+				best = alpha
+				entering = k
+			}
+		}
+		if entering < 0 {
+			return false
+		}
+		self.head[r] = entering
+		self.status[entering] = columnBasic
+	}
+
+	self.model.removeColumn(j)
+	self.lower = slices.Delete(self.lower, j, j+1)
+	self.upper = slices.Delete(self.upper, j, j+1)
+	self.cost = slices.Delete(self.cost, j, j+1)
+	self.x = slices.Delete(self.x, j, j+1)
+	self.d = slices.Delete(self.d, j, j+1)
+	self.status = slices.Delete(self.status, j, j+1)
+	for i, k := range self.head {
+		if k > j {
+			self.head[i]--
+		}
+	}
+	self.n--
+	if r >= 0 {
+		return self.refactor()
+	}
+	return true
+}
+
+// addRow extends the simplex by the last row of its model, whose logical
+// column becomes basic. Returns false if the basis can not be factorized.
+func (self *simplex) addRow() bool {
+	logical := self.n + self.m
+	lower, upper := logicalBounds(self.model.ops[self.m])
+	self.lower = append(slices.Insert(self.lower, logical, lower), 0)
+	self.upper = append(slices.Insert(self.upper, logical, upper), 0)
+	self.cost = append(slices.Insert(self.cost, logical, 0), 0)
+	self.x = append(slices.Insert(self.x, logical, 0), 0)
+	self.d = append(slices.Insert(self.d, logical, 0), 0)
+	self.status = append(slices.Insert(self.status, logical, columnBasic), columnAtLower)
+	for r, k := range self.head {
+		if k >= logical {
+			self.head[r]++
+		}
+	}
+	self.head = append(self.head, logical)
+	self.artificialSign = append(self.artificialSign, 0)
+	self.y = append(self.y, 0)
+	self.m++
+	self.factor = newSparseLU(self.m, self.tolerances.Pivot)
+	return self.refactor()
+}
+
+// removeRow removes row i from the simplex and its model. If the logical
+// of the row is nonbasic it replaces the basic column with the largest
+// entry in the row of the basis inverse first. Returns false if the basis
+// can not be factorized.
+func (self *simplex) removeRow(i int) bool {
+	logical := self.n + i
+	if self.status[logical] != columnBasic {
+		w := make([]float64, self.m)
+		w[i] = 1
+		self.factor.ftran(w)
+		r := 0
+		for k := range w {
+			if math.Abs(w[k]) > math.Abs(w[r]) {
+				r = k
+			}
+		}
+		if w[r] == 0 {
+			return false
+		}
+		self.setNonbasic(self.head[r])
+		self.head[r] = logical
+		self.status[logical] = columnBasic
+	}
+
+	self.model.removeRow(i)
+	artificial := self.n + self.m + i
+	for _, j := range []int{artificial, logical} {
+		self.lower = slices.Delete(self.lower, j, j+1)
+		self.upper = slices.Delete(self.upper, j, j+1)
+		self.cost = slices.Delete(self.cost, j, j+1)
+		self.x = slices.Delete(self.x, j, j+1)
+		self.d = slices.Delete(self.d, j, j+1)
+		self.status = slices.Delete(self.status, j, j+1)
+	}
+	position := slices.Index(self.head, logical)
+	self.head = slices.Delete(self.head, position, position+1)
+	for r, k := range self.head {
+		switch {
+		case k > artificial:
+			self.head[r] -= 2
+		case k > logical:
+			self.head[r]--
+		}
+	}
+	self.artificialSign = slices.Delete(self.artificialSign, i, i+1)
+	self.y = slices.Delete(self.y, i, i+1)
+	self.m--
+	self.factor = newSparseLU(self.m, self.tolerances.Pivot)
+	return self.refactor()
+}
+
+// repairStatus moves every nonbasic column to the bound that makes its
+// reduced cost dual feasible, if there is such a bound. Returns true if the
+// basis is dual feasible afterwards.
+func (self *simplex) repairStatus() bool {
+	dualFeasible := true
+	for j := 0; j < self.n+self.m; j++ {
+		if self.status[j] == columnBasic {
+			continue
+		}
+		lower, upper := self.lower[j], self.upper[j]
+		hasLower, hasUpper := !math.IsInf(lower, -1), !math.IsInf(upper, 1)
+		dj := self.d[j]
+
+		switch {
+		case lower == upper:
+			self.status[j] = columnAtLower
+		case dj > self.tolerances.DualFeasibility:
+			if hasLower {
+				self.status[j] = columnAtLower
+			} else {
+				dualFeasible = false
+				self.setNonbasic(j)
+			}
+		case dj < -self.tolerances.DualFeasibility:
+			if hasUpper {
+				self.status[j] = columnAtUpper
+			} else {
+				dualFeasible = false
+				self.setNonbasic(j)
+			}
+		case self.status[j] == columnAtLower && hasLower:
+		case self.status[j] == columnAtUpper && hasUpper:
+		default:
+			self.setNonbasic(j)
+		}
+
+		switch self.status[j] {
+		case columnAtLower:
+			self.x[j] = lower
+		case columnAtUpper:
+			self.x[j] = upper
+		default:
+			self.x[j] = 0
+		}
+	}
+	return dualFeasible
+}
+
+// primalFeasible returns true if all basic columns are within their
+// bounds.
+func (self *simplex) primalFeasible() bool {
+	for i := 0; i < self.m; i++ {
+		k := self.head[i]
+		if self.x[k] < self.lower[k]-self.tolerances.PrimalFeasibility || self.x[k] > self.upper[k]+self.tolerances.PrimalFeasibility {
+			return false
+		}
+	}
+	return true
+}
+
+// warmSolve re-optimizes from the basis installed by setBasis: with the
+// dual simplex if the basis is dual feasible, with the primal simplex if it
+// is primal feasible and from scratch otherwise.
+func (self *simplex) warmSolve() int {
+	if self.maxIterations <= 0 {
+		self.maxIterations = max(1000, 20*(self.n+2*self.m))
+	}
+	self.phase = 2
+	for j := range self.cost {
+		self.cost[j] = 0
+	}
+	copy(self.cost, self.model.c)
+
+	self.computeDuals()
+	dualFeasible := self.repairStatus()
+	self.computeValues()
+
+	if dualFeasible {
+		result := self.dual()
+		if result != ResultOptimal {
+			return result
+		}
+		self.feasible = true
+		return self.primal()
+	}
+	if self.primalFeasible() {
+		self.feasible = true
+		return self.primal()
+	}
+	return self.solve()
+}
+
+// dual runs the dual simplex method from a dual feasible basis until the
+// basis is primal feasible as well.
+func (self *simplex) dual() int {
+	rho := make([]float64, self.m)
+	w := make([]float64, self.m)
+	row := make([]float64, self.n+self.m)
+	self.degenerateSteps = 0
+	self.bland = false
+
+	for {
+		// leaving row: the largest bound violation
+		leaving := -1
+		worst := 0.0
+		for i := 0; i < self.m; i++ {
+			k := self.head[i]
+			violation := math.Max(self.lower[k]-self.x[k], self.x[k]-self.upper[k])
+			if violation <= self.tolerances.PrimalFeasibility {
+				continue
+			}
+			if leaving < 0 || (self.bland && k < self.head[leaving]) ||
+				(!self.bland && violation > worst) {
+				worst = violation
+				leaving = i
+			}
+		}
+		if leaving < 0 {
+			return ResultOptimal
+		}
+
+		if self.iterations >= self.maxIterations {
+			return self.limitResult()
+		}
+		if result, ok := interrupted(self.ctx); ok {
+			return result
+		}
+		self.iterations++
+
+		k := self.head[leaving]
+		toLower := self.x[k] < self.lower[k]
+
+		for i := range rho {
+			rho[i] = 0
+		}
+		rho[leaving] = 1
+		self.factor.btran(rho)
+
+		// entering column: the smallest ratio |d_j / alpha_j| among the
+		// columns that move the leaving column towards its bound; the
+		// pivots of a row of small entries may be small as well
+		for j := range row {
+			row[j] = 0
+			if self.status[j] != columnBasic && self.lower[j] != self.upper[j] {
+				row[j] = self.dot(rho, j)
+			}
+		}
+		tolerance := self.tolerances.Pivot * math.Min(maxAbs(row), 1)
+		entering := -1
+		ratio := math.Inf(1)
+		pivotValue := 0.0
+		for j, alpha := range row {
+			if math.Abs(alpha) <= tolerance {
+				continue
+			}
+			if toLower {
+				alpha = -alpha
+			}
+			// the leaving column changes by -alpha per unit of x_j
+			switch self.status[j] {
+			case columnAtLower:
+				if alpha < 0 {
+					continue
+				}
+			case columnAtUpper:
+				if alpha > 0 {
+					continue
+				}
+			}
+
+			t := math.Abs(self.d[j]) / math.Abs(alpha)
+			if t < ratio-self.tolerances.Pivot ||
+				(t <= ratio+self.tolerances.Pivot && !self.bland &&
+					math.Abs(alpha) > pivotValue) {
+				ratio = t
+				entering = j
+				pivotValue = math.Abs(alpha)
+			}
+		}
+
+		// the small entries of a row may be rounding errors of the updates
+		// of the factorization, which a fresh one removes
+		if pivotValue < self.tolerances.Pivot && self.updates > 0 {
+			if !self.refactor() {
+				return ResultNumFailure
+			}
+			self.computeValues()
+			self.computeDuals()
+			continue
+		}
+
+		if entering < 0 {
+			// the row proves that the bounds can not be satisfied; the
+			// constraint with the largest weight in it is reported
+			weight := 0.0
+			for i := 0; i < self.m; i++ {
+				if math.Abs(rho[i]) > weight {
+					weight = math.Abs(rho[i])
+					self.infeasibleRow = i
+				}
+			}
+			return ResultInfeasible
+		}
+
+		if ratio < self.tolerances.Pivot {
+			self.degenerateSteps++
+			if self.degenerateSteps > simplexStallLimit {
+				self.bland = true
+			}
+		} else {
+			self.degenerateSteps = 0
+			self.bland = false
+		}
+
+		self.column(entering, w)
+		self.factor.ftran(w)
+		if !self.pivot(entering, leaving, toLower, w) {
+			return ResultNumFailure
+		}
+		self.computeValues()
+		self.computeDuals()
+	}
+}
+
+// basisStatus returns the status of the structural and logical columns, or
+// nil if an artificial column is still basic.
+func (self *simplex) basisStatus() []int {
+	for i := 0; i < self.m; i++ {
+		if self.head[i] >= self.n+self.m {
+			return nil
+		}
+	}
+	status := make([]int, self.n+self.m)
+	copy(status, self.status)
+	return status
+}
+
+// DualSimplexSolver solves the same linear programs as SimplexSolver but
+// keeps the optimal basis of the last solve. After right sides, variable
+// bounds or added constraints the old basis stays dual feasible and the
+// next Solve re-optimizes from it with the dual simplex method. A changed
+// objective function is re-optimized with the primal simplex method from
+// the old basis. Changes to the left sides or operators of constraints and
+// to the tolerances, the scaling or the presolve of the specification
+// discard the basis.
+//
+// Without presolve the model and the factorized basis of the last solve
+// are kept as well and the changes of the specification are applied to
+// them: new bounds, right sides and objectives as they are, new variables
+// as nonbasic columns and new or removed constraints with a fresh
+// factorization of the basis. With presolve the reduced model is built
+// for each solve and the basis is mapped to it.
+type DualSimplexSolver struct {
+	ls            *LinearSpec
+	maxIterations int
+	iterations    int
+	result        int
+	infeasible    *Constraint
+	stats         SolveStats
+	// the last optimal basis for the sensitivity analysis
+	optimal *simplex
+	ray     *UnboundedRay
+
+	// basis of the last optimal solve
+	columnStatus map[columnKey]int
+	rowStatus    map[*Constraint]int
+	warmStarted  bool
+	// simplex of the last solve that the changes update, nil if the next
+	// solve builds its model
+	kept     *simplex
+	columnOf map[*Variable]int
+	rowOf    map[*Constraint]int
+}
+
+func NewDualSimplexSolver(ls *LinearSpec) *DualSimplexSolver {
+	ds := &DualSimplexSolver{}
+	ds.ls = ls
+	ds.result = ResultError
+
+	return ds
+}
+
+// SetMaxIterations sets the iteration limit; 0 selects a limit based on
+// the size of the problem.
+func (self *DualSimplexSolver) SetMaxIterations(iterations int) {
+	self.maxIterations = iterations
+}
+
+// Iterations gets the number of simplex iterations of the last solve.
+func (self *DualSimplexSolver) Iterations() int {
+	return self.iterations
+}
+
+// WarmStarted returns true if the last solve started from the basis of the
+// previous one.
+func (self *DualSimplexSolver) WarmStarted() bool {
+	return self.warmStarted
+}
+
+// InfeasibleConstraint gets a constraint that could not be satisfied by the
+// last solve if it was infeasible.
+func (self *DualSimplexSolver) InfeasibleConstraint() *Constraint {
+	return self.infeasible
+}
+
+// Stats gets the statistics of the last solve.
+func (self *DualSimplexSolver) Stats() SolveStats {
+	return self.stats
+}
+
+// UnboundedRay gets the direction of unboundedness of the last solve, nil
+// if it was not unbounded.
+func (self *DualSimplexSolver) UnboundedRay() *UnboundedRay {
+	return self.ray
+}
+
+// Sensitivity gets the sensitivity analysis of the last solve, nil if it
+// was not optimal or the specification changed since.
+func (self *DualSimplexSolver) Sensitivity() *Sensitivity {
+	if self.optimal == nil {
+		return nil
+	}
+	return self.optimal.sensitivity()
+}
+
+// DiscardBasis forces the next solve to start from scratch.
+func (self *DualSimplexSolver) DiscardBasis() {
+	self.columnStatus = nil
+	self.rowStatus = nil
+	self.kept = nil
+}
+
+func (self *DualSimplexSolver) Solve() int {
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves like Solve but stops with ResultTimeout or
+// ResultUserAbort when ctx is done. The basis is discarded then.
+func (self *DualSimplexSolver) SolveContext(ctx context.Context) int {
+	self.iterations = 0
+	self.optimal = nil
+	self.ray = nil
+	self.warmStarted = false
+	presolved := newPresolve(self.ls)
+	self.infeasible = presolved.infeasible
+	self.stats = SolveStats{PresolvedRows: presolved.removedRowCount,
+		PresolvedColumns: presolved.removedColumnCount}
+	if presolved.result != ResultOptimal {
+		solved := presolved.result == ResultPresolve
+		presolved.postsolve(solved, solved)
+		self.DiscardBasis()
+		self.result = presolved.result
+		return self.result
+	}
+
+	s := self.kept
+	self.kept = nil
+	if s != nil {
+		s.restart()
+		self.warmStarted = true
+	} else {
+		s = newSimplex(presolved.model())
+		self.warmStarted = self.columnStatus != nil &&
+			s.setBasis(self.mapBasis(s.model, presolved))
+	}
+	s.maxIterations = self.maxIterations
+	s.ctx = ctx
+
+	if self.warmStarted {
+		self.result = s.warmSolve()
+	} else {
+		self.result = s.solve()
+	}
+	self.iterations = s.iterations
+	self.infeasible = presolved.original(s.infeasibleConstraint())
+	if self.result == ResultUnbounded {
+		self.ray = presolved.postsolveRay(s.model.unboundedRay(s.ray))
+	}
+	self.stats.SimplexIterations = s.iterations
+	if s.feasible {
+		self.stats.ActiveConstraints = s.activeRows()
+	}
+
+	values := s.feasible && self.result != ResultNumFailure
+	if values {
+		s.model.setValues(s.x)
+	}
+	if self.result == ResultOptimal {
+		s.model.setDuals(s.y, s.d)
+		self.optimal = s
+	} else {
+		s.model.setDuals(nil, nil)
+	}
+	presolved.postsolve(values, self.result == ResultOptimal)
+
+	// an infeasible basis of the dual simplex stays dual feasible and is
+	// worth keeping as well
+	self.columnStatus = nil
+	self.rowStatus = nil
+	if self.result != ResultOptimal && self.result != ResultInfeasible {
+		return self.result
+	}
+	if status := s.basisStatus(); status != nil {
+		self.columnStatus = make(map[columnKey]int, s.n)
+		for j := 0; j < s.n; j++ {
+			self.columnStatus[s.model.keys[j]] = status[j]
+		}
+		self.rowStatus = make(map[*Constraint]int, s.m)
+		for i := 0; i < s.m; i++ {
+			self.rowStatus[presolved.original(s.model.constraints[i])] = status[s.n+i]
+		}
+		if !presolved.active {
+			self.kept = s
+			self.index()
+		}
+	}
+	return self.result
+}
+
+// index maps the variables and constraints to the columns and rows of the
+// kept simplex.
+func (self *DualSimplexSolver) index() {
+	model := self.kept.model
+	self.columnOf = model.columnMap()
+	self.rowOf = make(map[*Constraint]int, model.rows)
+	for i, constraint := range model.constraints {
+		self.rowOf[constraint] = i
+	}
+}
+
+// update returns the kept simplex for a change of the specification, nil
+// if there is none. The sensitivity analysis of the last solve is out of
+// date then.
+func (self *DualSimplexSolver) update() *simplex {
+	if self.kept != nil {
+		self.optimal = nil
+	}
+	return self.kept
+}
+
+// mapBasis translates the saved basis to the columns and rows of model,
+// whose rows are those of presolved.
+// New columns start nonbasic and the logicals of new rows basic.
+func (self *DualSimplexSolver) mapBasis(model *lpModel, presolved *presolve) []int {
+	status := make([]int, model.columns+model.rows)
+	for j := 0; j < model.columns; j++ {
+		if s, ok := self.columnStatus[model.keys[j]]; ok {
+			status[j] = s
+		} else {
+			status[j] = columnAtLower
+		}
+	}
+	for i := 0; i < model.rows; i++ {
+		if s, ok := self.rowStatus[presolved.original(model.constraints[i])]; ok {
+			status[model.columns+i] = s
+		} else {
+			status[model.columns+i] = columnBasic
+		}
+	}
+	return status
+}
+
+func (self *DualSimplexSolver) VariableAdded(variable *Variable) bool {
+	if s := self.update(); s != nil {
+		s.model.addColumn(variable, variable.Min(), variable.Max(), 0)
+		s.model.growColumns()
+		s.addColumn()
+		self.columnOf[variable] = s.n - 1
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) VariableRemoved(variable *Variable) bool {
+	if s := self.update(); s != nil {
+		j, ok := self.columnOf[variable]
+		if !ok || !s.removeColumn(j) {
+			self.kept = nil
+			return true
+		}
+		self.index()
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) VariableRangeChanged(variable *Variable) bool {
+	if s := self.update(); s != nil {
+		if j, ok := self.columnOf[variable]; ok {
+			factor := s.model.columnFactor(j)
+			s.model.lower[j] = variable.Min() / factor
+			s.model.upper[j] = variable.Max() / factor
+			s.lower[j], s.upper[j] = s.model.lower[j], s.model.upper[j]
+		}
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) ConstraintAdded(constraint *Constraint) bool {
+	if s := self.update(); s != nil {
+		if !s.model.appendRow(constraint, self.columnOf, self.ls.Scaling()) {
+			self.kept = nil
+			return true
+		}
+		for s.n < s.model.columns {
+			s.addColumn()
+		}
+		if !s.addRow() {
+			self.kept = nil
+			return true
+		}
+		self.rowOf[constraint] = s.m - 1
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) ConstraintRemoved(constraint *Constraint) bool {
+	if s := self.update(); s != nil {
+		i, ok := self.rowOf[constraint]
+		if !ok {
+			return true
+		}
+		if !s.removeRow(i) {
+			self.kept = nil
+			return true
+		}
+		// the deviation columns of a soft constraint
+		for j := s.n - 1; j >= 0; j-- {
+			if s.model.keys[j].constraint == constraint && !s.removeColumn(j) {
+				self.kept = nil
+				return true
+			}
+		}
+		self.index()
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) LeftSideChanged(constraint *Constraint) bool {
+	// a new constraint sets its left side before it is added
+	if constraint.Index() >= 0 {
+		self.DiscardBasis()
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) RightSideChanged(constraint *Constraint) bool {
+	if s := self.update(); s != nil {
+		if i, ok := self.rowOf[constraint]; ok {
+			s.model.b[i] = constraint.RightSide() * s.model.rowFactor(i)
+		}
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) OperatorChanged(constraint *Constraint) bool {
+	self.DiscardBasis()
+	return true
+}
+
+func (self *DualSimplexSolver) ObjectiveChanged() bool {
+	if s := self.update(); s != nil {
+		s.model.setObjective(self.ls)
+	}
+	return true
+}
+
+func (self *DualSimplexSolver) SettingsChanged() bool {
+	self.DiscardBasis()
+	return true
+}
+
+// SaveModel writes the specification in LP format.
+func (self *DualSimplexSolver) SaveModel(fileName string) error {
+	return saveLP(self.ls, fileName)
+}
+
+// MinSize minimizes width and height independently subject to the hard
+// constraints. An unbounded dimension is reported as -Inf.
+func (self *DualSimplexSolver) MinSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, 1, self.maxIterations)
+}
+
+// MaxSize maximizes width and height independently subject to the hard
+// constraints. An unbounded dimension is reported as +Inf.
+func (self *DualSimplexSolver) MaxSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, -1, self.maxIterations)
+}
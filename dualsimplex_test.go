@@ -0,0 +1,251 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// newColumnLayout creates n columns of at least minWidth between 0 and
+// the right border.
+func newColumnLayout(ls *LinearSpec, n int, minWidth float64) ([]*Variable, *Constraint) {
+	tabs := make([]*Variable, n+1)
+	for i := range tabs {
+		tabs[i], _ = ls.AddVariable(nil)
+		tabs[i].SetRange(0, math.Inf(1))
+	}
+	ls.AddConstraint2([]float64{1.0}, tabs[:1], OperatorEQ, 0)
+	for i := 0; i < n; i++ {
+		ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{tabs[i+1], tabs[i]}, OperatorGE, minWidth)
+	}
+	border, _ := ls.AddConstraint2([]float64{1.0}, tabs[n:], OperatorEQ, float64(n)*minWidth)
+	return tabs, border
+}
+
+func TestDualSimplexWarmStart(t *testing.T) {
+	fmt.Println("Test DualSimplex WarmStart")
+
+	ls := NewLinearSpec()
+	solver := NewDualSimplexSolver(ls)
+	ls.SetSolver(solver)
+
+	tabs, border := newColumnLayout(ls, 8, 10)
+	// prefer the first tab as far right as possible
+	ls.SetObjective1([]float64{-1.0}, tabs[1:2], OptMinimize)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if solver.WarmStarted() {
+		t.Error("first solve warm started")
+	}
+	checkValue(t, "tab 1", tabs[1].Value(), 10)
+
+	// resize the window
+	for _, width := range []float64{100, 150, 70, 95, 80} {
+		border.SetRightSide(width)
+		result, _ := ls.Solve()
+		if width < 80 {
+			if result != ResultInfeasible {
+				t.Errorf("width %v: result = %v, want %v", width, result, ResultInfeasible)
+			}
+			continue
+		}
+		if result != ResultOptimal {
+			t.Fatalf("width %v: result = %v, want %v", width, result, ResultOptimal)
+		}
+		if !solver.WarmStarted() {
+			t.Errorf("width %v: solve did not warm start", width)
+		}
+		checkValue(t, "tab 1", tabs[1].Value(), width-70)
+		checkValue(t, "tab 8", tabs[8].Value(), width)
+	}
+
+	// the same model solved from scratch
+	cold := NewLinearSpec()
+	cold.SetSolver(NewSimplexSolver(cold))
+	coldTabs, coldBorder := newColumnLayout(cold, 8, 10)
+	cold.SetObjective1([]float64{-1.0}, coldTabs[1:2], OptMinimize)
+	coldBorder.SetRightSide(80)
+	cold.Solve()
+	checkValue(t, "objective", ls.ObjectiveValue(), cold.ObjectiveValue())
+
+	// nothing changed, nothing to do
+	ls.Solve()
+	if solver.Iterations() != 0 {
+		t.Errorf("unchanged re-solve took %v iterations", solver.Iterations())
+	}
+
+	// an added constraint keeps the basis dual feasible
+	ls.AddConstraint2([]float64{1.0}, tabs[1:2], OperatorLE, 5)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("result = %v, want %v", result, ResultInfeasible)
+	}
+	border.SetRightSide(60)
+	tabs[2].SetMin(0)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("result = %v, want %v", result, ResultInfeasible)
+	}
+}
+
+func TestDualSimplexBounds(t *testing.T) {
+	fmt.Println("Test DualSimplex Bounds")
+
+	ls := NewLinearSpec()
+	solver := NewDualSimplexSolver(ls)
+	ls.SetSolver(solver)
+
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, 4)
+	y.SetRange(0, 6)
+	ls.AddConstraint2([]float64{3.0, 2.0}, []*Variable{x, y}, OperatorLE, 18)
+	ls.SetObjective1([]float64{3.0, 5.0}, []*Variable{x, y}, OptMaximize)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 36)
+
+	y.SetMax(3)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if !solver.WarmStarted() {
+		t.Error("solve did not warm start")
+	}
+	checkValue(t, "x", x.Value(), 4)
+	checkValue(t, "y", y.Value(), 3)
+	checkValue(t, "objective", ls.ObjectiveValue(), 27)
+
+	// a new objective is re-optimized from the old basis
+	ls.SetObjective1([]float64{1.0, 1.0}, []*Variable{x, y}, OptMinimize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 0)
+}
+
+func TestDualSimplexResolve(t *testing.T) {
+	fmt.Println("Test DualSimplex Resolve")
+
+	ls := NewLinearSpec()
+	solver := NewDualSimplexSolver(ls)
+	ls.SetSolver(solver)
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, 4)
+	y.SetRange(0, 6)
+	c, _ := ls.AddConstraint2([]float64{3.0, 2.0}, []*Variable{x, y}, OperatorLE, 18)
+	ls.SetObjective1([]float64{3.0, 5.0}, []*Variable{x, y}, OptMaximize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+
+	// an unchanged specification gets its solution back
+	x.SetValue(-1)
+	y.SetValue(-1)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if solver.Iterations() != 0 {
+		t.Errorf("unchanged re-solve took %v iterations", solver.Iterations())
+	}
+	checkValue(t, "x", x.Value(), 2)
+	checkValue(t, "y", y.Value(), 6)
+	checkValue(t, "dual", c.Dual(), 1)
+
+	// new settings discard the basis
+	for _, change := range []string{"tolerances", "scaling", "presolve"} {
+		switch change {
+		case "tolerances":
+			tolerances := DefaultTolerances()
+			tolerances.PrimalFeasibility = 1e-10
+			ls.SetTolerances(tolerances)
+		case "scaling":
+			ls.SetScaling(ScaleEquilibrate)
+		default:
+			ls.SetPresolve(true)
+		}
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("%v: result = %v, want %v", change, result, ResultOptimal)
+		}
+		if solver.WarmStarted() {
+			t.Errorf("%v: solve warm started", change)
+		}
+		checkValue(t, "objective", ls.ObjectiveValue(), 36)
+	}
+}
+
+func TestDualSimplexKeptModel(t *testing.T) {
+	fmt.Println("Test DualSimplex KeptModel")
+
+	for _, scaling := range []int{ScaleNone, ScaleGeometric, ScaleEquilibrate} {
+		ls := NewLinearSpec()
+		solver := NewDualSimplexSolver(ls)
+		ls.SetSolver(solver)
+		ls.SetScaling(scaling)
+
+		tabs, border := newColumnLayout(ls, 4, 10)
+		ls.SetObjective1([]float64{-1.0}, tabs[1:2], OptMinimize)
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("scaling %v: result = %v, want %v", scaling, result, ResultOptimal)
+		}
+		kept := solver.kept
+		if kept == nil {
+			t.Fatalf("scaling %v: the simplex was not kept", scaling)
+		}
+		solve := func(change string, tab1 float64) {
+			t.Helper()
+			if result, _ := ls.Solve(); result != ResultOptimal {
+				t.Fatalf("scaling %v, %v: result = %v, want %v", scaling, change, result, ResultOptimal)
+			}
+			if solver.kept != kept {
+				t.Errorf("scaling %v, %v: the model was rebuilt", scaling, change)
+			}
+			checkValue(t, change, tabs[1].Value(), tab1)
+		}
+
+		// bounds and right sides keep the factorization as well
+		factor := kept.factor
+		border.SetRightSide(60)
+		solve("right side", 30)
+		tabs[1].SetMax(25)
+		solve("bound", 25)
+		ls.SetObjective1([]float64{1.0, 1.0}, tabs[1:3], OptMaximize)
+		solve("objective", 25)
+		checkValue(t, "tab 2", tabs[2].Value(), 40)
+		if kept.factor != factor {
+			t.Errorf("scaling %v: the basis was factorized again", scaling)
+		}
+
+		// new and removed rows and columns
+		limit, _ := ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{tabs[2], tabs[1]}, OperatorLE, 12)
+		solve("added constraint", 25)
+		checkValue(t, "tab 2", tabs[2].Value(), 37)
+		soft, _ := ls.AddConstraint4([]float64{1.0}, tabs[1:2], OperatorLE, 20, 10, 10)
+		solve("added soft constraint", 20)
+		checkValue(t, "tab 2", tabs[2].Value(), 32)
+		ls.RemoveConstraint(limit)
+		solve("removed constraint", 20)
+		checkValue(t, "tab 2", tabs[2].Value(), 40)
+		ls.RemoveConstraint(soft)
+		solve("removed soft constraint", 25)
+		extra, _ := ls.AddVariable(nil)
+		extra.SetRange(0, 5)
+		ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{tabs[1], extra}, OperatorLE, 22)
+		solve("added variable", 22)
+		ls.RemoveVariable(extra)
+		solve("removed variable", 25)
+
+		// a new left side rebuilds the model
+		border.SetLeftSide1([]float64{0.5}, tabs[4:])
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("scaling %v: result = %v, want %v", scaling, result, ResultOptimal)
+		}
+		if solver.kept == kept || solver.WarmStarted() {
+			t.Errorf("scaling %v: a new left side kept the model", scaling)
+		}
+		checkValue(t, "tab 4", tabs[4].Value(), 120)
+	}
+}
@@ -1,6 +1,7 @@
 package lp
 
 import (
+	"context"
 	"fmt"
 	"math"
 )
@@ -8,10 +9,24 @@ import (
 type EquationSystem struct {
 	rowIndices    []int
 	columnIndices []int
-	matrix        [][]float64
-	b             []float64
-	rows          int
-	columns       int
+	// sparse rows, indexed by the original row and column
+	matrix  *sparseMatrix
+	b       []float64
+	rows    int
+	columns int
+	// combination of the original rows that gives each row
+	multipliers *sparseMatrix
+	// the row that proves infeasibility, -1 if none
+	infeasibleRow int
+	tolerances    Tolerances
+	// factors of the original rows and columns, nil if not scaled
+	rowScale, columnScale []float64
+	// the original rows that may be left unsatisfied, nil if there are none
+	soft []bool
+	// upper bounds of the original columns, nil if there are none; a
+	// flipped column holds upper - x instead of x
+	upper   []float64
+	flipped []bool
 }
 
 func NewEquationSystem(rows, columns int) *EquationSystem {
@@ -19,19 +34,22 @@ func NewEquationSystem(rows, columns int) *EquationSystem {
 	es.rows = rows
 	es.columns = columns
 
-	es.matrix = initMatrixSlice(es.rows, es.columns)
+	es.matrix = newSparseMatrix(es.rows, es.columns)
 	es.b = make([]float64, es.columns)
 
 	for i := 0; i < es.columns; i++ {
 		es.b[i] = 0
 	}
-	zeroMatrix(es.matrix, es.rows, es.columns)
 
 	es.rowIndices = make([]int, es.rows)
 	es.columnIndices = make([]int, es.columns)
+	es.multipliers = newSparseMatrix(es.rows, es.rows)
+	es.infeasibleRow = -1
+	es.tolerances = DefaultTolerances()
 
 	for i := 0; i < es.rows; i++ {
 		es.rowIndices[i] = i
+		*es.multipliers.ref(i, i) = 1
 	}
 	for i := 0; i < es.columns; i++ {
 		es.columnIndices[i] = i
@@ -40,6 +58,86 @@ func NewEquationSystem(rows, columns int) *EquationSystem {
 	return es
 }
 
+// SetTolerances sets the tolerances of the elimination and of solveEq.
+func (self *EquationSystem) SetTolerances(tolerances Tolerances) {
+	self.tolerances = tolerances
+}
+
+// Scale scales the rows and columns by the scaling mode. It must be called
+// before the elimination; Results and Multipliers undo the scaling.
+func (self *EquationSystem) Scale(mode int) {
+	if mode == ScaleNone {
+		return
+	}
+	a := newSparseMatrix(self.rows, len(self.columnIndices))
+	for r := 0; r < self.rows; r++ {
+		a.rows[r] = self.matrix.rows[self.rowIndices[r]]
+	}
+	rowScale, columnScale := scaleFactors(a, mode, nil)
+
+	self.rowScale = make([]float64, len(self.rowIndices))
+	for i := range self.rowScale {
+		self.rowScale[i] = 1
+	}
+	for r := 0; r < self.rows; r++ {
+		self.rowScale[self.rowIndices[r]] = rowScale[r]
+		row := &self.matrix.rows[self.rowIndices[r]]
+		for k, j := range row.index {
+			row.value[k] *= rowScale[r] * columnScale[j]
+		}
+		self.b[r] *= rowScale[r]
+	}
+	self.columnScale = columnScale
+}
+
+// SetSoft marks a row whose right side may be left unsatisfied, so a
+// dependent soft row is removed without proving infeasibility.
+func (self *EquationSystem) SetSoft(row int) {
+	if self.soft == nil {
+		self.soft = make([]bool, len(self.rowIndices))
+	}
+	self.soft[self.rowIndices[row]] = true
+}
+
+// SetUpper sets an upper bound of the non-negative variable of a column,
+// which solveEq keeps without a row of its own.
+func (self *EquationSystem) SetUpper(column int, upper float64) {
+	if self.upper == nil {
+		self.upper = make([]float64, len(self.columnIndices))
+		for j := range self.upper {
+			self.upper[j] = math.Inf(1)
+		}
+		self.flipped = make([]bool, len(self.columnIndices))
+	}
+	self.upper[self.columnIndices[column]] = upper
+}
+
+// scaledUpper gets the upper bound of an original column in the scaled
+// units of the matrix.
+func (self *EquationSystem) scaledUpper(j int) float64 {
+	if self.upper == nil {
+		return math.Inf(1)
+	}
+	if self.columnScale != nil {
+		return self.upper[j] / self.columnScale[j]
+	}
+	return self.upper[j]
+}
+
+// flip replaces the variable x of a column by upper - x.
+func (self *EquationSystem) flip(column int) {
+	j := self.columnIndices[column]
+	upper := self.scaledUpper(j)
+	for r := 0; r < self.rows; r++ {
+		row := &self.matrix.rows[self.rowIndices[r]]
+		if value := row.at(j); value != 0 {
+			self.b[r] -= value * upper
+			*row.ref(j) = -value
+		}
+	}
+	self.flipped[j] = !self.flipped[j]
+}
+
 func (self *EquationSystem) SetRows(rows int) {
 	self.rows = rows
 }
@@ -52,8 +150,14 @@ func (self *EquationSystem) Columns() int {
 	return self.columns
 }
 
+// A returns a pointer to a coefficient and stores it if it is zero, use at
+// to read coefficients.
 func (self *EquationSystem) A(row, column int) *float64 {
-	return &self.matrix[self.rowIndices[row]][self.columnIndices[column]]
+	return self.matrix.ref(self.rowIndices[row], self.columnIndices[column])
+}
+
+func (self *EquationSystem) at(row, column int) float64 {
+	return self.matrix.at(self.rowIndices[row], self.columnIndices[column])
 }
 
 func (self *EquationSystem) B(row int) *float64 {
@@ -64,12 +168,44 @@ func (self *EquationSystem) Results(results []float64, size int) {
 	for i := 0; i < size; i++ {
 		results[i] = 0
 	}
-	for i := 0; i < self.columns; i++ {
+	// the first rows columns are basic
+	for i := 0; i < self.rows; i++ {
 		index := self.columnIndices[i]
-		if index < self.rows {
+		if index < size {
 			results[index] = self.b[i]
 		}
 	}
+	for j := 0; j < size; j++ {
+		if self.flipped != nil && self.flipped[j] {
+			results[j] = self.scaledUpper(j) - results[j]
+		}
+		if self.columnScale != nil {
+			results[j] *= self.columnScale[j]
+		}
+	}
+}
+
+// Multipliers gets the weights of the original rows whose sum is the
+// current row.
+func (self *EquationSystem) Multipliers(row int) []float64 {
+	multipliers := make([]float64, self.multipliers.n)
+	vector := &self.multipliers.rows[self.rowIndices[row]]
+	for k, i := range vector.index {
+		multipliers[i] = vector.value[k]
+		if self.rowScale != nil {
+			multipliers[i] *= self.rowScale[i]
+		}
+	}
+	return multipliers
+}
+
+// InfeasibleRow gets the row that proved the last solveEq infeasible, -1 if
+// there is none. It has a negative right side and no negative coefficient
+// outside of the basis, so its multipliers are a Farkas certificate. After
+// RemoveLinearlyDependentRows it is a dependent hard row whose multipliers
+// give a vanishing left side and a negative right side.
+func (self *EquationSystem) InfeasibleRow() int {
+	return self.infeasibleRow
 }
 
 func (self *EquationSystem) SwapColumn(i, j int) {
@@ -86,40 +222,52 @@ func (self *EquationSystem) SwapRow(i, j int) {
 }
 
 func (self *EquationSystem) GaussianElimination() bool {
-	// basic solve
-	for i := 0; i < self.rows; i++ {
-		// find none zero element
-		swapRow := -1
-		for r := i; r < self.rows; r++ {
-			value := self.matrix[self.rowIndices[r]][self.columnIndices[i]]
-			if fuzzyEquals(value, 0) {
-				continue
+	// the pivots of a sparse LU keep the fill-in low
+	logical := make([]int, len(self.columnIndices))
+	for c := range logical {
+		logical[c] = -1
+	}
+	for c := 0; c < self.columns; c++ {
+		logical[self.columnIndices[c]] = c
+	}
+	a := newSparseMatrix(self.rows, self.columns)
+	for r := 0; r < self.rows; r++ {
+		row := &self.matrix.rows[self.rowIndices[r]]
+		for k, j := range row.index {
+			if logical[j] >= 0 {
+				*a.ref(r, logical[j]) = row.value[k]
 			}
-			swapRow = r
-			break
 		}
+	}
+	lu := newSparseLU(self.rows, self.tolerances.Zero)
+	if !lu.factorizeRows(a) {
+		return false
+	}
 
-		if swapRow == -1 {
-			swapColumn := -1
-			for c := i + 1; c < self.columns; c++ {
-				value := self.matrix[self.rowIndices[i]][self.columnIndices[c]]
-				if fuzzyEquals(value, 0) {
-					continue
-				}
-				swapRow = i
-				swapColumn = c
-				break
-			}
-
-			if swapColumn == -1 {
-				return false
-			}
-			self.SwapColumn(i, swapColumn)
-		}
-		if i != swapRow {
-			self.SwapRow(i, swapRow)
+	// move the pivots to the diagonal
+	rowIndices := make([]int, self.rows)
+	b := make([]float64, self.rows)
+	columnIndices := make([]int, 0, self.columns)
+	isPivot := make([]bool, self.columns)
+	for i, r := range lu.pivotRows {
+		rowIndices[i] = self.rowIndices[r]
+		b[i] = self.b[r]
+		columnIndices = append(columnIndices, self.columnIndices[lu.pivotColumns[i]])
+		isPivot[lu.pivotColumns[i]] = true
+	}
+	for c := 0; c < self.columns; c++ {
+		if !isPivot[c] {
+			columnIndices = append(columnIndices, self.columnIndices[c])
 		}
+	}
+	copy(self.rowIndices, rowIndices)
+	copy(self.b, b)
+	copy(self.columnIndices, columnIndices)
 
+	for i := 0; i < self.rows; i++ {
+		if self.tolerances.isZero(self.at(i, i)) {
+			return false
+		}
 		// normalize
 		self.eliminateColumn(i, i+1, self.rows-1)
 	}
@@ -148,31 +296,80 @@ func (self *EquationSystem) RemoveLinearlyDependentRows() {
 		oldB[r] = self.b[r]
 	}
 
-	temp := initMatrixSlice(self.rows, self.columns)
-	independentRows := make([]bool, self.rows)
+	// a dependent hard row whose right side does not vanish with its left
+	// side proves infeasibility, the rows are kept then
+	self.infeasibleRow = -1
+	var hardRows []int
+	for r := 0; r < self.rows; r++ {
+		if self.soft == nil || !self.soft[self.rowIndices[r]] {
+			hardRows = append(hardRows, r)
+		}
+	}
+	temp := newSparseMatrix(len(hardRows), self.columns)
+	combinations := newSparseMatrix(len(hardRows), len(hardRows))
+	for k, r := range hardRows {
+		temp.rows[k] = self.matrix.rows[self.rowIndices[r]].copy()
+		*combinations.ref(k, k) = 1
+	}
+	// without soft rows the hard rows are all rows
+	independentRows := make([]bool, len(hardRows))
+	nIndependent := sparseDependencies(temp, independentRows, self.tolerances.Zero, combinations)
+	for k, r := range hardRows {
+		combination := &combinations.rows[k]
+		residual := 0.0
+		for l, i := range combination.index {
+			residual += combination.value[l] * oldB[hardRows[i]]
+		}
+		if self.rowScale != nil {
+			residual /= self.rowScale[self.rowIndices[r]]
+		}
+		if independentRows[k] || math.Abs(residual) <= self.tolerances.PrimalFeasibility {
+			continue
+		}
+		// the weights with a negative right side, as solveEq leaves them
+		if residual > 0 {
+			combination.divide(-1)
+		}
+		var multipliers sparseVector
+		for l, i := range combination.index {
+			multipliers.axpy(combination.value[l], &self.multipliers.rows[self.rowIndices[hardRows[i]]])
+		}
+		self.multipliers.rows[self.rowIndices[r]] = multipliers
+		self.infeasibleRow = r
+		return
+	}
 
-	// copy to temp
-	copyMatrix(self.matrix, temp, self.rows, self.columns)
-	nIndependent := computeDependencies(temp, self.rows, self.columns, independentRows)
+	if len(hardRows) < self.rows {
+		// the soft rows are only kept if they are independent of the hard
+		// rows
+		orderedRows := hardRows
+		for r := 0; r < self.rows; r++ {
+			if self.soft[self.rowIndices[r]] {
+				orderedRows = append(orderedRows, r)
+			}
+		}
+		temp = newSparseMatrix(self.rows, self.columns)
+		for k, r := range orderedRows {
+			temp.rows[k] = self.matrix.rows[self.rowIndices[r]].copy()
+		}
+		orderedIndependent := make([]bool, self.rows)
+		nIndependent = sparseOrderedDependencies(temp, orderedIndependent, self.tolerances.Zero)
+		independentRows = make([]bool, self.rows)
+		for k, r := range orderedRows {
+			independentRows[r] = orderedIndependent[k]
+		}
+	}
 	if nIndependent == self.rows {
 		return
 	}
 
-	// remove the rows
+	// move the independent rows to the front, keeping their order; the rows
+	// between nKept and i are dependent
+	nKept := 0
 	for i := 0; i < self.rows; i++ {
-		if !independentRows[i] {
-			lastDepRow := -1
-			for d := self.rows - 1; d > i; d-- {
-				if independentRows[d] {
-					lastDepRow = d
-					break
-				}
-			}
-			if lastDepRow < 0 {
-				break
-			}
-			self.SwapRow(i, lastDepRow)
-			self.rows--
+		if independentRows[i] {
+			self.SwapRow(nKept, i)
+			nKept++
 		}
 	}
 	self.rows = nIndependent
@@ -182,7 +379,7 @@ func (self *EquationSystem) RemoveUnusedVariables() {
 	for c := 0; c < self.columns; c++ {
 		used := false
 		for r := 0; r < self.rows; r++ {
-			if !fuzzyEquals(self.matrix[r][self.columnIndices[c]], 0) {
+			if !self.tolerances.isZero(self.at(r, c)) {
 				used = true
 				break
 			}
@@ -208,36 +405,35 @@ func (self *EquationSystem) MoveColumnRight(i, target int) {
 func (self *EquationSystem) Print() {
 	for m := 0; m < self.rows; m++ {
 		for n := 0; n < self.columns; n++ {
-			fmt.Printf("%.1f ", self.matrix[self.rowIndices[m]][self.columnIndices[n]])
+			fmt.Printf("%.1f ", self.at(m, n))
 		}
 		fmt.Printf("= %.1f\n", self.b[m])
 	}
 }
 
 func (self *EquationSystem) eliminateColumn(column, startRow, endRow int) {
-	value := self.matrix[self.rowIndices[column]][self.columnIndices[column]]
+	pivotRow := &self.matrix.rows[self.rowIndices[column]]
+	pivotMultipliers := &self.multipliers.rows[self.rowIndices[column]]
+	value := self.at(column, column)
 	if value != 1. {
-		for j := column; j < self.columns; j++ {
-			self.matrix[self.rowIndices[column]][self.columnIndices[j]] /= value
-		}
+		pivotRow.divide(value)
 		self.b[column] /= value
+		pivotMultipliers.divide(value)
 	}
 
 	for r := startRow; r < endRow+1; r++ {
 		if r == column {
 			continue
 		}
-		q := -self.matrix[self.rowIndices[r]][self.columnIndices[column]]
+		q := -self.at(r, column)
 		// don't need to to anything, since matrix is typically sparse
 		// this should save some work
-		if fuzzyEquals(q, 0) {
+		if self.tolerances.isZero(q) {
 			continue
 		}
-		for c := column; c < self.columns; c++ {
-			self.matrix[self.rowIndices[r]][self.columnIndices[c]] +=
-				self.matrix[self.rowIndices[column]][self.columnIndices[c]] * q
-		}
+		self.matrix.rows[self.rowIndices[r]].axpy(q, pivotRow)
 		self.b[r] += self.b[column] * q
+		self.multipliers.rows[self.rowIndices[r]].axpy(q, pivotMultipliers)
 	}
 }
 
@@ -246,24 +442,29 @@ Solving Inequalities and Proving Farkas's Lemma Made Easy
 David Avis and Bohdan Kaluzny
 The American Mathematical Monthly
 Vol. 111, No. 2 (Feb., 2004), pp. 152-157 */
-func solveEq(system *EquationSystem) bool {
+func solveEq(ctx context.Context, system *EquationSystem) (iterations int, solved bool) {
+	system.infeasibleRow = -1
+
 	// basic solve
 	if !(system.GaussJordan()) {
-		return false
+		return 0, false
 	}
 
 	done := false
 	for !done {
-		smallestB := math.Inf(1)
+		if ctx.Err() != nil {
+			return iterations, false
+		}
+		// Bland's rule on the original column indices, the pivot with the
+		// smallest |b| can cycle
 		smallestBRow := -1
 		for row := 0; row < system.Rows(); row++ {
-			if *system.B(row) > 0 || fuzzyEquals(*system.B(row), 0) {
+			upper := system.scaledUpper(system.columnIndices[row])
+			if system.b[row] > -system.tolerances.PrimalFeasibility &&
+				system.b[row] < upper+system.tolerances.PrimalFeasibility {
 				continue
 			}
-
-			bValue := math.Abs(*system.B(row))
-			if bValue < smallestB {
-				smallestB = bValue
+			if smallestBRow < 0 || system.columnIndices[row] < system.columnIndices[smallestBRow] {
 				smallestBRow = row
 			}
 		}
@@ -271,25 +472,33 @@ func solveEq(system *EquationSystem) bool {
 			done = true
 			break
 		}
+		// a basic variable above its upper bound is flipped, which leaves
+		// it below zero
+		if system.b[smallestBRow] > 0 {
+			system.flip(smallestBRow)
+			system.GaussJordan1(smallestBRow)
+		}
 
 		negValueCol := -1
 		for col := system.Rows(); col < system.Columns(); col++ {
-			value := *system.A(smallestBRow, col)
-			if value > 0 || fuzzyEquals(value, 0) {
+			value := system.at(smallestBRow, col)
+			if value > -system.tolerances.Zero {
 				continue
 			}
-			negValueCol = col
-			break
+			if negValueCol < 0 || system.columnIndices[col] < system.columnIndices[negValueCol] {
+				negValueCol = col
+			}
 		}
 		if negValueCol == -1 {
-			fmt.Printf("can't solve\n")
-			return false
+			system.infeasibleRow = smallestBRow
+			return iterations, false
 		}
+		iterations++
 		system.SwapColumn(smallestBRow, negValueCol)
 
 		// eliminate
 		system.GaussJordan1(smallestBRow)
 	}
 
-	return true
+	return iterations, true
 }
@@ -0,0 +1,123 @@
+package lp
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors of the methods that modify a specification.
+var (
+	ErrLengthMismatch    = errors.New("lp: number of coefficients and variables differ")
+	ErrInvalidVariable   = errors.New("lp: variable is not part of the specification")
+	ErrInvalidConstraint = errors.New("lp: constraint is not part of the specification")
+	ErrInvalidOperator   = errors.New("lp: unknown operator")
+	ErrInvalidDirection  = errors.New("lp: unknown optimization direction")
+	ErrRejected          = errors.New("lp: rejected by the solver")
+	ErrNotEmpty          = errors.New("lp: specification is not empty")
+	ErrNoSolver          = errors.New("lp: no solver")
+	ErrUnsupported       = errors.New("lp: not supported by the solver")
+	ErrNotOptimal        = errors.New("lp: last solve was not optimal")
+	ErrFeasible          = errors.New("lp: problem is feasible")
+	ErrNoCertificate     = errors.New("lp: no certificate of infeasibility")
+	ErrNoRay             = errors.New("lp: no unbounded ray")
+	ErrInvalidTolerances = errors.New("lp: tolerances must be positive and finite")
+	ErrInvalidScaling    = errors.New("lp: unknown scaling mode")
+	ErrPresolved         = errors.New("lp: not available for a presolved solve")
+	ErrNoResiduals       = errors.New("lp: no values to check")
+	ErrNotConvex         = errors.New("lp: quadratic objective is not convex")
+	ErrQuadratic         = errors.New("lp: format has no quadratic objective")
+	ErrInvalidRange      = errors.New("lp: minimum exceeds maximum or is NaN")
+)
+
+// Errors of Solve, MinSize and MaxSize, one for each result type that does
+// not come with a solution.
+var (
+	ErrNoMemory   = errors.New("lp: out of memory")
+	ErrSolver     = errors.New("lp: solver failed")
+	ErrInfeasible = errors.New("lp: problem is infeasible")
+	ErrUnbounded  = errors.New("lp: problem is unbounded")
+	ErrDegenerate = errors.New("lp: problem is degenerate")
+	ErrNumFailure = errors.New("lp: numerical failure")
+	ErrUserAbort  = errors.New("lp: aborted")
+	ErrTimeout    = errors.New("lp: timeout")
+)
+
+// VariableError records the variable that caused an error.
+type VariableError struct {
+	Variable *Variable
+	Err      error
+}
+
+func (self *VariableError) Error() string {
+	if self.Variable == nil {
+		return self.Err.Error() + ": nil variable"
+	}
+	return fmt.Sprintf("%v: variable %v", self.Err, self.Variable.name())
+}
+
+func (self *VariableError) Unwrap() error {
+	return self.Err
+}
+
+// ConstraintError records the constraint that caused an error, e.g. the
+// constraint that could not be satisfied for ErrInfeasible.
+type ConstraintError struct {
+	Constraint *Constraint
+	Err        error
+}
+
+func (self *ConstraintError) Error() string {
+	if self.Constraint == nil {
+		return self.Err.Error() + ": nil constraint"
+	}
+	return fmt.Sprintf("%v: constraint %v", self.Err, self.Constraint.name())
+}
+
+func (self *ConstraintError) Unwrap() error {
+	return self.Err
+}
+
+// InfeasibilityReporter is implemented by solvers that can name a
+// constraint that is violated by the last infeasible solve.
+type InfeasibilityReporter interface {
+	InfeasibleConstraint() *Constraint
+}
+
+// resultError returns the error for a result type, or nil if the result
+// comes with a solution.
+func resultError(result int) error {
+	switch result {
+	case ResultOptimal, ResultSubOptimal, ResultFeasFound, ResultPresolve:
+		return nil
+	case ResultNoMemory:
+		return ErrNoMemory
+	case ResultInfeasible, ResultNoFeasFound:
+		return ErrInfeasible
+	case ResultUnbounded:
+		return ErrUnbounded
+	case ResultDegenerate:
+		return ErrDegenerate
+	case ResultNumFailure:
+		return ErrNumFailure
+	case ResultUserAbort:
+		return ErrUserAbort
+	case ResultTimeout:
+		return ErrTimeout
+	}
+	return ErrSolver
+}
+
+// solveError returns the error of a solve with result, naming the violated
+// constraint of an infeasible problem if the solver knows it.
+func solveError(solver interface{}, result int) error {
+	err := resultError(result)
+	if err != ErrInfeasible {
+		return err
+	}
+	if reporter, ok := solver.(InfeasibilityReporter); ok {
+		if constraint := reporter.InfeasibleConstraint(); constraint != nil {
+			return &ConstraintError{constraint, err}
+		}
+	}
+	return err
+}
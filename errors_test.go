@@ -0,0 +1,109 @@
+package lp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestMutationErrors(t *testing.T) {
+	fmt.Println("Test Mutation Errors")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	other := newSimplexSpec()
+	z, _ := other.AddVariable(nil)
+
+	if _, err := ls.AddConstraint2([]float64{1.0}, []*Variable{x, y}, OperatorLE, 1); err != ErrLengthMismatch {
+		t.Errorf("length mismatch: %v", err)
+	}
+	if _, err := ls.AddConstraint2([]float64{1.0}, []*Variable{x}, 7, 1); err != ErrInvalidOperator {
+		t.Errorf("unknown operator: %v", err)
+	}
+
+	_, err := ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, z}, OperatorLE, 1)
+	var variableError *VariableError
+	if !errors.Is(err, ErrInvalidVariable) || !errors.As(err, &variableError) ||
+		variableError.Variable != z {
+		t.Errorf("variable of another specification: %v", err)
+	}
+	if ls.Constraints().Len() != 0 {
+		t.Errorf("%v constraints added", ls.Constraints().Len())
+	}
+
+	c, err := ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorLE, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ls.RemoveConstraint(c); err != nil {
+		t.Errorf("remove constraint: %v", err)
+	}
+	if err := ls.RemoveConstraint(c); !errors.Is(err, ErrInvalidConstraint) {
+		t.Errorf("remove constraint twice: %v", err)
+	}
+	if err := c.SetRightSide(2); !errors.Is(err, ErrInvalidConstraint) {
+		t.Errorf("right side of a removed constraint: %v", err)
+	}
+
+	x.SetRange(0, 10)
+	for _, bounds := range [][2]float64{{5, 1}, {math.NaN(), 1}, {0, math.NaN()}} {
+		err := x.SetRange(bounds[0], bounds[1])
+		if !errors.Is(err, ErrInvalidRange) || !errors.As(err, &variableError) ||
+			variableError.Variable != x {
+			t.Errorf("range %v: %v", bounds, err)
+		}
+		if x.Min() != 0 || x.Max() != 10 {
+			t.Errorf("range %v changed the bounds to [%v, %v]", bounds, x.Min(), x.Max())
+		}
+	}
+	if err := x.SetMin(11); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("minimum above the maximum: %v", err)
+	}
+
+	if err := ls.RemoveVariable(y); err != nil {
+		t.Errorf("remove variable: %v", err)
+	}
+	if err := y.SetRange(0, 1); !errors.Is(err, ErrInvalidVariable) {
+		t.Errorf("range of a removed variable: %v", err)
+	}
+	if err := y.SetBinary(); !errors.Is(err, ErrInvalidVariable) || y.IsInteger() {
+		t.Errorf("binary removed variable: %v, integer %v", err, y.IsInteger())
+	}
+	if _, err := ls.AddConstraint2([]float64{1.0}, []*Variable{y}, OperatorLE, 1); !errors.Is(err, ErrInvalidVariable) {
+		t.Errorf("constraint on a removed variable: %v", err)
+	}
+
+	if err := ls.SetSolver(NewDualSimplexSolver(ls)); err != ErrNotEmpty {
+		t.Errorf("solver of a non-empty specification: %v", err)
+	}
+}
+
+func TestSolveErrors(t *testing.T) {
+	fmt.Println("Test Solve Errors")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorLE, 1)
+	c, _ := ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorGE, 3)
+	c.SetLabel("lower")
+
+	result, err := ls.Solve()
+	if result != ResultInfeasible || !errors.Is(err, ErrInfeasible) {
+		t.Fatalf("result = %v, err = %v", result, err)
+	}
+	var constraintError *ConstraintError
+	if !errors.As(err, &constraintError) || constraintError.Constraint == nil {
+		t.Errorf("no constraint reported: %v", err)
+	}
+	if _, err := ls.MaxSize(x, y); !errors.Is(err, ErrInfeasible) {
+		t.Errorf("MaxSize of an infeasible specification: %v", err)
+	}
+
+	c.SetRightSide(1)
+	if _, err := ls.Solve(); err != nil {
+		t.Errorf("feasible specification: %v", err)
+	}
+}
@@ -0,0 +1,699 @@
+package lp
+
+import (
+	"context"
+	"math"
+	"math/big"
+)
+
+// ratSystem is the exact counterpart of the EquationSystem: a dense system
+// of equations over the rationals, kept in the tableau form of a basis by
+// Gauss-Jordan elimination. The basic column of each row is a unit column.
+type ratSystem struct {
+	rows, columns int
+	a             [][]big.Rat
+	// basic column of each row
+	head []int
+}
+
+func newRatSystem(rows, columns int) *ratSystem {
+	system := &ratSystem{}
+	system.rows = rows
+	system.columns = columns
+	system.a = make([][]big.Rat, rows)
+	for i := range system.a {
+		system.a[i] = make([]big.Rat, columns)
+	}
+	system.head = make([]int, rows)
+
+	return system
+}
+
+// pivot makes column q the basic column of row r by dividing the row by
+// its entry in q and eliminating q from all other rows.
+func (self *ratSystem) pivot(r, q int) {
+	var inverse, factor, product big.Rat
+	inverse.Inv(&self.a[r][q])
+	row := self.a[r]
+	for j := range row {
+		if row[j].Sign() != 0 {
+			row[j].Mul(&row[j], &inverse)
+		}
+	}
+	for i := 0; i < self.rows; i++ {
+		if i == r || self.a[i][q].Sign() == 0 {
+			continue
+		}
+		factor.Set(&self.a[i][q])
+		for j := range row {
+			if row[j].Sign() != 0 {
+				product.Mul(&factor, &row[j])
+				self.a[i][j].Sub(&self.a[i][j], &product)
+			}
+		}
+	}
+	self.head[r] = q
+}
+
+// ratSimplex is the bounded-variable simplex method of simplex over the
+// rationals. The columns are laid out the same way: structural, logical
+// and artificial. There are no tolerances; a value is zero or it is not.
+// Since the tableau is dense it only suits small models.
+type ratSimplex struct {
+	model  *lpModel
+	m, n   int
+	system *ratSystem
+	// nil for an infinite bound
+	lower, upper []*big.Rat
+	cost         []big.Rat
+	x            []big.Rat
+	status       []int
+	// sign of the artificial column of each row, 0 if it is not used
+	artificialSign []int
+	// reduced costs of the columns
+	d []big.Rat
+
+	feasible        bool
+	iterations      int
+	degenerateSteps int
+	bland           bool
+	// rows whose artificial stays basic because they depend on others
+	dependentRows int
+	// a row that can not be satisfied, -1 if unknown
+	infeasibleRow int
+	ctx           context.Context
+}
+
+// newRat returns the exact value of f, nil if it is infinite.
+func newRat(f float64) *big.Rat {
+	return new(big.Rat).SetFloat64(f)
+}
+
+// cmpAbs compares |a| and |b| like Cmp.
+func cmpAbs(a, b *big.Rat) int {
+	var x, y big.Rat
+	return x.Abs(a).Cmp(y.Abs(b))
+}
+
+func newRatSimplex(model *lpModel) *ratSimplex {
+	s := &ratSimplex{}
+	s.model = model
+	s.m = model.rows
+	s.n = model.columns
+
+	total := s.n + 2*s.m
+	s.system = newRatSystem(s.m, total)
+	s.lower = make([]*big.Rat, total)
+	s.upper = make([]*big.Rat, total)
+	s.cost = make([]big.Rat, total)
+	s.x = make([]big.Rat, total)
+	s.status = make([]int, total)
+	s.artificialSign = make([]int, s.m)
+	s.d = make([]big.Rat, total)
+	s.infeasibleRow = -1
+	s.ctx = context.Background()
+
+	for j := 0; j < s.n; j++ {
+		s.lower[j] = newRat(model.lower[j])
+		s.upper[j] = newRat(model.upper[j])
+	}
+	for j := 0; j < s.n; j++ {
+		column := model.column(j)
+		for k, i := range column.index {
+			s.system.a[i][j].SetFloat64(column.value[k])
+		}
+	}
+	zero := new(big.Rat)
+	for i := 0; i < s.m; i++ {
+		logical := s.n + i
+		s.system.a[i][logical].SetInt64(1)
+		switch model.ops[i] {
+		case OperatorLE:
+			s.lower[logical] = zero
+		case OperatorGE:
+			s.upper[logical] = zero
+		default:
+			s.lower[logical] = zero
+			s.upper[logical] = zero
+		}
+		artificial := s.n + s.m + i
+		s.lower[artificial] = zero
+		s.upper[artificial] = zero
+	}
+
+	return s
+}
+
+// fixed returns whether column j can not move.
+func (self *ratSimplex) fixed(j int) bool {
+	return self.lower[j] != nil && self.upper[j] != nil && self.lower[j].Cmp(self.upper[j]) == 0
+}
+
+// setNonbasic puts a nonbasic column on its lower bound, on its upper
+// bound if there is no lower one, or at zero if it is free.
+func (self *ratSimplex) setNonbasic(j int) {
+	switch {
+	case self.lower[j] != nil:
+		self.status[j] = columnAtLower
+		self.x[j].Set(self.lower[j])
+	case self.upper[j] != nil:
+		self.status[j] = columnAtUpper
+		self.x[j].Set(self.upper[j])
+	default:
+		self.status[j] = columnAtZero
+		self.x[j].SetInt64(0)
+	}
+}
+
+// coldStart sets up the slack basis; rows whose logical cannot take the
+// residual get a basic artificial column.
+func (self *ratSimplex) coldStart() {
+	for j := 0; j < self.n+self.m; j++ {
+		self.setNonbasic(j)
+	}
+	var product big.Rat
+	for i := 0; i < self.m; i++ {
+		logical := self.n + i
+		artificial := self.n + self.m + i
+		self.status[artificial] = columnAtLower
+		self.x[artificial].SetInt64(0)
+
+		residual := newRat(self.model.b[i])
+		for j := 0; j < self.n; j++ {
+			if self.x[j].Sign() != 0 && self.system.a[i][j].Sign() != 0 {
+				product.Mul(&self.system.a[i][j], &self.x[j])
+				residual.Sub(residual, &product)
+			}
+		}
+		self.system.head[i] = logical
+		switch {
+		case self.lower[logical] != nil && residual.Cmp(self.lower[logical]) < 0:
+			self.x[logical].Set(self.lower[logical])
+			self.status[logical] = columnAtLower
+		case self.upper[logical] != nil && residual.Cmp(self.upper[logical]) > 0:
+			self.x[logical].Set(self.upper[logical])
+			self.status[logical] = columnAtUpper
+		default:
+			self.x[logical].Set(residual)
+			self.status[logical] = columnBasic
+			continue
+		}
+
+		rest := residual.Sub(residual, &self.x[logical])
+		self.artificialSign[i] = rest.Sign()
+		self.system.a[i][artificial].SetInt64(int64(self.artificialSign[i]))
+		self.upper[artificial] = nil
+		self.x[artificial].Abs(rest)
+		self.status[artificial] = columnBasic
+		self.system.pivot(i, artificial)
+	}
+}
+
+// computeReducedCosts computes d = c - c_B^T B^-1 [A I] from the tableau.
+func (self *ratSimplex) computeReducedCosts() {
+	var product big.Rat
+	for j := range self.d {
+		self.d[j].SetInt64(0)
+		if self.status[j] == columnBasic {
+			continue
+		}
+		self.d[j].Set(&self.cost[j])
+		for i := 0; i < self.m; i++ {
+			cost := &self.cost[self.system.head[i]]
+			if cost.Sign() == 0 || self.system.a[i][j].Sign() == 0 {
+				continue
+			}
+			product.Mul(cost, &self.system.a[i][j])
+			self.d[j].Sub(&self.d[j], &product)
+		}
+	}
+}
+
+// price selects the entering column and the direction it moves in, or -1
+// if the basis is optimal. Dantzig's rule is used unless the method stalls,
+// then Bland's rule prevents cycling.
+func (self *ratSimplex) price() (int, int) {
+	entering := -1
+	direction := 0
+	var best big.Rat
+	for j := range self.d {
+		if self.status[j] == columnBasic || self.fixed(j) {
+			continue
+		}
+		sign := self.d[j].Sign()
+		dir := 0
+		switch self.status[j] {
+		case columnAtLower:
+			if sign < 0 {
+				dir = 1
+			}
+		case columnAtUpper:
+			if sign > 0 {
+				dir = -1
+			}
+		case columnAtZero:
+			dir = -sign
+		}
+		if dir == 0 {
+			continue
+		}
+		if self.bland {
+			return j, dir
+		}
+		if entering < 0 || cmpAbs(&self.d[j], &best) > 0 {
+			best.Set(&self.d[j])
+			entering = j
+			direction = dir
+		}
+	}
+	return entering, direction
+}
+
+// ratioTest returns the step length for moving the entering column q in
+// direction dir, nil if it is unlimited, and the basis position that
+// leaves or -1 if q just moves to its opposite bound.
+func (self *ratSimplex) ratioTest(q, dir int) (*big.Rat, int) {
+	var theta *big.Rat
+	leaving := -1
+	if self.lower[q] != nil && self.upper[q] != nil {
+		theta = new(big.Rat).Sub(self.upper[q], self.lower[q])
+	}
+
+	var alpha, t big.Rat
+	for i := 0; i < self.m; i++ {
+		if self.system.a[i][q].Sign() == 0 {
+			continue
+		}
+		alpha.Set(&self.system.a[i][q])
+		if dir < 0 {
+			alpha.Neg(&alpha)
+		}
+		k := self.system.head[i]
+		if alpha.Sign() > 0 {
+			if self.lower[k] == nil {
+				continue
+			}
+			t.Sub(&self.x[k], self.lower[k])
+			t.Quo(&t, &alpha)
+		} else {
+			if self.upper[k] == nil {
+				continue
+			}
+			t.Sub(self.upper[k], &self.x[k])
+			t.Quo(&t, &alpha)
+			t.Neg(&t)
+		}
+
+		switch {
+		case theta == nil || t.Cmp(theta) < 0:
+			theta = new(big.Rat).Set(&t)
+			leaving = i
+		case t.Cmp(theta) == 0 && leaving >= 0:
+			// tie: Bland's rule takes the smallest column index,
+			// otherwise prefer the largest pivot
+			if self.bland {
+				if k < self.system.head[leaving] {
+					leaving = i
+				}
+			} else if cmpAbs(&alpha, &self.system.a[leaving][q]) > 0 {
+				leaving = i
+			}
+		}
+	}
+	return theta, leaving
+}
+
+// primal runs the primal simplex with the current costs from a primal
+// feasible basis.
+func (self *ratSimplex) primal() int {
+	self.degenerateSteps = 0
+	self.bland = false
+	var step big.Rat
+
+	for {
+		self.computeReducedCosts()
+		q, dir := self.price()
+		if q < 0 {
+			return ResultOptimal
+		}
+		if result, ok := interrupted(self.ctx); ok {
+			return result
+		}
+		self.iterations++
+
+		theta, leaving := self.ratioTest(q, dir)
+		if theta == nil {
+			return ResultUnbounded
+		}
+		if theta.Sign() == 0 {
+			self.degenerateSteps++
+			if self.degenerateSteps > simplexStallLimit {
+				self.bland = true
+			}
+		} else {
+			self.degenerateSteps = 0
+			self.bland = false
+		}
+
+		// x_q += dir theta, x_B -= dir theta B^-1 a_q
+		if dir < 0 {
+			theta.Neg(theta)
+		}
+		self.x[q].Add(&self.x[q], theta)
+		for i := 0; i < self.m; i++ {
+			if self.system.a[i][q].Sign() == 0 {
+				continue
+			}
+			k := self.system.head[i]
+			step.Mul(theta, &self.system.a[i][q])
+			self.x[k].Sub(&self.x[k], &step)
+		}
+
+		if leaving < 0 {
+			if dir > 0 {
+				self.status[q] = columnAtUpper
+			} else {
+				self.status[q] = columnAtLower
+			}
+			continue
+		}
+		k := self.system.head[leaving]
+		if self.lower[k] != nil && self.x[k].Cmp(self.lower[k]) == 0 {
+			self.status[k] = columnAtLower
+		} else {
+			self.status[k] = columnAtUpper
+		}
+		self.status[q] = columnBasic
+		self.system.pivot(leaving, q)
+	}
+}
+
+// removeArtificials fixes all artificial columns at zero and pivots the
+// basic ones out of the basis where possible. Artificials of dependent
+// rows stay basic at zero.
+func (self *ratSimplex) removeArtificials() {
+	zero := new(big.Rat)
+	for i := 0; i < self.m; i++ {
+		self.upper[self.n+self.m+i] = zero
+	}
+	for r := 0; r < self.m; r++ {
+		if self.system.head[r] < self.n+self.m {
+			continue
+		}
+		entering := -1
+		for j := 0; j < self.n+self.m; j++ {
+			if self.status[j] != columnBasic && self.system.a[r][j].Sign() != 0 {
+				entering = j
+				break
+			}
+		}
+		if entering < 0 {
+			self.dependentRows++
+			continue
+		}
+		self.status[self.system.head[r]] = columnAtLower
+		self.status[entering] = columnBasic
+		self.system.pivot(r, entering)
+	}
+}
+
+// solve runs the two-phase primal simplex method.
+func (self *ratSimplex) solve() int {
+	self.coldStart()
+
+	if self.hasArtificials() {
+		for i := 0; i < self.m; i++ {
+			self.cost[self.n+self.m+i].SetInt64(1)
+		}
+		result := self.primal()
+		if result != ResultOptimal {
+			return result
+		}
+
+		var worst big.Rat
+		for i := 0; i < self.m; i++ {
+			value := &self.x[self.n+self.m+i]
+			if value.Cmp(&worst) > 0 {
+				worst.Set(value)
+				self.infeasibleRow = i
+			}
+		}
+		if worst.Sign() > 0 {
+			return ResultInfeasible
+		}
+		self.removeArtificials()
+	}
+
+	self.feasible = true
+	for j := range self.cost {
+		self.cost[j].SetInt64(0)
+	}
+	for j := 0; j < self.n; j++ {
+		self.cost[j].SetFloat64(self.model.c[j])
+	}
+	return self.primal()
+}
+
+func (self *ratSimplex) hasArtificials() bool {
+	for i := 0; i < self.m; i++ {
+		if self.artificialSign[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// infeasibleConstraint returns the constraint of the row that could not be
+// satisfied by the last infeasible solve, or nil.
+func (self *ratSimplex) infeasibleConstraint() *Constraint {
+	if self.infeasibleRow < 0 {
+		return nil
+	}
+	return self.model.constraints[self.infeasibleRow]
+}
+
+// value returns the value of structural column j in the variables of the
+// LinearSpec.
+func (self *ratSimplex) value(j int) *big.Rat {
+	value := newRat(self.model.columnFactor(j))
+	return value.Mul(value, &self.x[j])
+}
+
+// ExactSolver solves the linear program of a LinearSpec like the
+// SimplexSolver, but with the rational numbers of math/big instead of
+// float64: the elimination of the equation system, the feasibility search
+// of phase 1 and the simplex of phase 2 are exact. Every float64 of the
+// specification is a rational number, so the results are proven for the
+// specification as given. The variables, duals and reduced costs get the
+// nearest float64 of the exact values, which Value, Dual and
+// ObjectiveValue return.
+//
+// The tolerances and the presolve are not used. The dense tableau and the
+// growing numerators make the solver slow; it is meant for verifying small
+// models and as an oracle for the other solvers.
+type ExactSolver struct {
+	ls         *LinearSpec
+	infeasible *Constraint
+	stats      SolveStats
+	values     map[*Variable]*big.Rat
+	duals      map[*Constraint]*big.Rat
+	objective  *big.Rat
+}
+
+func NewExactSolver(ls *LinearSpec) *ExactSolver {
+	es := &ExactSolver{}
+	es.ls = ls
+
+	return es
+}
+
+func (self *ExactSolver) Solve() int {
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves like Solve but stops with ResultTimeout or
+// ResultUserAbort when ctx is done.
+func (self *ExactSolver) SolveContext(ctx context.Context) int {
+	self.infeasible = nil
+	self.stats = SolveStats{}
+	self.values = nil
+	self.duals = nil
+	self.objective = nil
+
+	model := newLPModel(self.ls, true)
+	s := newRatSimplex(model)
+	s.ctx = ctx
+	result := s.solve()
+	self.infeasible = s.infeasibleConstraint()
+	self.stats.SimplexIterations = s.iterations
+	self.stats.RemovedRows = s.dependentRows
+
+	if !s.feasible {
+		model.setDuals(nil, nil)
+		return result
+	}
+
+	x := make([]float64, model.columns)
+	self.values = make(map[*Variable]*big.Rat, model.columns)
+	self.objective = newRat(model.offset)
+	var product big.Rat
+	for j, v := range model.variables {
+		x[j], _ = s.x[j].Float64()
+		if v == nil {
+			continue
+		}
+		self.values[v] = s.value(j)
+		product.Mul(newRat(model.c[j]), &s.x[j])
+		self.objective.Add(self.objective, &product)
+	}
+	self.objective.Mul(self.objective, newRat(model.sense))
+	model.setValues(x)
+	for i := 0; i < model.rows; i++ {
+		if s.x[model.columns+i].Sign() == 0 {
+			self.stats.ActiveConstraints++
+		}
+	}
+	if result != ResultOptimal {
+		model.setDuals(nil, nil)
+		return result
+	}
+
+	// the logical of row i has the reduced cost -y_i
+	y := make([]float64, model.rows)
+	d := make([]float64, model.columns)
+	self.duals = make(map[*Constraint]*big.Rat, model.rows)
+	for i, constraint := range model.constraints {
+		dual := newRat(-model.sense * model.rowFactor(i))
+		dual.Mul(dual, &s.d[model.columns+i])
+		self.duals[constraint] = dual
+		y[i], _ = s.d[model.columns+i].Float64()
+		y[i] = -y[i]
+	}
+	for j := range d {
+		d[j], _ = s.d[j].Float64()
+	}
+	model.setDuals(y, d)
+	return result
+}
+
+// Value gets the exact value of a variable after the last solve, nil if it
+// found no feasible point.
+func (self *ExactSolver) Value(v *Variable) *big.Rat {
+	return self.values[v]
+}
+
+// Dual gets the exact dual value of a constraint after the last solve, nil
+// if it was not optimal.
+func (self *ExactSolver) Dual(constraint *Constraint) *big.Rat {
+	return self.duals[constraint]
+}
+
+// ObjectiveValue gets the exact value of the objective function after the
+// last solve, nil if it found no feasible point.
+func (self *ExactSolver) ObjectiveValue() *big.Rat {
+	return self.objective
+}
+
+// InfeasibleConstraint gets a constraint that could not be satisfied by the
+// last solve if it was infeasible.
+func (self *ExactSolver) InfeasibleConstraint() *Constraint {
+	return self.infeasible
+}
+
+// Stats gets the statistics of the last solve.
+func (self *ExactSolver) Stats() SolveStats {
+	return self.stats
+}
+
+func (self *ExactSolver) VariableAdded(variable *Variable) bool {
+	return true
+}
+
+func (self *ExactSolver) VariableRemoved(variable *Variable) bool {
+	return true
+}
+
+func (self *ExactSolver) VariableRangeChanged(variable *Variable) bool {
+	return true
+}
+
+func (self *ExactSolver) ConstraintAdded(constraint *Constraint) bool {
+	return true
+}
+
+func (self *ExactSolver) ConstraintRemoved(constraint *Constraint) bool {
+	return true
+}
+
+func (self *ExactSolver) LeftSideChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *ExactSolver) RightSideChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *ExactSolver) OperatorChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *ExactSolver) ObjectiveChanged() bool {
+	return true
+}
+
+// SaveModel writes the specification in LP format.
+func (self *ExactSolver) SaveModel(fileName string) error {
+	return saveLP(self.ls, fileName)
+}
+
+// MinSize minimizes width and height independently subject to the hard
+// constraints. An unbounded dimension is reported as -Inf.
+func (self *ExactSolver) MinSize(width, height *Variable) (Size, error) {
+	return exactOptimizeSize(newLPModel(self.ls, false), width, height, 1)
+}
+
+// MaxSize maximizes width and height independently subject to the hard
+// constraints. An unbounded dimension is reported as +Inf.
+func (self *ExactSolver) MaxSize(width, height *Variable) (Size, error) {
+	return exactOptimizeSize(newLPModel(self.ls, false), width, height, -1)
+}
+
+// exactOptimizeSize is optimizeSize with the ratSimplex.
+func exactOptimizeSize(model *lpModel, width, height *Variable, sense float64) (Size, error) {
+	w, err := exactOptimizeVariable(model, width, sense)
+	if err != nil {
+		return Size{}, err
+	}
+	h, err := exactOptimizeVariable(model, height, sense)
+	if err != nil {
+		return Size{}, err
+	}
+	return Size{w, h}, nil
+}
+
+// exactOptimizeVariable is optimizeVariable with the ratSimplex.
+func exactOptimizeVariable(model *lpModel, v *Variable, sense float64) (float64, error) {
+	index := v.GlobalIndex()
+	if index < 0 {
+		return 0, &VariableError{v, ErrInvalidVariable}
+	}
+	objective := *model
+	objective.c = make([]float64, model.columns)
+	objective.c[index] = sense
+	objective.sense = 1
+
+	s := newRatSimplex(&objective)
+	result := s.solve()
+	switch result {
+	case ResultOptimal:
+		value, _ := s.value(index).Float64()
+		return value, nil
+	case ResultUnbounded:
+		return math.Inf(-int(sense)), nil
+	case ResultInfeasible:
+		if constraint := s.infeasibleConstraint(); constraint != nil {
+			return 0, &ConstraintError{constraint, ErrInfeasible}
+		}
+	}
+	return 0, resultError(result)
+}
@@ -0,0 +1,143 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestExactSolver(t *testing.T) {
+	fmt.Println("Test Exact Solver")
+
+	// Chvatal's example, which cycles with Dantzig's rule and the largest
+	// pivot
+	ls := NewLinearSpec()
+	solver := NewExactSolver(ls)
+	ls.SetSolver(solver)
+	x := make([]*Variable, 4)
+	for i := range x {
+		x[i], _ = ls.AddVariable(nil)
+		x[i].SetMin(0)
+	}
+	c1, _ := ls.AddConstraint2([]float64{0.5, -5.5, -2.5, 9}, x, OperatorLE, 0)
+	ls.AddConstraint2([]float64{0.5, -1.5, -0.5, 1}, x, OperatorLE, 0)
+	ls.AddConstraint2([]float64{1}, x[:1], OperatorLE, 1)
+	ls.SetObjective1([]float64{10, -57, -9, -24}, x, OptMaximize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if got := solver.ObjectiveValue(); got.Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("objective = %v, want 1", got)
+	}
+	for i, want := range []int64{1, 0, 1, 0} {
+		if got := solver.Value(x[i]); got.Cmp(big.NewRat(want, 1)) != 0 {
+			t.Errorf("x%v = %v, want %v", i, got, want)
+		}
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 1)
+	if dual, _ := solver.Dual(c1).Float64(); dual != c1.Dual() {
+		t.Errorf("dual = %v, projected %v", solver.Dual(c1), c1.Dual())
+	}
+
+	// a value that float64 can not represent
+	third, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{3}, []*Variable{third}, OperatorEQ, 1)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if got := solver.Value(third); got.Cmp(big.NewRat(1, 3)) != 0 {
+		t.Errorf("third = %v, want 1/3", got)
+	}
+	if third.Value() != 1.0/3 {
+		t.Errorf("projected third = %v", third.Value())
+	}
+
+	// a violation of 2^-53 that the tolerances of the simplex hide
+	for _, exact := range []bool{false, true} {
+		ls := NewLinearSpec()
+		if exact {
+			ls.SetSolver(NewExactSolver(ls))
+		} else {
+			ls.SetSolver(NewSimplexSolver(ls))
+		}
+		x, _ := ls.AddVariable(nil)
+		x.SetMin(3)
+		c, _ := ls.AddConstraint2([]float64{0.33333333333333337}, []*Variable{x},
+			OperatorLE, 1)
+		result, err := ls.Solve()
+		switch {
+		case !exact && result != ResultOptimal:
+			t.Errorf("simplex: result = %v, want %v", result, ResultOptimal)
+		case exact && result != ResultInfeasible:
+			t.Errorf("exact: result = %v, want %v", result, ResultInfeasible)
+		case exact:
+			if cerr, ok := err.(*ConstraintError); !ok || cerr.Constraint != c {
+				t.Errorf("exact: error = %v", err)
+			}
+		}
+	}
+
+	// as an oracle for the simplex on models whose numbers are exact in
+	// float64
+	rng := rand.New(rand.NewSource(1))
+	for iteration := 0; iteration < 200; iteration++ {
+		seed := rng.Int63()
+		build := func(exact bool) *LinearSpec {
+			r := rand.New(rand.NewSource(seed))
+			ls := NewLinearSpec()
+			if exact {
+				ls.SetSolver(NewExactSolver(ls))
+			} else {
+				ls.SetSolver(NewSimplexSolver(ls))
+			}
+			n := 2 + r.Intn(4)
+			variables := make([]*Variable, n)
+			coeffs := make([]float64, n)
+			for i := range variables {
+				variables[i], _ = ls.AddVariable(nil)
+				variables[i].SetRange(float64(r.Intn(3)-1), float64(2+r.Intn(8)))
+				coeffs[i] = float64(r.Intn(7) - 3)
+			}
+			for k := 1 + r.Intn(5); k > 0; k-- {
+				row := make([]float64, n)
+				for i := range row {
+					row[i] = float64(r.Intn(9)-4) / 4
+				}
+				op := []int{OperatorLE, OperatorGE, OperatorEQ}[r.Intn(3)]
+				ls.AddConstraint2(row, variables, op, float64(r.Intn(17)-8))
+			}
+			ls.SetObjective1(coeffs, variables, OptMinimize)
+			return ls
+		}
+		reference := build(false)
+		want, _ := reference.Solve()
+		ls := build(true)
+		if result, _ := ls.Solve(); result != want {
+			t.Errorf("seed %v: result = %v, simplex %v", seed, result, want)
+			continue
+		}
+		if want == ResultOptimal &&
+			math.Abs(ls.ObjectiveValue()-reference.ObjectiveValue()) > 1e-6 {
+			t.Errorf("seed %v: objective = %v, simplex %v", seed, ls.ObjectiveValue(),
+				reference.ObjectiveValue())
+		}
+	}
+
+	// the sizes of a layout
+	ls = NewLinearSpec()
+	ls.SetSolver(NewExactSolver(ls))
+	left, _ := ls.AddVariable(nil)
+	right, _ := ls.AddVariable(nil)
+	left.SetRange(0, 0)
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{right, left}, OperatorGE, 30)
+	size, err := ls.MinSize(right, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, "min width", size.W, 30)
+	if size, _ = ls.MaxSize(right, right); !math.IsInf(size.W, 1) {
+		t.Errorf("max width = %v", size.W)
+	}
+}
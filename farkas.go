@@ -0,0 +1,69 @@
+package lp
+
+// FarkasCertificate proves that the hard constraints a_i^Tx (op_i) b_i have
+// no solution within the variable bounds l <= x <= u. The weights y_i
+// satisfy y_i >= 0 for LE and y_i <= 0 for GE, so every solution x would
+// give r^Tx <= sum y_ib_i with r = sum y_ia_i, while the minimum of r^Tx
+// over the bounds is larger than sum y_ib_i.
+type FarkasCertificate struct {
+	// the hard constraints
+	Constraints *ConstraintList
+	// one weight per constraint
+	Weights []float64
+	// the tolerances of the solve; the defaults if they are not valid
+	Tolerances Tolerances
+}
+
+// FarkasReporter is implemented by solvers that prove infeasibility.
+type FarkasReporter interface {
+	// FarkasCertificate returns nil if the last solve was not infeasible.
+	FarkasCertificate() *FarkasCertificate
+}
+
+// Verify checks the certificate against the current constraints and
+// bounds.
+func (self *FarkasCertificate) Verify() bool {
+	if self.Constraints.Len() != len(self.Weights) {
+		return false
+	}
+	tolerances := self.Tolerances
+	if !tolerances.valid() {
+		tolerances = DefaultTolerances()
+	}
+
+	combination := make(map[*Variable]float64)
+	rightSide := 0.0
+	for i, y := range self.Weights {
+		constraint := self.Constraints.GetAt(i)
+		switch constraint.Op() {
+		case OperatorLE:
+			if y < -tolerances.DualFeasibility {
+				return false
+			}
+		case OperatorGE:
+			if y > tolerances.DualFeasibility {
+				return false
+			}
+		}
+		summands := constraint.LeftSide()
+		for s := 0; s < summands.Len(); s++ {
+			summand := summands.GetAt(s)
+			combination[summand.Var()] += y * summand.Coeff()
+		}
+		rightSide += y * constraint.RightSide()
+	}
+
+	// minimum of r^Tx over the bounds
+	minimum := 0.0
+	for v, coeff := range combination {
+		switch {
+		case tolerances.isZero(coeff):
+			continue
+		case coeff > 0:
+			minimum += coeff * v.Min()
+		default:
+			minimum += coeff * v.Max()
+		}
+	}
+	return minimum > rightSide+tolerances.PrimalFeasibility
+}
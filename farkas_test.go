@@ -0,0 +1,133 @@
+package lp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFarkasCertificate(t *testing.T) {
+	fmt.Println("Test FarkasCertificate")
+
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorGE, 10)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 5)
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorEQ, 0)
+
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Fatalf("result = %v, want %v", result, ResultInfeasible)
+	}
+	farkas, err := ls.FarkasCertificate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println("weights:", farkas.Weights)
+	if farkas.Constraints.Len() != 3 {
+		t.Errorf("certificate has %v constraints, want 3", farkas.Constraints.Len())
+	}
+	if !farkas.Verify() {
+		t.Errorf("certificate %v does not verify", farkas.Weights)
+	}
+
+	// a wrong weight fails the check
+	farkas.Weights[0] = -farkas.Weights[0] + 1
+	if farkas.Verify() {
+		t.Error("modified certificate verifies")
+	}
+
+	// a constraint against a bound
+	bounded := NewLinearSpec()
+	z, _ := bounded.AddVariable(nil)
+	z.SetRange(0, 4)
+	bounded.AddConstraint2([]float64{1}, []*Variable{z}, OperatorGE, 10)
+	if result, _ := bounded.Solve(); result != ResultInfeasible {
+		t.Fatalf("result = %v, want %v", result, ResultInfeasible)
+	}
+	if farkas, err := bounded.FarkasCertificate(); err != nil || !farkas.Verify() {
+		t.Errorf("certificate against a bound: %v", err)
+	}
+
+	// a violation of 1e-4 proves nothing with a feasibility tolerance of 1e-3
+	bounded.Constraints().GetAt(0).SetRightSide(4 + 1e-4)
+	if result, _ := bounded.Solve(); result != ResultInfeasible {
+		t.Fatalf("result = %v, want %v", result, ResultInfeasible)
+	}
+	farkas, err = bounded.FarkasCertificate()
+	if err != nil || farkas.Tolerances != bounded.Tolerances() || !farkas.Verify() {
+		t.Fatalf("certificate of a small violation: %v", err)
+	}
+	farkas.Tolerances.PrimalFeasibility = 1e-3
+	if farkas.Verify() {
+		t.Error("certificate verifies within the feasibility tolerance")
+	}
+
+	// feasible again
+	ls.Constraints().GetAt(0).SetRightSide(1)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if _, err := ls.FarkasCertificate(); err != ErrNoCertificate {
+		t.Errorf("certificate of a feasible solve: %v", err)
+	}
+}
+
+func TestFarkasDependentRows(t *testing.T) {
+	fmt.Println("Test Farkas DependentRows")
+
+	for _, scaling := range []int{ScaleNone, ScaleGeometric} {
+		// the same left side with two right sides
+		ls := NewLinearSpec()
+		ls.SetScaling(scaling)
+		x, _ := ls.AddVariable(nil)
+		y, _ := ls.AddVariable(nil)
+		ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorEQ, 1)
+		ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorEQ, 2)
+		if result, _ := ls.Solve(); result != ResultInfeasible {
+			t.Fatalf("scaling %v: result = %v, want %v", scaling, result, ResultInfeasible)
+		}
+		if farkas, err := ls.FarkasCertificate(); err != nil || !farkas.Verify() {
+			t.Errorf("scaling %v: certificate of a duplicated row: %v", scaling, err)
+		}
+
+		// the third row is a combination of the other two, next to an
+		// inequality
+		ls = NewLinearSpec()
+		ls.SetScaling(scaling)
+		x, _ = ls.AddVariable(nil)
+		y, _ = ls.AddVariable(nil)
+		ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorEQ, 10)
+		ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorLE, 100)
+		ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorEQ, 0)
+		ls.AddConstraint2([]float64{200}, []*Variable{x}, OperatorEQ, 3)
+		if result, _ := ls.Solve(); result != ResultInfeasible {
+			t.Fatalf("scaling %v: result = %v, want %v", scaling, result, ResultInfeasible)
+		}
+		farkas, err := ls.FarkasCertificate()
+		if err != nil || !farkas.Verify() {
+			t.Errorf("scaling %v: certificate of a combination: %v", scaling, err)
+		}
+
+		// consistent right sides are only dropped
+		ls.Constraints().GetAt(3).SetRightSide(1000)
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("scaling %v: result = %v, want %v", scaling, result, ResultOptimal)
+		}
+		checkValue(t, "x", x.Value(), 5)
+		if stats := ls.Stats(); stats.RemovedRows != 1 {
+			t.Errorf("scaling %v: removed rows = %v, want 1", scaling, stats.RemovedRows)
+		}
+
+		// a penalized equality gives way to the hard one
+		ls = NewLinearSpec()
+		ls.SetScaling(scaling)
+		x, _ = ls.AddVariable(nil)
+		y, _ = ls.AddVariable(nil)
+		ls.AddConstraint4([]float64{1, 1}, []*Variable{x, y}, OperatorEQ, 2, 1, 1)
+		ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorEQ, 1)
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("scaling %v: result = %v, want %v", scaling, result, ResultOptimal)
+		}
+		checkValue(t, "x + y", x.Value()+y.Value(), 1)
+	}
+}
@@ -0,0 +1,96 @@
+package lp
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// IIS is an irreducible infeasible subset of a specification: its
+// constraints and variable bounds can not be satisfied together, but every
+// proper subset of them can.
+type IIS struct {
+	Constraints *ConstraintList
+	// variables whose Min() belongs to the subset
+	LowerBounds *VariableList
+	// variables whose Max() belongs to the subset
+	UpperBounds *VariableList
+}
+
+// String lists the members of the subset by their labels.
+func (self *IIS) String() string {
+	var buffer bytes.Buffer
+	for i := 0; i < self.Constraints.Len(); i++ {
+		fmt.Fprintf(&buffer, "constraint %v\n", self.Constraints.GetAt(i).name())
+	}
+	for i := 0; i < self.LowerBounds.Len(); i++ {
+		v := self.LowerBounds.GetAt(i)
+		fmt.Fprintf(&buffer, "%v >= %v\n", v.name(), v.Min())
+	}
+	for i := 0; i < self.UpperBounds.Len(); i++ {
+		v := self.UpperBounds.GetAt(i)
+		fmt.Fprintf(&buffer, "%v <= %v\n", v.name(), v.Max())
+	}
+	return buffer.String()
+}
+
+// ComputeIIS finds an irreducible infeasible subset of the hard constraints
+// and the variable bounds with a deletion filter: each member is left out in
+// turn and stays out if the rest is still infeasible. Soft constraints can
+// always be satisfied and are ignored. Returns ErrFeasible if the
+// specification is feasible.
+func (self *LinearSpec) ComputeIIS() (*IIS, error) {
+	model := newLPModel(self, false)
+	rows := make([]bool, model.rows)
+	for i := range rows {
+		rows[i] = true
+	}
+	lower := copyVector(model.lower)
+	upper := copyVector(model.upper)
+
+	feasible, err := model.subset(rows, lower, upper).isFeasible()
+	if err != nil {
+		return nil, err
+	}
+	if feasible {
+		return nil, ErrFeasible
+	}
+
+	for i := range rows {
+		rows[i] = false
+		if feasible, err = model.subset(rows, lower, upper).isFeasible(); err != nil {
+			return nil, err
+		}
+		rows[i] = feasible
+	}
+
+	iis := &IIS{newConstraintList(), newVariableList(), newVariableList()}
+	for j, v := range model.variables {
+		if !math.IsInf(lower[j], -1) {
+			lower[j] = math.Inf(-1)
+			if feasible, err = model.subset(rows, lower, upper).isFeasible(); err != nil {
+				return nil, err
+			}
+			if feasible {
+				lower[j] = model.lower[j]
+				iis.LowerBounds.AddItem(v)
+			}
+		}
+		if !math.IsInf(upper[j], 1) {
+			upper[j] = math.Inf(1)
+			if feasible, err = model.subset(rows, lower, upper).isFeasible(); err != nil {
+				return nil, err
+			}
+			if feasible {
+				upper[j] = model.upper[j]
+				iis.UpperBounds.AddItem(v)
+			}
+		}
+	}
+	for i, constraint := range model.constraints {
+		if rows[i] {
+			iis.Constraints.AddItem(constraint)
+		}
+	}
+	return iis, nil
+}
@@ -0,0 +1,64 @@
+package lp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestComputeIIS(t *testing.T) {
+	fmt.Println("Test ComputeIIS")
+
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	left, _ := ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorGE, 10)
+	left.SetLabel("left")
+	width, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorEQ, 20)
+	width.SetLabel("width")
+	right, _ := ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorGE, 5)
+	right.SetLabel("right")
+	ls.AddConstraint4([]float64{1}, []*Variable{y}, OperatorGE, 100, 1, 1)
+
+	if _, err := ls.ComputeIIS(); err != ErrFeasible {
+		t.Fatalf("IIS of a feasible specification: %v", err)
+	}
+
+	// the designer adds a bad rule
+	bad, _ := ls.AddConstraint2([]float64{1}, []*Variable{y}, OperatorGE, 8)
+	bad.SetLabel("bad")
+	if _, err := ls.Solve(); err == nil {
+		t.Fatal("solve of an infeasible specification succeeded")
+	}
+	iis, err := ls.ComputeIIS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Print(iis)
+	if iis.Constraints.Len() != 3 || iis.Constraints.IndexOf(width) < 0 ||
+		iis.Constraints.IndexOf(right) < 0 || iis.Constraints.IndexOf(bad) < 0 {
+		t.Errorf("IIS = %v", iis)
+	}
+	if iis.LowerBounds.Len() != 0 || iis.UpperBounds.Len() != 0 {
+		t.Errorf("IIS has bounds: %v", iis)
+	}
+
+	// a constraint against a variable bound
+	ls = NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	x, _ = ls.AddVariable(nil)
+	x.SetRange(0, 4)
+	y, _ = ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 50)
+	c, _ := ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorGE, 10)
+
+	iis, err = ls.ComputeIIS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if iis.Constraints.Len() != 1 || iis.Constraints.GetAt(0) != c {
+		t.Errorf("IIS constraints = %v", iis)
+	}
+	if iis.LowerBounds.Len() != 0 || iis.UpperBounds.Len() != 1 || iis.UpperBounds.GetAt(0) != x {
+		t.Errorf("IIS bounds = %v", iis)
+	}
+}
@@ -0,0 +1,539 @@
+package lp
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	// relative residuals and duality gap at which the barrier stops
+	barrierTolerance     = 1e-9
+	barrierMaxIterations = 100
+	// iterations without halving the merit after which the barrier stalls
+	barrierStallIterations = 10
+	// fraction of the step to the boundary that the barrier takes
+	barrierStepFactor = 0.995
+	// regularization of the normal equations, relative to their diagonal,
+	// and of the columns
+	barrierRegularization = 1e-12
+	// corrections of a Newton direction at most
+	barrierRefinementSteps = 3
+	// iterates beyond this size are taken as a sign that the problem is
+	// infeasible or unbounded
+	barrierDivergence = 1e12
+)
+
+// barrier is a primal-dual interior point method with Mehrotra's
+// predictor-corrector for the lpModel
+//
+//	min c^Tx  s.t.  Ax + s = b,  lower <= (x, s) <= upper
+//
+// with the logical columns s of the simplex. Column j < n is structural and
+// n <= j the logical of row j-n. z and w are the duals of the lower and the
+// upper bounds, which the iterates keep strictly positive together with the
+// distances to the bounds. Fixed columns stay at their bound.
+type barrier struct {
+	model              *lpModel
+	m, n               int
+	lower, upper, cost []float64
+	// the columns of [A I]
+	columns []sparseVector
+	fixed   []bool
+
+	x, y, z, w []float64
+	iterations int
+	ctx        context.Context
+	tolerances Tolerances
+}
+
+func newBarrier(model *lpModel) *barrier {
+	b := &barrier{}
+	b.model = model
+	b.m = model.rows
+	b.n = model.columns
+
+	total := b.n + b.m
+	b.lower = make([]float64, total)
+	b.upper = make([]float64, total)
+	b.cost = make([]float64, total)
+	b.columns = make([]sparseVector, total)
+	b.fixed = make([]bool, total)
+	b.ctx = context.Background()
+	b.tolerances = model.tolerances
+
+	copy(b.lower, model.lower)
+	copy(b.upper, model.upper)
+	copy(b.cost, model.c)
+	// the structural columns are shared with the model
+	for j := 0; j < b.n; j++ {
+		b.columns[j] = *model.column(j)
+	}
+	for i := 0; i < b.m; i++ {
+		logical := b.n + i
+		b.columns[logical] = sparseVector{[]int{i}, []float64{1}}
+		switch model.ops[i] {
+		case OperatorLE:
+			b.lower[logical] = 0
+			b.upper[logical] = math.Inf(1)
+		case OperatorGE:
+			b.lower[logical] = math.Inf(-1)
+			b.upper[logical] = 0
+		default:
+			b.lower[logical] = 0
+			b.upper[logical] = 0
+		}
+	}
+	for j := range b.fixed {
+		b.fixed[j] = b.lower[j] == b.upper[j]
+	}
+	return b
+}
+
+func (self *barrier) hasLower(j int) bool {
+	return !self.fixed[j] && !math.IsInf(self.lower[j], -1)
+}
+
+func (self *barrier) hasUpper(j int) bool {
+	return !self.fixed[j] && !math.IsInf(self.upper[j], 1)
+}
+
+// start sets the initial point: the columns at the size of the right side
+// inside their bounds or in the middle of a narrower range, the duals of
+// the bounds at the size of the costs and y = 0.
+func (self *barrier) start() {
+	total := self.n + self.m
+	self.x = make([]float64, total)
+	self.y = make([]float64, self.m)
+	self.z = make([]float64, total)
+	self.w = make([]float64, total)
+
+	offset := 1.0
+	for _, value := range self.model.b {
+		offset = math.Max(offset, math.Abs(value))
+	}
+	dual := 1.0
+	for _, value := range self.cost {
+		dual = math.Max(dual, math.Abs(value))
+	}
+	for j := 0; j < total; j++ {
+		lower, upper := self.lower[j], self.upper[j]
+		switch {
+		case self.fixed[j]:
+			self.x[j] = lower
+		case self.hasLower(j) && self.hasUpper(j):
+			self.x[j] = lower + math.Min(offset, (upper-lower)/2)
+		case self.hasLower(j):
+			self.x[j] = lower + offset
+		case self.hasUpper(j):
+			self.x[j] = upper - offset
+		}
+		if self.hasLower(j) {
+			self.z[j] = dual
+		}
+		if self.hasUpper(j) {
+			self.w[j] = dual
+		}
+	}
+}
+
+// residuals computes rp = b - [A I]x and rd = c - [A I]^Ty - z + w.
+func (self *barrier) residuals(rp, rd []float64) {
+	copy(rp, self.model.b)
+	for j := range self.columns {
+		column := &self.columns[j]
+		for k, i := range column.index {
+			rp[i] -= column.value[k] * self.x[j]
+		}
+		rd[j] = 0
+		if !self.fixed[j] {
+			rd[j] = self.cost[j] - column.dot(self.y) - self.z[j] + self.w[j]
+		}
+	}
+}
+
+// complementarity returns the average product of the distance to a bound
+// and its dual after steps ap and ad along dx, dz and dw, and the number
+// of bounds.
+func (self *barrier) complementarity(ap, ad float64, dx, dz, dw []float64) (float64, int) {
+	sum := 0.0
+	count := 0
+	for j := range self.x {
+		if self.hasLower(j) {
+			sum += (self.x[j] + ap*dx[j] - self.lower[j]) * (self.z[j] + ad*dz[j])
+			count++
+		}
+		if self.hasUpper(j) {
+			sum += (self.upper[j] - self.x[j] - ap*dx[j]) * (self.w[j] + ad*dw[j])
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return sum / float64(count), count
+}
+
+// merit returns the largest of the residuals and the duality gap relative
+// to the size of the problem.
+func (self *barrier) merit(rp, rd []float64, mu float64, count int) float64 {
+	bNorm, cNorm, objective := 0.0, 0.0, 0.0
+	for _, value := range self.model.b {
+		bNorm = math.Max(bNorm, math.Abs(value))
+	}
+	for j, value := range self.cost {
+		cNorm = math.Max(cNorm, math.Abs(value))
+		objective += value * self.x[j]
+	}
+	return math.Max(math.Max(maxAbs(rp)/(1+bNorm), maxAbs(rd)/(1+cNorm)),
+		mu*float64(count)/(1+math.Abs(objective)))
+}
+
+// diverged returns whether the iterates grew without limit.
+func (self *barrier) diverged() bool {
+	return maxAbs(self.x) > barrierDivergence || maxAbs(self.y) > barrierDivergence
+}
+
+func maxAbs(x []float64) float64 {
+	largest := 0.0
+	for _, value := range x {
+		largest = math.Max(largest, math.Abs(value))
+	}
+	return largest
+}
+
+// factorize factorizes the normal equations [A I] diag(theta) [A I]^T,
+// regularized so that dependent rows do not make them singular.
+func (self *barrier) factorize(theta []float64) *sparseLU {
+	normal := newSparseMatrix(self.m, self.m)
+	for j := range self.columns {
+		if theta[j] == 0 {
+			continue
+		}
+		column := &self.columns[j]
+		for k, i := range column.index {
+			for l, h := range column.index {
+				*normal.ref(i, h) += theta[j] * column.value[k] * column.value[l]
+			}
+		}
+	}
+	for i := 0; i < self.m; i++ {
+		*normal.ref(i, i) *= 1 + barrierRegularization
+		*normal.ref(i, i) += barrierRegularization
+	}
+
+	factor := newSparseLU(self.m, 0)
+	if !factor.factorizeRows(normal) {
+		return nil
+	}
+	return factor
+}
+
+// direction computes the Newton direction for the residuals rp and rd and
+// the complementarity residuals rl and ru of the lower and upper bounds.
+func (self *barrier) direction(factor *sparseLU, theta, rp, rd, rl, ru,
+	dx, dy, dz, dw []float64) {
+	// eliminating dz and dw leaves -theta^-1 dx + [A I]^Tdy = h
+	h := make([]float64, len(self.x))
+	copy(dy, rp)
+	for j := range self.columns {
+		if theta[j] == 0 {
+			continue
+		}
+		h[j] = rd[j]
+		if self.hasLower(j) {
+			h[j] -= rl[j] / (self.x[j] - self.lower[j])
+		}
+		if self.hasUpper(j) {
+			h[j] += ru[j] / (self.upper[j] - self.x[j])
+		}
+		column := &self.columns[j]
+		for k, i := range column.index {
+			dy[i] += column.value[k] * theta[j] * h[j]
+		}
+	}
+	factor.ftran(dy)
+
+	// refinement removes the error of the regularization from [A I]dx = rp
+	residual := make([]float64, self.m)
+	for step := 0; ; step++ {
+		copy(residual, rp)
+		for j := range self.columns {
+			dx[j] = 0
+			if theta[j] != 0 {
+				dx[j] = theta[j] * (self.columns[j].dot(dy) - h[j])
+			}
+			column := &self.columns[j]
+			for k, i := range column.index {
+				residual[i] -= column.value[k] * dx[j]
+			}
+		}
+		if step == barrierRefinementSteps || maxAbs(residual) <= barrierTolerance*maxAbs(rp) {
+			break
+		}
+		factor.ftran(residual)
+		addVectorsScaled(dy, residual, 1, self.m)
+	}
+
+	for j := range self.columns {
+		dz[j], dw[j] = 0, 0
+		if self.fixed[j] {
+			continue
+		}
+		lowerGap, upperGap := self.x[j]-self.lower[j], self.upper[j]-self.x[j]
+		if self.hasLower(j) && lowerGap > 0 {
+			dz[j] = (rl[j] - self.z[j]*dx[j]) / lowerGap
+		}
+		if self.hasUpper(j) && upperGap > 0 {
+			dw[j] = (ru[j] + self.w[j]*dx[j]) / upperGap
+		}
+		// a column that reached a bound in floating point stays there and
+		// the dual of the bound takes its dual residual
+		switch {
+		case self.hasLower(j) && lowerGap <= 0:
+			dz[j] = rd[j] - self.columns[j].dot(dy) + dw[j]
+		case self.hasUpper(j) && upperGap <= 0:
+			dw[j] = self.columns[j].dot(dy) + dz[j] - rd[j]
+		}
+	}
+}
+
+// stepLengths returns the largest primal and dual steps up to 1 along the
+// direction that keep the iterates inside, times factor.
+func (self *barrier) stepLengths(dx, dz, dw []float64, factor float64) (float64, float64) {
+	ap, ad := 1.0, 1.0
+	for j := range self.x {
+		if self.hasLower(j) {
+			if dx[j] < 0 {
+				ap = math.Min(ap, factor*(self.x[j]-self.lower[j])/-dx[j])
+			}
+			if dz[j] < 0 {
+				ad = math.Min(ad, factor*self.z[j]/-dz[j])
+			}
+		}
+		if self.hasUpper(j) {
+			if dx[j] > 0 {
+				ap = math.Min(ap, factor*(self.upper[j]-self.x[j])/dx[j])
+			}
+			if dw[j] < 0 {
+				ad = math.Min(ad, factor*self.w[j]/-dw[j])
+			}
+		}
+	}
+	return ap, ad
+}
+
+// solve runs the barrier until it converges and returns ResultOptimal then.
+// It returns ResultSubOptimal if it stalls or reaches its iteration limit,
+// and ResultNumFailure if it diverges or can not factorize the normal
+// equations.
+func (self *barrier) solve() int {
+	total := self.n + self.m
+	rp := make([]float64, self.m)
+	rd := make([]float64, total)
+	rl := make([]float64, total)
+	ru := make([]float64, total)
+	theta := make([]float64, total)
+	dx := make([]float64, total)
+	dy := make([]float64, self.m)
+	dz := make([]float64, total)
+	dw := make([]float64, total)
+
+	best, stalled := math.Inf(1), 0
+	self.start()
+	for {
+		self.residuals(rp, rd)
+		mu, count := self.complementarity(0, 0, dx, dz, dw)
+		merit := self.merit(rp, rd, mu, count)
+		if merit <= barrierTolerance {
+			return ResultOptimal
+		}
+		if merit < best/2 {
+			best, stalled = merit, 0
+		} else {
+			stalled++
+		}
+		if self.diverged() {
+			return ResultNumFailure
+		}
+		if self.iterations >= barrierMaxIterations || stalled >= barrierStallIterations {
+			return ResultSubOptimal
+		}
+		if result, ok := interrupted(self.ctx); ok {
+			return result
+		}
+		self.iterations++
+
+		for j := range theta {
+			theta[j] = 0
+			if self.fixed[j] {
+				continue
+			}
+			if (self.hasLower(j) && self.x[j] <= self.lower[j]) ||
+				(self.hasUpper(j) && self.x[j] >= self.upper[j]) {
+				continue
+			}
+			inverse := barrierRegularization
+			if self.hasLower(j) {
+				inverse += self.z[j] / (self.x[j] - self.lower[j])
+			}
+			if self.hasUpper(j) {
+				inverse += self.w[j] / (self.upper[j] - self.x[j])
+			}
+			theta[j] = 1 / inverse
+		}
+		factor := self.factorize(theta)
+		if factor == nil {
+			return ResultNumFailure
+		}
+
+		// the predictor aims at the optimum directly
+		for j := range self.x {
+			rl[j], ru[j] = 0, 0
+			if self.hasLower(j) {
+				rl[j] = -(self.x[j] - self.lower[j]) * self.z[j]
+			}
+			if self.hasUpper(j) {
+				ru[j] = -(self.upper[j] - self.x[j]) * self.w[j]
+			}
+		}
+		self.direction(factor, theta, rp, rd, rl, ru, dx, dy, dz, dw)
+		ap, ad := self.stepLengths(dx, dz, dw, 1)
+		affine, _ := self.complementarity(ap, ad, dx, dz, dw)
+
+		// the corrector centers by how much the predictor reduced the
+		// complementarity and corrects its second order error
+		sigma := math.Pow(affine/mu, 3)
+		for j := range self.x {
+			if self.hasLower(j) {
+				rl[j] = sigma*mu - (self.x[j]-self.lower[j])*self.z[j] - dx[j]*dz[j]
+			}
+			if self.hasUpper(j) {
+				ru[j] = sigma*mu - (self.upper[j]-self.x[j])*self.w[j] + dx[j]*dw[j]
+			}
+		}
+		self.direction(factor, theta, rp, rd, rl, ru, dx, dy, dz, dw)
+		ap, ad = self.stepLengths(dx, dz, dw, barrierStepFactor)
+
+		addVectorsScaled(self.x, dx, ap, total)
+		addVectorsScaled(self.y, dy, ad, self.m)
+		addVectorsScaled(self.z, dz, ad, total)
+		addVectorsScaled(self.w, dw, ad, total)
+	}
+}
+
+// crossover installs in s a basis of the columns that the barrier left
+// further from their bounds than their duals, completed by logical
+// columns, and re-optimizes from it. The other columns start at their
+// nearer bound.
+func (self *barrier) crossover(s *simplex) int {
+	status := make([]int, self.n+self.m)
+	var candidates []int
+	for j := range self.x {
+		if self.fixed[j] {
+			status[j] = columnAtLower
+			continue
+		}
+		lowerGap, upperGap := math.Inf(1), math.Inf(1)
+		if self.hasLower(j) {
+			lowerGap = self.x[j] - self.lower[j]
+		}
+		if self.hasUpper(j) {
+			upperGap = self.upper[j] - self.x[j]
+		}
+		gap, dual := lowerGap, self.z[j]
+		status[j] = columnAtLower
+		if upperGap < lowerGap {
+			gap, dual = upperGap, self.w[j]
+			status[j] = columnAtUpper
+		}
+		if math.IsInf(gap, 1) {
+			status[j] = columnAtZero
+		}
+		if gap > dual {
+			candidates = append(candidates, j)
+		}
+	}
+	for _, j := range self.basisColumns(candidates) {
+		status[j] = columnBasic
+	}
+
+	if !s.setBasis(status) {
+		return s.solve()
+	}
+	return s.warmSolve()
+}
+
+// basisColumns returns the columns of a basis: linearly independent
+// candidates, picked by the sparse LU factorization, and the logicals of
+// the rows that none of them pivots on.
+func (self *barrier) basisColumns(candidates []int) []int {
+	a := newSparseMatrix(self.m, len(candidates))
+	for k, j := range candidates {
+		column := &self.columns[j]
+		for l, i := range column.index {
+			a.rows[i].index = append(a.rows[i].index, k)
+			a.rows[i].value = append(a.rows[i].value, column.value[l])
+		}
+	}
+	lu := newSparseLU(self.m, self.tolerances.Pivot)
+	steps := lu.eliminate(a)
+
+	chosen := make([]int, 0, self.m)
+	pivoted := make([]bool, self.m)
+	for step := 0; step < steps; step++ {
+		chosen = append(chosen, candidates[lu.pivotColumns[step]])
+		pivoted[lu.pivotRows[step]] = true
+	}
+	for i := 0; i < self.m; i++ {
+		if !pivoted[i] {
+			chosen = append(chosen, self.n+i)
+		}
+	}
+	return chosen
+}
+
+// InteriorPointSolver solves the same linear programs as SimplexSolver with
+// a primal-dual interior point method, whose iterations take time
+// proportional to the factorization of sparse normal equations instead of
+// pivoting through the vertices. A crossover then moves the solution to a
+// vertex with the simplex method, so the values, duals, sensitivity and
+// results are those of a basic solution. If the interior point method
+// stalls, the crossover starts from its last iterate, and if it diverges,
+// e.g. because the problem is infeasible or unbounded, the simplex method
+// solves the problem from scratch and decides it.
+type InteriorPointSolver struct {
+	*SimplexSolver
+}
+
+func NewInteriorPointSolver(ls *LinearSpec) *InteriorPointSolver {
+	ips := &InteriorPointSolver{}
+	ips.SimplexSolver = NewSimplexSolver(ls)
+
+	return ips
+}
+
+func (self *InteriorPointSolver) Solve() int {
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves like Solve but stops with ResultTimeout or
+// ResultUserAbort when ctx is done. The variables only get values if the
+// crossover reached a feasible basis.
+func (self *InteriorPointSolver) SolveContext(ctx context.Context) int {
+	iterations := 0
+	result := self.SimplexSolver.solve(ctx, func(s *simplex) int {
+		b := newBarrier(s.model)
+		b.ctx = ctx
+		result := b.solve()
+		iterations = b.iterations
+		if result == ResultOptimal || result == ResultSubOptimal {
+			return b.crossover(s)
+		}
+		if result, ok := interrupted(ctx); ok {
+			return result
+		}
+		return s.solve()
+	})
+	self.stats.BarrierIterations = iterations
+	return result
+}
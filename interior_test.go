@@ -0,0 +1,159 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestInteriorPoint(t *testing.T) {
+	fmt.Println("Test InteriorPoint")
+
+	build := func(solver string) (*LinearSpec, []*Variable, []*Constraint) {
+		ls := NewLinearSpec()
+		if solver == "barrier" {
+			ls.SetSolver(NewInteriorPointSolver(ls))
+		} else {
+			ls.SetSolver(NewSimplexSolver(ls))
+		}
+		x, _ := ls.AddVariable(nil)
+		y, _ := ls.AddVariable(nil)
+		z, _ := ls.AddVariable(nil)
+		x.SetRange(0, 3)
+		y.SetRange(0, math.Inf(1))
+		z.SetRange(-2, 2)
+		c1, _ := ls.AddConstraint2([]float64{1, 1, 1}, []*Variable{x, y, z}, OperatorLE, 4)
+		c2, _ := ls.AddConstraint2([]float64{1, 3}, []*Variable{x, y}, OperatorLE, 7)
+		c3, _ := ls.AddConstraint2([]float64{1, -1}, []*Variable{y, z}, OperatorGE, -1)
+		ls.SetObjective1([]float64{3, 2, 1}, []*Variable{x, y, z}, OptMaximize)
+		return ls, []*Variable{x, y, z}, []*Constraint{c1, c2, c3}
+	}
+	ls, vars, constraints := build("barrier")
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	if ls.Stats().BarrierIterations == 0 {
+		t.Error("no barrier iterations")
+	}
+	ref, refVars, refConstraints := build("simplex")
+	ref.Solve()
+	checkValue(t, "objective", ls.ObjectiveValue(), ref.ObjectiveValue())
+	for i, v := range vars {
+		checkValue(t, fmt.Sprintf("x%v", i), v.Value(), refVars[i].Value())
+		checkValue(t, fmt.Sprintf("reduced cost %v", i), v.ReducedCost(),
+			refVars[i].ReducedCost())
+	}
+	for i, c := range constraints {
+		checkValue(t, fmt.Sprintf("dual %v", i), c.Dual(), refConstraints[i].Dual())
+	}
+	if _, err := ls.Sensitivity(); err != nil {
+		t.Errorf("sensitivity: %v", err)
+	}
+
+	// a wide layout, with the basis of the crossover close to the optimum
+	ls = NewLinearSpec()
+	ls.SetSolver(NewInteriorPointSolver(ls))
+	tabs, border := newColumnLayout(ls, 50, 10)
+	ls.SetObjective1([]float64{-1.0}, tabs[1:2], OptMinimize)
+	border.SetRightSide(1000)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("layout result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "tab 1", tabs[1].Value(), 510)
+	checkValue(t, "tab 50", tabs[50].Value(), 1000)
+	border.SetRightSide(400)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("narrow layout result = %v, want %v", result, ResultInfeasible)
+	}
+	if ls.Solver().(*InteriorPointSolver).InfeasibleConstraint() == nil {
+		t.Error("no infeasible constraint")
+	}
+
+	ls = NewLinearSpec()
+	ls.SetSolver(NewInteriorPointSolver(ls))
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, math.Inf(1))
+	y.SetRange(0, math.Inf(1))
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorLE, 1)
+	ls.SetObjective1([]float64{1, -0.5}, []*Variable{x, y}, OptMaximize)
+	if result, _ := ls.Solve(); result != ResultUnbounded {
+		t.Fatalf("result = %v, want %v", result, ResultUnbounded)
+	}
+	if _, err := ls.UnboundedRay(); err != nil {
+		t.Errorf("ray: %v", err)
+	}
+}
+
+// TestInteriorPointLarge solves a layout of tens of thousands of rows, whose
+// dense constraint matrix would take gigabytes.
+func TestInteriorPointLarge(t *testing.T) {
+	fmt.Println("Test InteriorPoint Large")
+
+	const n = 20000
+	ls := NewLinearSpec()
+	ls.SetSolver(NewInteriorPointSolver(ls))
+	tabs, border := newColumnLayout(ls, n, 10)
+	ls.SetObjective1([]float64{-1.0}, tabs[1:2], OptMinimize)
+	border.SetRightSide(20 * n)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "tab 1", tabs[1].Value(), 10*n+10)
+	checkValue(t, "tab n", tabs[n].Value(), 20*n)
+}
+
+func TestInteriorPointRandom(t *testing.T) {
+	fmt.Println("Test InteriorPoint Random")
+
+	random := rand.New(rand.NewSource(7))
+	for problem := 0; problem < 200; problem++ {
+		seed := random.Int63()
+		build := func(solver SolverLike, ls *LinearSpec) {
+			random := rand.New(rand.NewSource(seed))
+			ls.SetSolver(solver)
+			n := 2 + random.Intn(20)
+			vars := make([]*Variable, n)
+			coeffs := make([]float64, n)
+			for i := range vars {
+				vars[i], _ = ls.AddVariable(nil)
+				switch random.Intn(4) {
+				case 0:
+					vars[i].SetRange(float64(random.Intn(3)-1), float64(2+random.Intn(8)))
+				case 1:
+					vars[i].SetMin(0)
+				case 2:
+					vars[i].SetRange(math.Inf(-1), float64(random.Intn(5)))
+				default:
+					vars[i].SetRange(math.Inf(-1), math.Inf(1))
+				}
+				coeffs[i] = float64(random.Intn(7) - 3)
+			}
+			for k := 1 + random.Intn(15); k > 0; k-- {
+				row := make([]float64, n)
+				for i := range row {
+					if random.Intn(3) > 0 {
+						row[i] = float64(random.Intn(9)-4) / 4
+					}
+				}
+				op := []int{OperatorLE, OperatorGE, OperatorEQ}[random.Intn(3)]
+				ls.AddConstraint2(row, vars, op, float64(random.Intn(17)-8))
+			}
+			ls.SetObjective1(coeffs, vars, []int{OptMinimize, OptMaximize}[random.Intn(2)])
+		}
+		ref := NewLinearSpec()
+		build(NewSimplexSolver(ref), ref)
+		want, _ := ref.Solve()
+		ls := NewLinearSpec()
+		build(NewInteriorPointSolver(ls), ls)
+		if result, _ := ls.Solve(); result != want {
+			t.Errorf("problem %v: result = %v, want %v", problem, result, want)
+			continue
+		}
+		if want == ResultOptimal {
+			checkValue(t, fmt.Sprintf("problem %v", problem), ls.ObjectiveValue(),
+				ref.ObjectiveValue())
+		}
+	}
+}
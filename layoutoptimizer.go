@@ -1,30 +1,52 @@
 package lp
 
-import "errors"
+import (
+	"context"
+	"math"
+)
+
+// how the active set sees a variable bound
+const (
+	boundInactive = iota
+	boundAtLower
+	boundAtUpper
+)
 
 type LayoutOptimizer struct {
 	variableCount int
 	constraints   *ConstraintList
 
-	temp1            [][]float64
-	temp2            [][]float64
-	zTrans           [][]float64
-	q                [][]float64
-	activeMatrix     [][]float64
-	activeMatrixTemp [][]float64
-	softConstraints  [][]float64
-	g                [][]float64
-	desired          []float64
+	activeMatrix    *sparseMatrix
+	softConstraints *sparseMatrix
+	g               *sparseMatrix
+	desired         []float64
+	// objective 1/2 x^TQx + c^Tx added to the penalty function
+	objective *sparseMatrix
+	linear    []float64
+	// penalty weight of each soft constraint
+	weights []float64
+	// multiplier of each constraint at the optimum
+	duals []float64
+	// variable bounds, nil if the variables are free
+	lower, upper []float64
+	// multiplier of the active bound of each variable at the optimum
+	boundDuals []float64
+	tolerances Tolerances
+
+	// statistics of the last solve
+	iterations        int
+	activeConstraints int
 }
 
 func NewLayoutOptimizer(list *ConstraintList, variableCount int) *LayoutOptimizer {
 	lo := &LayoutOptimizer{}
+	lo.tolerances = DefaultTolerances()
 	lo.SetConstraints(list, variableCount)
 
 	return lo
 }
 
-func (self *LayoutOptimizer) SetConstraints(list *ConstraintList, variableCount int) bool {
+func (self *LayoutOptimizer) SetConstraints(list *ConstraintList, variableCount int) error {
 	self.constraints = list
 	constraintCount := self.constraints.Len()
 
@@ -33,8 +55,9 @@ func (self *LayoutOptimizer) SetConstraints(list *ConstraintList, variableCount
 		self.init(variableCount, constraintCount)
 	}
 
-	zeroMatrix(self.softConstraints, constraintCount, self.variableCount)
+	self.softConstraints = newSparseMatrix(constraintCount, self.variableCount)
 	rightSide := make([]float64, constraintCount)
+	self.weights = make([]float64, constraintCount)
 
 	// set up soft constraint matrix
 	for c := 0; c < self.constraints.Len(); c++ {
@@ -56,37 +79,74 @@ func (self *LayoutOptimizer) SetConstraints(list *ConstraintList, variableCount
 			weight /= 2
 		}
 
+		self.weights[c] = weight
 		rightSide[c] = self.rightSide(constraint) * weight
 		summands := constraint.LeftSide()
 		for s := 0; s < summands.Len(); s++ {
 			summand := summands.GetAt(s)
 			variable := summand.Var().Index()
 			if constraint.Op() == OperatorLE {
-				self.softConstraints[c][variable] = -summand.Coeff()
+				*self.softConstraints.ref(c, variable) = -summand.Coeff()
 			} else {
-				self.softConstraints[c][variable] = summand.Coeff()
+				*self.softConstraints.ref(c, variable) = summand.Coeff()
 			}
-			self.softConstraints[c][variable] *= weight
+			*self.softConstraints.ref(c, variable) *= weight
 		}
 	}
 
-	// create g
-	transposeMatrix(self.softConstraints, self.temp1, constraintCount, self.variableCount)
-	multiplyMatrices(self.temp1, self.softConstraints, self.g, self.variableCount,
-		constraintCount, self.variableCount)
+	// create g = S^TS, each soft constraint only adds the products of its
+	// own coefficients
+	self.g = newSparseMatrix(self.variableCount, self.variableCount)
+	for c := 0; c < constraintCount; c++ {
+		row := &self.softConstraints.rows[c]
+		for k, i := range row.index {
+			for l, j := range row.index {
+				*self.g.ref(i, j) += row.value[k] * row.value[l]
+			}
+		}
+	}
 
 	// create d
-	multiplyMatrixVector(self.temp1, rightSide, self.variableCount, constraintCount, self.desired)
+	self.softConstraints.transpose().multiplyVector(rightSide, self.desired)
 	negateVector(self.desired, self.variableCount)
 
-	return true
+	if self.objective != nil {
+		for i := 0; i < self.variableCount; i++ {
+			row := &self.objective.rows[i]
+			for k, j := range row.index {
+				*self.g.ref(i, j) += row.value[k]
+			}
+		}
+		addVectors(self.desired, self.linear, self.variableCount)
+	}
+
+	return self.InitCheck()
+}
+
+// SetObjective adds the objective 1/2 x^TQx + c^Tx to the penalty function
+// of the soft constraints. q must be symmetric and positive semidefinite.
+func (self *LayoutOptimizer) SetObjective(q *sparseMatrix, c []float64) error {
+	self.objective = q
+	self.linear = c
+	return self.SetConstraints(self.constraints, self.variableCount)
+}
+
+// SetBounds sets the bounds of the variables, which the active set keeps
+// by fixing the variables at an active bound instead of by constraints.
+func (self *LayoutOptimizer) SetBounds(lower, upper []float64) {
+	self.lower = lower
+	self.upper = upper
+}
+
+// SetTolerances sets the tolerances of the active set method.
+func (self *LayoutOptimizer) SetTolerances(tolerances Tolerances) {
+	self.tolerances = tolerances
 }
 
 func (self *LayoutOptimizer) InitCheck() error {
-	if self.temp1 == nil || self.temp2 == nil || self.zTrans == nil || self.q == nil ||
-		self.softConstraints == nil || self.g == nil || self.desired == nil {
+	if self.softConstraints == nil || self.g == nil || self.desired == nil {
 
-		return errors.New("No memory")
+		return ErrNoMemory
 	}
 	return nil
 }
@@ -114,11 +174,7 @@ func (self *LayoutOptimizer) rightSide(c *Constraint) float64 {
 }
 
 func (self *LayoutOptimizer) makeEmpty() {
-	self.temp1 = nil
-	self.temp2 = nil
-	self.zTrans = nil
 	self.softConstraints = nil
-	self.q = nil
 	self.g = nil
 	self.desired = nil
 }
@@ -126,18 +182,13 @@ func (self *LayoutOptimizer) makeEmpty() {
 func (self *LayoutOptimizer) init(variableCount, nConstraints int) {
 	self.variableCount = variableCount
 
-	maxExtend := max(variableCount, nConstraints)
-	self.temp1 = initMatrixSlice(maxExtend, maxExtend)
-	self.temp2 = initMatrixSlice(maxExtend, maxExtend)
-	self.zTrans = initMatrixSlice(nConstraints, self.variableCount)
-	self.softConstraints = initMatrixSlice(nConstraints, self.variableCount)
-	self.q = initMatrixSlice(nConstraints, self.variableCount)
-	self.g = initMatrixSlice(nConstraints, nConstraints)
+	self.softConstraints = newSparseMatrix(nConstraints, self.variableCount)
+	self.g = newSparseMatrix(self.variableCount, self.variableCount)
 
 	self.desired = make([]float64, self.variableCount)
 }
 
-func (self *LayoutOptimizer) solve(values []float64) bool {
+func (self *LayoutOptimizer) solve(ctx context.Context, values []float64) bool {
 	if values == nil {
 		return false
 	}
@@ -165,6 +216,8 @@ func (self *LayoutOptimizer) solve(values []float64) bool {
 
 	// init active set
 	activeConstraints := newConstraintList()
+	self.iterations = 0
+	self.activeConstraints = 0
 
 	for i := 0; i < constraintCount; i++ {
 		constraint := self.constraints.GetAt(i)
@@ -174,34 +227,55 @@ func (self *LayoutOptimizer) solve(values []float64) bool {
 
 		actualValue := self.actualValue(constraint, x)
 
-		if fuzzyEquals(actualValue, self.rightSide(constraint)) {
+		if math.Abs(actualValue-self.rightSide(constraint)) < self.tolerances.PrimalFeasibility {
 			activeConstraints.AddItem(constraint)
 		}
 	}
 
+	// the variables at a bound are fixed
+	bounds := make([]int, self.variableCount)
+	activeBounds := 0
+	for i := 0; self.lower != nil && i < self.variableCount; i++ {
+		switch {
+		case math.Abs(x[i]-self.lower[i]) < self.tolerances.PrimalFeasibility:
+			bounds[i] = boundAtLower
+		case math.Abs(x[i]-self.upper[i]) < self.tolerances.PrimalFeasibility:
+			bounds[i] = boundAtUpper
+		default:
+			continue
+		}
+		activeBounds++
+	}
+
 	// The main loop: Each iteration we try to get closer to the optimum
 	// solution. We compute a vector p that brings our x closer to the optimum.
 	// We do that by computing the QP resulting from our active constraint set,
 	// W^k. Afterward each iteration we adjust the active set.
 
 	for true {
+		// stop with the current feasible x
+		if ctx.Err() != nil {
+			self.setResult(x, values)
+			return false
+		}
+		self.iterations++
+		self.activeConstraints = activeConstraints.Len() + activeBounds
+
 		// solve the QP:
 		//   min_p 1/2p^TGp + g_k^Tp
 		//   s.t. a_i^Tp = 0
+		//        p_j = 0
 		//   with a_i \in activeConstraints
+		//        x_j at an active bound
 		//        g_k = Gx_k + d
 		//        p = x - x_k
 
 		activeCount := activeConstraints.Len()
-		if activeCount == 0 {
-			return false
-		}
 
 		// construct a matrix from the active constraints
-		am := activeCount
 		an := self.variableCount
 		independentRows := make([]bool, activeCount)
-		zeroMatrix(self.activeMatrix, am, an)
+		self.activeMatrix = newSparseMatrix(activeCount, an)
 
 		for i := 0; i < activeCount; i++ {
 			constraint := activeConstraints.GetAt(i)
@@ -209,75 +283,48 @@ func (self *LayoutOptimizer) solve(values []float64) bool {
 				continue
 			}
 
+			// the fixed variables are left out
 			summands := constraint.LeftSide()
 			for s := 0; s < summands.Len(); s++ {
 				summand := summands.GetAt(s)
 				variable := summand.Var().Index()
+				if bounds[variable] != boundInactive {
+					continue
+				}
 				if constraint.Op() == OperatorLE {
-					self.activeMatrix[i][variable] = -summand.Coeff()
+					*self.activeMatrix.ref(i, variable) = -summand.Coeff()
 				} else {
-					self.activeMatrix[i][variable] = summand.Coeff()
+					*self.activeMatrix.ref(i, variable) = summand.Coeff()
 				}
 			}
 		}
 
-		am = removeLinearyDependentRows(self.activeMatrix, self.activeMatrixTemp,
-			independentRows, am, an)
+		removeSparseDependentRows(self.activeMatrix, independentRows, self.tolerances.Zero)
 
 		// gxd = G * x + d
 		gxd := make([]float64, self.variableCount)
-		multiplyMatrixVector(self.g, x, self.variableCount, self.variableCount, gxd)
+		self.g.multiplyVector(x, gxd)
 		addVectors(gxd, d, self.variableCount)
 
 		p := make([]float64, self.variableCount)
+		lambda := make([]float64, self.activeMatrix.m)
 
-		if !self.solveSubProblem(gxd, am, p) {
+		if !self.solveSubProblem(gxd, p, lambda, bounds) {
 			return false
 		}
 
-		if isZero(p, self.variableCount) {
-			// compute Lagrange multipliers lambda_i
-			// if lambda_i >= 0 for all i \in W^k \union inequality constraints,
-			// then we're done.
+		if isZero(p, self.variableCount, self.tolerances.Zero) {
+			// The Lagrange multipliers lambda_i of the subproblem tell
+			// whether we're done: if lambda_i >= 0 for all i \in W^k \union
+			// inequality constraints, we are.
 			// Otherwise remove the constraint with the smallest lambda_i
-			// from the active set.
-			// The derivation of the Lagrangian yields:
-			//   \sum_{i \in W^k}(lambda_ia_i) = Gx_k + d
-			// Which is an system we can solve:
-			//   A^Tlambda = Gx_k + d
-
-			// A^T is over-determined, hence we need to reduce the number of
-			// rows before we can solve it.
-
-			independentColumns := make([]bool, an)
-			aa := self.temp1
-			transposeMatrix(self.activeMatrix, aa, am, an)
-			aam := removeLinearyDependentRows(aa, self.temp2, independentColumns, an, am)
-			aan := am
-
-			if aam != aan {
-				return false
-			}
-
-			// also reduce the number of rows on the right hand side
-			lambda := make([]float64, aam)
-			index := 0
-			for i := 0; i < an; i++ {
-				if independentColumns[i] {
-					lambda[index] = gxd[i]
-					index++
-				}
-			}
-
-			success := solve(aa, am, lambda)
-			if !success {
-				return false
-			}
-
+			// from the active set. The active bounds have multipliers as
+			// well.
 			// find min lambda_i (only, if it's < 0, though)
 			minLambda := 0.0
 			minIndex := -1
-			index = 0
+			minBound := -1
+			index := 0
 			for i := 0; i < activeCount; i++ {
 				if independentRows[i] {
 					constraint := activeConstraints.GetAt(i)
@@ -291,15 +338,33 @@ func (self *LayoutOptimizer) solve(values []float64) bool {
 					index++
 				}
 			}
+			mu := self.boundMultipliers(gxd, activeConstraints, independentRows, lambda, bounds)
+			for i := range mu {
+				// a fixed variable keeps its bounds
+				if bounds[i] == boundInactive || self.lower[i] == self.upper[i] {
+					continue
+				}
+				if mu[i] < minLambda {
+					minLambda = mu[i]
+					minIndex = -1
+					minBound = i
+				}
+			}
 
 			// if the min lambda is >= 0, we're done
-			if minIndex < 0 || fuzzyEquals(minLambda, 0) {
+			if (minIndex < 0 && minBound < 0) || minLambda > -self.tolerances.DualFeasibility {
+				self.setDuals(x, activeConstraints, independentRows, lambda, bounds, mu)
 				self.setResult(x, values)
 				return true
 			}
 
 			// remove i from the active set
-			activeConstraints.RemoveItemAt(minIndex)
+			if minBound >= 0 {
+				bounds[minBound] = boundInactive
+				activeBounds--
+			} else {
+				activeConstraints.RemoveItemAt(minIndex)
+			}
 		} else {
 			// compute alpha_k
 			alpha := 1.0
@@ -312,7 +377,7 @@ func (self *LayoutOptimizer) solve(values []float64) bool {
 				}
 
 				divider := self.actualValue(constraint, p)
-				if divider > 0 || fuzzyEquals(divider, 0) {
+				if divider > -self.tolerances.Zero {
 					continue
 				}
 
@@ -324,74 +389,92 @@ func (self *LayoutOptimizer) solve(values []float64) bool {
 					barrier = i
 				}
 			}
-
-			if alpha < 1 {
-				activeConstraints.AddItem(self.constraints.GetAt(barrier))
+			// or a bound
+			barrierBound := -1
+			barrierSide := boundInactive
+			for i := 0; self.lower != nil && i < self.variableCount; i++ {
+				if bounds[i] != boundInactive {
+					continue
+				}
+				alphaI := math.Inf(1)
+				side := boundAtLower
+				if p[i] < -self.tolerances.Zero {
+					alphaI = (self.lower[i] - x[i]) / p[i]
+				} else if p[i] > self.tolerances.Zero {
+					alphaI = (self.upper[i] - x[i]) / p[i]
+					side = boundAtUpper
+				}
+				if alphaI < alpha {
+					alpha = alphaI
+					barrier = -1
+					barrierBound = i
+					barrierSide = side
+				}
 			}
 
 			// x += p * alpha
 			addVectorsScaled(x, p, alpha, self.variableCount)
+
+			if alpha < 1 && barrierBound >= 0 {
+				bounds[barrierBound] = barrierSide
+				activeBounds++
+				x[barrierBound] = self.lower[barrierBound]
+				if barrierSide == boundAtUpper {
+					x[barrierBound] = self.upper[barrierBound]
+				}
+			} else if alpha < 1 {
+				activeConstraints.AddItem(self.constraints.GetAt(barrier))
+			}
 		}
 	}
 	return true
 }
 
-func (self *LayoutOptimizer) solveSubProblem(d []float64, am int, p []float64) bool {
+func (self *LayoutOptimizer) solveSubProblem(d, p, lambda []float64, bounds []int) bool {
 	// We have to solve the QP subproblem:
 	//   min_p 1/2p^TGp + d^Tp
 	//   s.t. a_i^Tp = 0
+	//        p_j = 0
 	//   with a_i \in activeConstraints
+	//        x_j at an active bound
 	//
-	// We use the null space method, i.e. we find matrices Y and Z, such that
-	// AZ = 0 and [Y Z] is regular. Then with
-	//   p = Yp_Y + Zp_z
-	// we get
-	//   p_Y = 0
-	// and
-	//  (Z^TGZ)p_Z = -(Z^TYp_Y + Z^Tg) = -Z^Td
-	// which is a linear equation system, which we can solve.
+	// Its optimum and the Lagrange multipliers solve the KKT system
+	//   [G -A^T] [p     ]   [-d]
+	//   [A    0] [lambda] = [ 0]
+	// which is as sparse as G and A. Unlike the null space method it needs
+	// no dense basis of the null space of A. The system is regular iff the
+	// rows of A are independent and G is positive definite on the null space.
+	// The row of a fixed variable is p_j = 0, A has no entries in its column.
 
 	an := self.variableCount
+	am := self.activeMatrix.m
 
-	// we get Y and Z by QR decomposition of A^T
-	tempD := make([]float64, am)
-	Q := self.q
-	transposeMatrix(self.activeMatrix, self.temp1, am, an)
-	success := qrDecomposition(self.temp1, an, am, tempD, Q)
-	if !success {
-		return false
+	kkt := newSparseMatrix(an+am, an+am)
+	rhs := make([]float64, an+am)
+	for i := 0; i < an; i++ {
+		if bounds[i] != boundInactive {
+			*kkt.ref(i, i) = 1
+			continue
+		}
+		row := &self.g.rows[i]
+		kkt.rows[i].index = append(kkt.rows[i].index, row.index...)
+		kkt.rows[i].value = append(kkt.rows[i].value, row.value...)
+		rhs[i] = -d[i]
 	}
-
-	// Z is the (1, m + 1) minor of Q
-	zm := an
-	zn := an - am
-
-	Z := make([][]float64, zm)
-	for i := 0; i < zm; i++ {
-		Z[i] = Q[i][am:]
+	for i := 0; i < am; i++ {
+		row := &self.activeMatrix.rows[i]
+		for k, j := range row.index {
+			*kkt.ref(an+i, j) = row.value[k]
+			*kkt.ref(j, an+i) = -row.value[k]
+		}
 	}
 
-	// solve (Z^TGZ)p_Z = -Z^Td
-
-	// Z^T
-	transposeMatrix(Z, self.zTrans, zm, zn)
-	// rhs: -Z^T * d
-	pz := make([]float64, zm)
-	multiplyMatrixVector(self.zTrans, d, zn, zm, pz)
-	negateVector(pz, zn)
-
-	// self.Temp2 = self.zTrans * G * Z
-	multiplyMatrices(self.g, Z, self.temp1, zm, self.variableCount, zn)
-	multiplyMatrices(self.zTrans, self.temp1, self.temp2, zn, zm, zn)
-
-	success = solve(self.temp2, zn, pz)
-	if !success {
+	if !sparseSolve(kkt, rhs, self.tolerances.Zero) {
 		return false
 	}
 
-	// p = Z * pz
-	multiplyMatrixVector(Z, pz, zm, zn, p)
-
+	copy(p, rhs[:an])
+	copy(lambda, rhs[an:])
 	return true
 }
 
@@ -403,20 +486,130 @@ func (self *LayoutOptimizer) solveSubProblem(d []float64, am int, p []float64) b
 	be overwritten with the optimial solution the method computes.
 */
 func (self *LayoutOptimizer) Solve(values []float64) (success bool) {
+	return self.SolveContext(context.Background(), values)
+}
+
+// SolveContext solves like Solve but stops when ctx is done; values get
+// the last feasible solution then.
+func (self *LayoutOptimizer) SolveContext(ctx context.Context, values []float64) (success bool) {
 	if values == nil {
 		return false
 	}
 
 	constraintCount := self.constraints.Len()
 
-	// allocate the active constraint matrix and its transposed matrix
-	self.activeMatrix = initMatrixSlice(constraintCount, self.variableCount)
-	self.activeMatrixTemp = initMatrixSlice(constraintCount, self.variableCount)
-
-	success = self.solve(values)
+	self.duals = make([]float64, constraintCount)
+	self.boundDuals = make([]float64, self.variableCount)
+	success = self.solve(ctx, values)
 	return
 }
 
+// Duals gets the multiplier of each constraint at the optimum of the last
+// successful solve, i.e. the change of the penalty function per unit
+// increase of the right side, in the order of the constraint list.
+func (self *LayoutOptimizer) Duals() []float64 {
+	return self.duals
+}
+
+// BoundDuals gets the multiplier of the active bound of each variable at
+// the optimum of the last successful solve, like Duals for the constraint
+// x >= lower or x <= upper; 0 if no bound is active.
+func (self *LayoutOptimizer) BoundDuals() []float64 {
+	return self.boundDuals
+}
+
+// boundMultipliers returns the Lagrange multipliers of the active bounds:
+// the part of the gradient gxd that the active constraints do not cancel,
+// turned to the inside of the bound.
+func (self *LayoutOptimizer) boundMultipliers(gxd []float64, activeConstraints *ConstraintList,
+	independentRows []bool, lambda []float64, bounds []int) []float64 {
+
+	mu := make([]float64, self.variableCount)
+	copy(mu, gxd)
+	index := 0
+	for i := 0; i < activeConstraints.Len(); i++ {
+		if !independentRows[i] {
+			continue
+		}
+		constraint := activeConstraints.GetAt(i)
+		summands := constraint.LeftSide()
+		for s := 0; s < summands.Len(); s++ {
+			summand := summands.GetAt(s)
+			coeff := summand.Coeff()
+			if constraint.Op() == OperatorLE {
+				coeff = -coeff
+			}
+			mu[summand.Var().Index()] -= coeff * lambda[index]
+		}
+		index++
+	}
+	for i := range mu {
+		switch bounds[i] {
+		case boundInactive:
+			mu[i] = 0
+		case boundAtUpper:
+			mu[i] = -mu[i]
+		}
+	}
+	return mu
+}
+
+// setDuals sets the duals of the hard constraints from the Lagrange
+// multipliers of the independent active constraints, the duals of the
+// active bounds from their multipliers mu and the duals of the soft
+// constraints from the derivation of their penalty at x.
+func (self *LayoutOptimizer) setDuals(x []float64, activeConstraints *ConstraintList,
+	independentRows []bool, lambda []float64, bounds []int, mu []float64) {
+
+	index := 0
+	for i := 0; i < activeConstraints.Len(); i++ {
+		if !independentRows[i] {
+			continue
+		}
+		constraint := activeConstraints.GetAt(i)
+		// LE constraints are negated in the active matrix
+		dual := lambda[index]
+		if constraint.Op() == OperatorLE {
+			dual = -dual
+		}
+		self.duals[self.constraints.IndexOf(constraint)] = dual
+		index++
+	}
+	// an upper bound is an LE constraint
+	for i := range mu {
+		self.boundDuals[i] = mu[i]
+		if bounds[i] == boundAtUpper {
+			self.boundDuals[i] = -mu[i]
+		}
+	}
+
+	for c := 0; c < self.constraints.Len(); c++ {
+		constraint := self.constraints.GetAt(c)
+		if !constraint.IsSoft() {
+			continue
+		}
+		// penalty 1/2 (weight * (a^Tx - b))^2
+		residual := -constraint.RightSide()
+		summands := constraint.LeftSide()
+		for s := 0; s < summands.Len(); s++ {
+			summand := summands.GetAt(s)
+			residual += summand.Coeff() * x[summand.Var().Index()]
+		}
+		self.duals[c] = -self.weights[c] * self.weights[c] * residual
+	}
+}
+
+// Iterations gets the number of active set iterations of the last solve.
+func (self *LayoutOptimizer) Iterations() int {
+	return self.iterations
+}
+
+// ActiveConstraints gets the size of the active set when the last solve
+// stopped.
+func (self *LayoutOptimizer) ActiveConstraints() int {
+	return self.activeConstraints
+}
+
 func (self *LayoutOptimizer) setResult(x, values []float64) {
 	for i := 0; i < self.variableCount; i++ {
 		values[i] = x[i]
@@ -1,72 +1,192 @@
 package lp
 
+import "context"
 import "fmt"
+import "strconv"
+import "time"
 
 type LinearSpec struct {
-	variables     *VariableList
-	usedVariables *VariableList
-	constraints   *ConstraintList
-	result        int
-	solvingTime   float64
-	solver        *ActiveSetSolver
+	variables      *VariableList
+	usedVariables  *VariableList
+	constraints    *ConstraintList
+	objective      *SummandList
+	optType        int
+	objectiveValue float64
+	result         int
+	stats          SolveStats
+	solver         SolverLike
+	tolerances     Tolerances
+	scaling        int
+	presolve       bool
+	// whether the last solve was presolved
+	presolved bool
+	// whether the objective is quadratic, and the pairs of summands whose
+	// products are the entries of its matrix
+	quadratic                     bool
+	quadraticLeft, quadraticRight *SummandList
 }
 
 func NewLinearSpec() *LinearSpec {
 	ls := &LinearSpec{}
 	ls.result = ResultError
-	ls.solvingTime = 0
 	ls.variables = newVariableList()
 	ls.usedVariables = newVariableList()
 	ls.constraints = newConstraintList()
+	ls.objective = newSummandList()
+	ls.quadraticLeft = newSummandList()
+	ls.quadraticRight = newSummandList()
+	ls.optType = OptMinimize
+	ls.objectiveValue = 0
+	ls.tolerances = DefaultTolerances()
+	ls.scaling = ScaleNone
 
 	ls.solver = NewActiveSetSolver(ls)
 
 	return ls
 }
 
+// SetSolver replaces the solver of the specification. The solver can only
+// be changed as long as the specification has neither variables nor
+// constraints, since solvers may add their own variables and constraints.
+func (self *LinearSpec) SetSolver(solver SolverLike) error {
+	if solver == nil {
+		return ErrNoSolver
+	}
+	if self.variables.Len() > 0 || self.constraints.Len() > 0 {
+		return ErrNotEmpty
+	}
+	if !solver.ObjectiveChanged() {
+		return ErrRejected
+	}
+	self.solver = solver
+	return nil
+}
+
+// Solver gets the solver of the specification.
+func (self *LinearSpec) Solver() SolverLike {
+	return self.solver
+}
+
+// SetTolerances sets the numerical tolerances of the solves. All of them
+// must be positive and finite.
+func (self *LinearSpec) SetTolerances(tolerances Tolerances) error {
+	if !tolerances.valid() {
+		return ErrInvalidTolerances
+	}
+	self.tolerances = tolerances
+	self.settingsChanged()
+	return nil
+}
+
+// Tolerances gets the numerical tolerances of the solves.
+func (self *LinearSpec) Tolerances() Tolerances {
+	return self.tolerances
+}
+
+// SetScaling sets how the solvers scale the constraint matrix, one of
+// ScaleNone, ScaleGeometric and ScaleEquilibrate.
+func (self *LinearSpec) SetScaling(mode int) error {
+	if mode != ScaleNone && mode != ScaleGeometric && mode != ScaleEquilibrate {
+		return ErrInvalidScaling
+	}
+	self.scaling = mode
+	self.settingsChanged()
+	return nil
+}
+
+// Scaling gets the scaling mode of the solvers.
+func (self *LinearSpec) Scaling() int {
+	return self.scaling
+}
+
+// SetPresolve enables or disables the presolve, which removes fixed
+// variables, rows with a single entry, redundant inequalities and variables
+// defined by an equation before a solve. The values of all variables and
+// the duals of all constraints are recovered after the solve. A problem
+// that the presolve solves completely gives ResultPresolve. The sensitivity
+// analysis and the Farkas certificate are not available for a presolved
+// solve.
+func (self *LinearSpec) SetPresolve(presolve bool) {
+	self.presolve = presolve
+	self.settingsChanged()
+}
+
+// settingsChanged tells a solver that keeps state between solves about new
+// settings.
+func (self *LinearSpec) settingsChanged() {
+	if solver, ok := self.solver.(SettingsSolver); ok {
+		solver.SettingsChanged()
+	}
+}
+
+// Presolve gets whether the solvers presolve the specification.
+func (self *LinearSpec) Presolve() bool {
+	return self.presolve
+}
+
 // Adds a new variable to the specification
 // if v == 0 then create new default variable in return it.
 // Otherwise the returned variable is v.
-func (self *LinearSpec) AddVariable(v *Variable) *Variable {
+func (self *LinearSpec) AddVariable(v *Variable) (*Variable, error) {
 	var var1 *Variable = v
 	if var1 == nil {
 		var1 = newVariable(self)
 	}
 
-	if var1.IsValid() {
-		return nil
+	if var1.IsValid() || var1.ls != self {
+		return nil, &VariableError{var1, ErrInvalidVariable}
 	}
 
 	self.variables.AddItem(var1)
 
 	if !self.solver.VariableAdded(var1) {
 		self.variables.RemoveItem(var1)
-		return nil
+		return nil, &VariableError{var1, ErrRejected}
 	}
 	var1.isValid = true
 
-	if !self.UpdateRange(var1) {
+	if err := self.UpdateRange(var1); err != nil {
 		self.RemoveVariable(var1)
-		return nil
+		return nil, err
 	}
 
-	return var1
+	return var1, nil
 }
 
-func (self *LinearSpec) RemoveVariable(v *Variable) bool {
+func (self *LinearSpec) RemoveVariable(v *Variable) error {
+	// do we know the variable?
+	if v == nil || self.variables.IndexOf(v) < 0 {
+		return &VariableError{v, ErrInvalidVariable}
+	}
 
 	// must be called first otherwise the index is invalid
 	if self.solver.VariableRemoved(v) == false {
-		return false
+		return &VariableError{v, ErrRejected}
 	}
 
-	// do we know the variable?
-	if self.variables.RemoveItem(v) == false {
-		return false
-	}
+	self.variables.RemoveItem(v)
 	self.usedVariables.RemoveItem(v)
 	v.isValid = false
 
+	// drop the variable from the objective function
+	for i := 0; i < self.objective.Len(); i++ {
+		if self.objective.GetAt(i).Var() == v {
+			self.releaseSummand(self.objective.GetAt(i))
+			self.objective.RemoveItemAt(i)
+			i--
+		}
+	}
+	for i := 0; i < self.quadraticLeft.Len(); i++ {
+		left, right := self.quadraticLeft.GetAt(i), self.quadraticRight.GetAt(i)
+		if left.Var() == v || right.Var() == v {
+			self.releaseSummand(left)
+			self.releaseSummand(right)
+			self.quadraticLeft.RemoveItemAt(i)
+			self.quadraticRight.RemoveItemAt(i)
+			i--
+		}
+	}
+
 	// Invalidate all constraints that use this variable
 	markedForInvalidation := newConstraintList()
 	constraints := self.Constraints()
@@ -90,7 +210,7 @@ func (self *LinearSpec) RemoveVariable(v *Variable) bool {
 	for i := 0; i < markedForInvalidation.Len(); i++ {
 		self.RemoveConstraint(markedForInvalidation.GetAt(i))
 	}
-	return true
+	return nil
 }
 
 func (self *LinearSpec) IndexOf(v *Variable) int {
@@ -101,14 +221,20 @@ func (self *LinearSpec) GlobalIndexOf(v *Variable) int {
 	return self.variables.IndexOf(v)
 }
 
-func (self *LinearSpec) UpdateRange(v *Variable) bool {
+func (self *LinearSpec) UpdateRange(v *Variable) error {
 	if !self.solver.VariableRangeChanged(v) {
-		return false
+		return &VariableError{v, ErrRejected}
 	}
-	return true
+	return nil
 }
 
-func (self *LinearSpec) AddConstraint(c *Constraint) bool {
+func (self *LinearSpec) AddConstraint(c *Constraint) error {
+	if c == nil || c.ls != self || self.constraints.IndexOf(c) >= 0 {
+		return &ConstraintError{c, ErrInvalidConstraint}
+	}
+	if err := self.checkConstraint(c.LeftSide(), c.Op()); err != nil {
+		return err
+	}
 	self.constraints.AddItem(c)
 
 	leftSide := c.LeftSide()
@@ -122,13 +248,16 @@ func (self *LinearSpec) AddConstraint(c *Constraint) bool {
 
 	if !self.solver.ConstraintAdded(c) {
 		self.RemoveConstraint(c)
-		return false
+		return &ConstraintError{c, ErrRejected}
 	}
 	c.isValid = true
-	return true
+	return nil
 }
 
-func (self *LinearSpec) RemoveConstraint(c *Constraint) bool {
+func (self *LinearSpec) RemoveConstraint(c *Constraint) error {
+	if c == nil || self.constraints.IndexOf(c) < 0 {
+		return &ConstraintError{c, ErrInvalidConstraint}
+	}
 	self.solver.ConstraintRemoved(c)
     self.constraints.RemoveItem(c)
 	c.isValid = false
@@ -141,28 +270,28 @@ func (self *LinearSpec) RemoveConstraint(c *Constraint) bool {
         }
 	}
 
-	return true
+	return nil
 }
 
-func (self *LinearSpec) AddConstraint1(summands *SummandList, opType int, rightSide float64) *Constraint {
+func (self *LinearSpec) AddConstraint1(summands *SummandList, opType int, rightSide float64) (*Constraint, error) {
 	return self.AddConstraint3(summands, opType, rightSide, -1, -1)
 }
 
 func (self *LinearSpec) AddConstraint2(coeffs []float64, vars []*Variable,
-	opType int, rightSide float64) *Constraint {
+	opType int, rightSide float64) (*Constraint, error) {
 	return self.AddConstraint4(coeffs, vars, opType, rightSide, -1, -1)
 }
 
 func (self *LinearSpec) AddConstraint3(summands *SummandList, opType int, rightSide float64,
-	penaltyNeg, penaltyPos float64) *Constraint {
+	penaltyNeg, penaltyPos float64) (*Constraint, error) {
 	return self.addConstraint(summands, opType, rightSide, penaltyNeg, penaltyPos)
 }
 
 func (self *LinearSpec) AddConstraint4(coeffs []float64, vars []*Variable,
 	opType int, rightSide float64,
-	penaltyNeg, penaltyPos float64) *Constraint {
+	penaltyNeg, penaltyPos float64) (*Constraint, error) {
 	if len(coeffs) != len(vars) {
-		return nil
+		return nil, ErrLengthMismatch
 	}
 	summands := newSummandList()
 
@@ -174,23 +303,249 @@ func (self *LinearSpec) AddConstraint4(coeffs []float64, vars []*Variable,
 	return self.addConstraint(summands, opType, rightSide, penaltyNeg, penaltyPos)
 }
 
-func (self *LinearSpec) MinSize(width, height *Variable) Size {
+func (self *LinearSpec) MinSize(width, height *Variable) (Size, error) {
 	return self.solver.MinSize(width, height)
 }
 
-func (self *LinearSpec) MaxSize(width, height *Variable) Size {
+func (self *LinearSpec) MaxSize(width, height *Variable) (Size, error) {
 	return self.solver.MaxSize(width, height)
 }
 
-func (self *LinearSpec) Solve() int {
-	// TODO: Measure solve time
-	self.result = self.solver.Solve()
-	return self.result
+// SetObjective sets the linear objective function and the optimization
+// direction (OptMinimize or OptMaximize). An empty or nil summand list
+// removes the objective; a quadratic objective is replaced. Returns an
+// error if the direction is unknown, a summand refers to a variable that
+// is not part of this specification or the solver does not support a
+// linear objective; the previous objective is kept in that case.
+func (self *LinearSpec) SetObjective(summands *SummandList, direction int) error {
+	if direction != OptMinimize && direction != OptMaximize {
+		return ErrInvalidDirection
+	}
+	if summands == nil {
+		summands = newSummandList()
+	}
+	if err := self.checkSummands(summands); err != nil {
+		return err
+	}
+
+	old := self.saveObjective()
+	self.releaseObjective()
+	self.objective = mergeSummands(summands)
+	self.optType = direction
+	self.quadratic = false
+	self.quadraticLeft = newSummandList()
+	self.quadraticRight = newSummandList()
+
+	if !self.solver.ObjectiveChanged() {
+		self.restoreObjective(old)
+		return ErrRejected
+	}
+	return nil
+}
+
+// mergeSummands returns a copy of summands with one summand per variable.
+func mergeSummands(summands *SummandList) *SummandList {
+	merged := newSummandList()
+	for i := 0; i < summands.Len(); i++ {
+		s := summands.GetAt(i)
+
+		found := false
+		for j := 0; j < merged.Len(); j++ {
+			if merged.GetAt(j).Var() == s.Var() {
+				merged.GetAt(j).SetCoeff(merged.GetAt(j).Coeff() + s.Coeff())
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.AddItem(NewSummand(s.Coeff(), s.Var()))
+		}
+	}
+	return merged
+}
+
+// SetObjective1 sets the objective function sum(coeffs[i] * vars[i]).
+func (self *LinearSpec) SetObjective1(coeffs []float64, vars []*Variable, direction int) error {
+	if len(coeffs) != len(vars) {
+		return ErrLengthMismatch
+	}
+	summands := newSummandList()
+	for i, c := range coeffs {
+		summands.AddItem(NewSummand(c, vars[i]))
+	}
+	return self.SetObjective(summands, direction)
 }
 
-// Writes the specification into a text file.
+// Objective gets the summands of the linear objective function.
+func (self *LinearSpec) Objective() *SummandList {
+	return self.objective
+}
+
+// SetQuadraticObjective sets the objective function 1/2 x^TQx + c^Tx to
+// minimize. Each pair of summands left[k] and right[k] adds the product of
+// their coefficients to the entry of Q in the row of the variable of
+// left[k] and the column of the variable of right[k]; linear gives c. Only
+// the symmetric part of Q counts, which must be positive semidefinite. The
+// variables of the objective are used variables even if no constraint
+// refers to them.
+//
+// Returns ErrUnsupported if the solver does not implement QuadraticSolver,
+// ErrLengthMismatch if left and right differ in length, ErrNotConvex if Q
+// is not positive semidefinite and an error if a summand refers to a
+// variable that is not part of this specification; the previous objective
+// is kept in these cases. SetObjective replaces the quadratic objective.
+func (self *LinearSpec) SetQuadraticObjective(left, right, linear *SummandList) error {
+	if left == nil {
+		left = newSummandList()
+	}
+	if right == nil {
+		right = newSummandList()
+	}
+	if linear == nil {
+		linear = newSummandList()
+	}
+	if left.Len() != right.Len() {
+		return ErrLengthMismatch
+	}
+	for _, summands := range []*SummandList{left, right, linear} {
+		if err := self.checkSummands(summands); err != nil {
+			return err
+		}
+	}
+	solver, ok := self.solver.(QuadraticSolver)
+	if !ok {
+		return ErrUnsupported
+	}
+	if q, _ := quadraticMatrix(left, right); !semidefinite(q, self.tolerances.Zero) {
+		return ErrNotConvex
+	}
+
+	old := self.saveObjective()
+	self.releaseObjective()
+	self.objective = mergeSummands(linear)
+	self.optType = OptMinimize
+	self.quadratic = true
+	self.quadraticLeft = newSummandList()
+	self.quadraticRight = newSummandList()
+	for i := 0; i < left.Len(); i++ {
+		l, r := left.GetAt(i), right.GetAt(i)
+		self.quadraticLeft.AddItem(NewSummand(l.Coeff(), l.Var()))
+		self.quadraticRight.AddItem(NewSummand(r.Coeff(), r.Var()))
+	}
+	self.referenceObjective()
+
+	if !solver.QuadraticObjectiveChanged() {
+		self.restoreObjective(old)
+		return ErrRejected
+	}
+	return nil
+}
+
+// SetQuadraticObjective1 sets the objective function 1/2 x^TQx + c^Tx to
+// minimize for x = vars, Q = q and c = linear, which may be nil.
+func (self *LinearSpec) SetQuadraticObjective1(q [][]float64, vars []*Variable,
+	linear []float64) error {
+	if len(q) != len(vars) || (linear != nil && len(linear) != len(vars)) {
+		return ErrLengthMismatch
+	}
+	left := newSummandList()
+	right := newSummandList()
+	summands := newSummandList()
+	for i, row := range q {
+		if len(row) != len(vars) {
+			return ErrLengthMismatch
+		}
+		for j, coeff := range row {
+			if coeff != 0 {
+				left.AddItem(NewSummand(coeff, vars[i]))
+				right.AddItem(NewSummand(1, vars[j]))
+			}
+		}
+		if linear != nil {
+			summands.AddItem(NewSummand(linear[i], vars[i]))
+		}
+	}
+	return self.SetQuadraticObjective(left, right, summands)
+}
+
+// QuadraticObjective gets the pairs of summands of the matrix of a
+// quadratic objective function; Objective gets its linear part.
+func (self *LinearSpec) QuadraticObjective() (left, right *SummandList) {
+	return self.quadraticLeft, self.quadraticRight
+}
+
+// HasQuadraticObjective returns true if the objective function was set by
+// SetQuadraticObjective.
+func (self *LinearSpec) HasQuadraticObjective() bool {
+	return self.quadratic
+}
+
+// OptimizationType gets the optimization direction of the objective
+// function, either OptMinimize or OptMaximize.
+func (self *LinearSpec) OptimizationType() int {
+	return self.optType
+}
+
+// HasObjective returns true if a linear objective function is set.
+func (self *LinearSpec) HasObjective() bool {
+	return self.objective.Len() > 0
+}
+
+// ObjectiveValue gets the value of the objective function for the variable
+// values of the last solve.
+func (self *LinearSpec) ObjectiveValue() float64 {
+	return self.objectiveValue
+}
+
+// Solve solves the specification and returns the result type. The error
+// is nil for the result types that come with a solution; for an infeasible
+// specification it is a *ConstraintError naming a violated constraint if
+// the solver knows one.
+func (self *LinearSpec) Solve() (int, error) {
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves the specification like Solve but stops when ctx is
+// done, with ResultTimeout if its deadline passed and ResultUserAbort if
+// it was canceled. The variables keep the best point found so far. Solvers
+// that do not implement ContextSolver are only interrupted before they
+// start.
+func (self *LinearSpec) SolveContext(ctx context.Context) (int, error) {
+	start := time.Now()
+	self.presolved = self.presolve
+	if result, ok := interrupted(ctx); ok {
+		self.result = result
+	} else if solver, ok := self.solver.(ContextSolver); ok {
+		self.result = solver.SolveContext(ctx)
+	} else {
+		self.result = self.solver.Solve()
+	}
+
+	self.stats = SolveStats{}
+	if reporter, ok := self.solver.(StatsReporter); ok {
+		self.stats = reporter.Stats()
+	}
+	self.stats.Time = time.Since(start)
+	self.objectiveValue = self.evalObjective()
+	return self.result, solveError(self.solver, self.result)
+}
+
+func (self *LinearSpec) evalObjective() float64 {
+	value := 0.0
+	for i := 0; i < self.objective.Len(); i++ {
+		s := self.objective.GetAt(i)
+		value += s.Coeff() * s.Var().Value()
+	}
+	for i := 0; i < self.quadraticLeft.Len(); i++ {
+		left, right := self.quadraticLeft.GetAt(i), self.quadraticRight.GetAt(i)
+		value += left.Coeff() * right.Coeff() * left.Var().Value() * right.Var().Value() / 2
+	}
+	return value
+}
+
+// Writes the specification into a text file in the LP format of lp_solve.
 // The file will be overwritten if it exists.
-func (self *LinearSpec) Save(filename string) bool {
+func (self *LinearSpec) Save(filename string) error {
 	return self.solver.SaveModel(filename)
 }
 
@@ -202,9 +557,76 @@ func (self *LinearSpec) Result() int {
 	return self.result
 }
 
-// SolvingTime gets the solving time.
+// SolvingTime gets the wall time of the last solve in seconds.
 func (self *LinearSpec) SolvingTime() float64 {
-	return self.solvingTime
+	return self.stats.Time.Seconds()
+}
+
+// Stats gets the statistics of the last solve.
+func (self *LinearSpec) Stats() SolveStats {
+	return self.stats
+}
+
+// FarkasCertificate gets the proof of infeasibility of the last solve.
+// Only the ActiveSetSolver supports it.
+func (self *LinearSpec) FarkasCertificate() (*FarkasCertificate, error) {
+	if self.presolved {
+		return nil, ErrPresolved
+	}
+	reporter, ok := self.solver.(FarkasReporter)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	farkas := reporter.FarkasCertificate()
+	if farkas == nil {
+		return nil, ErrNoCertificate
+	}
+	return farkas, nil
+}
+
+// UnboundedRay gets the direction along which the objective of the last
+// solve improves without limit. Only the simplex based solvers support it.
+func (self *LinearSpec) UnboundedRay() (*UnboundedRay, error) {
+	reporter, ok := self.solver.(RayReporter)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	ray := reporter.UnboundedRay()
+	if ray == nil {
+		return nil, ErrNoRay
+	}
+	return ray, nil
+}
+
+// Sensitivity gets the sensitivity analysis of the last solve, which must
+// have been optimal. Only the simplex based solvers support it.
+func (self *LinearSpec) Sensitivity() (*Sensitivity, error) {
+	if self.presolved {
+		return nil, ErrPresolved
+	}
+	reporter, ok := self.solver.(SensitivityReporter)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	sensitivity := reporter.Sensitivity()
+	if sensitivity == nil {
+		return nil, ErrNotOptimal
+	}
+	return sensitivity, nil
+}
+
+// Residuals gets the violations of the hard constraints and the bounds by
+// the values of the last solve. Only the ActiveSetSolver supports it.
+func (self *LinearSpec) Residuals() (*Residuals, error) {
+	reporter, ok := self.solver.(ResidualReporter)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	residuals := reporter.Residuals()
+	if residuals == nil {
+		return nil, ErrNoResiduals
+	}
+	return residuals, nil
 }
 
 //func (self *LinearSpec) String() string {
@@ -223,53 +645,63 @@ func (self *LinearSpec) AllVariables() *VariableList {
 	return self.variables
 }
 
-func (self *LinearSpec) updateLeftSide(c *Constraint) bool {
+func (self *LinearSpec) updateLeftSide(c *Constraint) error {
 	if !self.solver.LeftSideChanged(c) {
-		return false
+		return &ConstraintError{c, ErrRejected}
 	}
-	return true
+	return nil
 }
 
-func (self *LinearSpec) updateRightSide(c *Constraint) bool {
+func (self *LinearSpec) updateRightSide(c *Constraint) error {
 	if !self.solver.RightSideChanged(c) {
-		return false
+		return &ConstraintError{c, ErrRejected}
 	}
-	return true
+	return nil
 }
 
-func (self *LinearSpec) updateOperator(c *Constraint) bool {
+func (self *LinearSpec) updateOperator(c *Constraint) error {
 	if !self.solver.OperatorChanged(c) {
-		return false
+		return &ConstraintError{c, ErrRejected}
 	}
-	return true
+	return nil
 }
 
-func (self *LinearSpec) checkSummandList(list *SummandList) bool {
-	ok := true
+// checkSummands checks that all summands refer to valid variables of this
+// specification.
+func (self *LinearSpec) checkSummands(list *SummandList) error {
 	for i := 0; i < list.Len(); i++ {
 		s := list.GetAt(i)
-		if s == nil {
-			ok = false
-			break
+		if s == nil || s.Var() == nil {
+			return &VariableError{nil, ErrInvalidVariable}
+		}
+		if !s.Var().IsValid() || s.Var().LS() != self {
+			return &VariableError{s.Var(), ErrInvalidVariable}
 		}
 	}
+	return nil
+}
 
-	if ok {
-		return true
+func (self *LinearSpec) checkConstraint(leftSide *SummandList, opType int) error {
+	if opType != OperatorLE && opType != OperatorGE && opType != OperatorEQ {
+		return ErrInvalidOperator
 	}
-
-	list.Clear()
-	return false
+	return self.checkSummands(leftSide)
 }
 
 func (self *LinearSpec) addConstraint(leftSide *SummandList, opType int,
-	rightSide, penaltyNeg, penaltyPos float64) *Constraint {
+	rightSide, penaltyNeg, penaltyPos float64) (*Constraint, error) {
 
+	if leftSide == nil {
+		leftSide = newSummandList()
+	}
+	if err := self.checkConstraint(leftSide, opType); err != nil {
+		return nil, err
+	}
 	c := newConstraint(self, leftSide, opType, rightSide, penaltyNeg, penaltyPos)
-	if !self.AddConstraint(c) {
-		return nil
+	if err := self.AddConstraint(c); err != nil {
+		return nil, err
 	}
-	return c
+	return c, nil
 }
 
 func (self *LinearSpec) String() string {
@@ -301,7 +733,7 @@ func (self *LinearSpec) String() string {
 	case ResultNumFailure:
 		s = s + "NumFailure"
 	default:
-		s = s + string(self.Result())
+		s = s + strconv.Itoa(self.Result())
 	}
 
 	return s
@@ -6,14 +6,14 @@ import "fmt"
 func tstLinearSpec(t *testing.T) {
 	fmt.Println("Test linear spec")
 	ls := NewLinearSpec()
-	x1 := ls.AddVariable(nil)
-	x2 := ls.AddVariable(nil)
+	x1, _ := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
 
 	ls.AddConstraint2([]float64{1.0}, []*Variable{x1}, OperatorLE, 108)
-	c2 := ls.AddConstraint2([]float64{1.0}, []*Variable{x2}, OperatorGE, 113)
+	c2, _ := ls.AddConstraint2([]float64{1.0}, []*Variable{x2}, OperatorGE, 113)
 
-	fmt.Println("Num of Variables: ", ls.solver.variables.Len())
-	fmt.Println("Num of Constraints: ", ls.solver.constraints.Len())
+	fmt.Println("Num of Variables: ", ls.UsedVariables().Len())
+	fmt.Println("Num of Constraints: ", ls.Constraints().Len())
 
 	ls.Solve()
 	fmt.Println(ls.String())
@@ -22,7 +22,7 @@ func tstLinearSpec(t *testing.T) {
 	ls.Solve()
 	fmt.Println(ls.String())
 
-	c2 = ls.AddConstraint2([]float64{1.0}, []*Variable{x2}, OperatorGE, 113)
+	c2, _ = ls.AddConstraint2([]float64{1.0}, []*Variable{x2}, OperatorGE, 113)
 	ls.Solve()
 	fmt.Println(ls.String())
 }
@@ -38,13 +38,13 @@ func TestSoftConstraints(t *testing.T) {
 
 	ls := NewLinearSpec()
 
-	x1 := ls.AddVariable(nil)
+	x1, _ := ls.AddVariable(nil)
 	x1.SetLabel("label_x1")
 
-	x2 := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
 	x2.SetLabel("label_x2")
 
-	x3 := ls.AddVariable(nil)
+	x3, _ := ls.AddVariable(nil)
 	x3.SetLabel("label_x3")
 
 	ls.AddConstraint2([]float64{1.0}, []*Variable{x1}, OperatorEQ, 0)
@@ -53,16 +53,46 @@ func TestSoftConstraints(t *testing.T) {
 	ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{x3, x1}, OperatorEQ, 20)
 
 	ls.AddConstraint4([]float64{1.0, -1.0}, []*Variable{x2, x1}, OperatorEQ, 10, 5, 5)
-	c6 := ls.AddConstraint4([]float64{1.0, -1.0}, []*Variable{x3, x2}, OperatorEQ, 5, 5, 5)
+	c6, _ := ls.AddConstraint4([]float64{1.0, -1.0}, []*Variable{x3, x2}, OperatorEQ, 5, 5, 5)
 
     printResults(ls.UsedVariables())
 
-	ls.Solve()
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Errorf("result = %v, want %v", result, ResultOptimal)
+	}
 	fmt.Println("ls: ", ls.String())
 	printResults(ls.AllVariables())
 
 	ls.RemoveConstraint(c6)
-	ls.Solve()
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Errorf("result without c6 = %v, want %v", result, ResultOptimal)
+	}
 	fmt.Println("ls: ", ls.String())
 	printResults(ls.UsedVariables())
 }
+
+func TestObjective(t *testing.T) {
+	fmt.Println("Test Objective")
+
+	ls := NewLinearSpec()
+	x1, _ := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
+
+	if err := ls.SetObjective1([]float64{1.0, 2.0}, []*Variable{x1, x2}, 3); err != ErrInvalidDirection {
+		t.Errorf("objective with unknown direction: %v", err)
+	}
+	if err := ls.SetObjective1([]float64{1.0}, []*Variable{x1, x2}, OptMinimize); err != ErrLengthMismatch {
+		t.Errorf("objective with mismatching lengths: %v", err)
+	}
+
+	// the active set solver only minimizes soft constraint penalties
+	if err := ls.SetObjective1([]float64{1.0, 2.0}, []*Variable{x1, x2}, OptMaximize); err != ErrRejected {
+		t.Errorf("ActiveSetSolver and a linear objective: %v", err)
+	}
+	if ls.HasObjective() || ls.OptimizationType() != OptMinimize {
+		t.Error("refused objective was not rolled back")
+	}
+	if err := ls.SetObjective(nil, OptMinimize); err != nil {
+		t.Error("clearing the objective failed")
+	}
+}
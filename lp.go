@@ -1,5 +1,7 @@
 package lp
 
+import "context"
+
 //import "github.com/norisatir/go-lp/lpsolve"
 //import "math"
 
@@ -47,10 +49,38 @@ type SolverLike interface {
 	LeftSideChanged(*Constraint) bool
 	RightSideChanged(*Constraint) bool
 	OperatorChanged(*Constraint) bool
-	SaveModel(filename string) bool
+	ObjectiveChanged() bool
+	SaveModel(filename string) error
+
+	MinSize(width, height *Variable) (Size, error)
+	MaxSize(width, height *Variable) (Size, error)
+}
+
+// ContextSolver is implemented by solvers that can be interrupted: Solve
+// stops when ctx is done and returns ResultTimeout if its deadline passed
+// and ResultUserAbort if it was canceled. The variables keep the best
+// point found so far.
+type ContextSolver interface {
+	SolveContext(ctx context.Context) int
+}
+
+// SettingsSolver is implemented by solvers that keep state from one solve
+// to the next, which changes of the tolerances, the scaling or the presolve
+// of the specification invalidate.
+type SettingsSolver interface {
+	SettingsChanged() bool
+}
 
-	MinSize(width, height *Variable) Size
-	MaxSize(width, height *Variable) Size
+// interrupted returns ResultTimeout or ResultUserAbort and true if ctx is
+// done.
+func interrupted(ctx context.Context) (int, bool) {
+	switch ctx.Err() {
+	case nil:
+		return ResultOptimal, false
+	case context.DeadlineExceeded:
+		return ResultTimeout, true
+	}
+	return ResultUserAbort, true
 }
 
 func max(a, b int) int {
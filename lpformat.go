@@ -0,0 +1,818 @@
+package lp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// lp_solve writes and reads infinite bounds as 1e30
+const lpInfinity = 1e30
+
+const (
+	lpTokenIdentifier = iota
+	lpTokenNumber
+	lpTokenOperator
+	lpTokenSign
+	lpTokenColon
+	lpTokenSemicolon
+	lpTokenStar
+	lpTokenPenalty
+)
+
+type lpToken struct {
+	kind  int
+	text  string
+	value float64
+	line  int
+}
+
+// WriteLP writes the specification in the LP format of lp_solve. Soft
+// constraints are written as hard constraints followed by a
+// "/* penalties: neg pos */" comment, which ReadLP understands. The slack
+// variables and rows that the solver adds to soft inequalities are left
+// out. The format has no quadratic objective; ErrQuadratic is returned for
+// one.
+func (self *LinearSpec) WriteLP(w io.Writer) error {
+	if self.quadratic {
+		return ErrQuadratic
+	}
+	out := bufio.NewWriter(w)
+	variables, constraints, slacks := self.writtenModel()
+	variableNames := lpVariableNames(variables)
+
+	fmt.Fprintf(out, "/* Objective function */\n")
+	if self.OptimizationType() == OptMaximize {
+		fmt.Fprintf(out, "max:")
+	} else {
+		fmt.Fprintf(out, "min:")
+	}
+	// variables without objective coefficient are listed with 0 to keep
+	// the order of the columns
+	coeffs := make(map[*Variable]float64)
+	for i := 0; i < self.objective.Len(); i++ {
+		s := self.objective.GetAt(i)
+		coeffs[s.Var()] += s.Coeff()
+	}
+	for i := 0; i < variables.Len(); i++ {
+		v := variables.GetAt(i)
+		coeff := coeffs[v]
+		if coeff == 0 {
+			fmt.Fprintf(out, " +0 %v", variableNames[v])
+		} else {
+			fmt.Fprintf(out, " %v", lpTerm(coeff, variableNames[v]))
+		}
+	}
+	fmt.Fprintf(out, ";\n")
+
+	if constraints.Len() > 0 {
+		fmt.Fprintf(out, "\n/* Constraints */\n")
+	}
+	usedNames := make(map[string]bool)
+	for i := 0; i < constraints.Len(); i++ {
+		c := constraints.GetAt(i)
+		leftSide := writtenLeftSide(c, slacks)
+
+		// a constraint with a single variable needs a name, otherwise it
+		// is read as a bound
+		name := c.Label()
+		if !isLPIdentifier(name) || usedNames[name] {
+			name = ""
+			if leftSide.Len() == 1 {
+				name = "R" + strconv.Itoa(i+1)
+			}
+		}
+		if name != "" {
+			usedNames[name] = true
+			fmt.Fprintf(out, "%v: ", name)
+		}
+
+		for j := 0; j < leftSide.Len(); j++ {
+			s := leftSide.GetAt(j)
+			if j > 0 {
+				fmt.Fprintf(out, " ")
+			}
+			fmt.Fprintf(out, "%v", lpTerm(s.Coeff(), variableNames[s.Var()]))
+		}
+		if leftSide.Len() == 0 {
+			fmt.Fprintf(out, "0")
+		}
+
+		switch c.Op() {
+		case OperatorLE:
+			fmt.Fprintf(out, " <= ")
+		case OperatorGE:
+			fmt.Fprintf(out, " >= ")
+		default:
+			fmt.Fprintf(out, " = ")
+		}
+		fmt.Fprintf(out, "%v;", lpNumber(c.RightSide()))
+		if c.PenaltyNeg() > 0 || c.PenaltyPos() > 0 {
+			fmt.Fprintf(out, " /* penalties: %v %v */", lpNumber(c.PenaltyNeg()),
+				lpNumber(c.PenaltyPos()))
+		}
+		fmt.Fprintf(out, "\n")
+	}
+
+	// LP files default to the range [0, +inf)
+	boundsHeader := false
+	integers := []string{}
+	for i := 0; i < variables.Len(); i++ {
+		v := variables.GetAt(i)
+		name := variableNames[v]
+		if v.IsInteger() {
+			integers = append(integers, name)
+		}
+		if v.Min() == 0 && math.IsInf(v.Max(), 1) {
+			continue
+		}
+		if !boundsHeader {
+			fmt.Fprintf(out, "\n/* Bounds */\n")
+			boundsHeader = true
+		}
+		if v.Min() == v.Max() {
+			fmt.Fprintf(out, "%v = %v;\n", name, lpNumber(v.Min()))
+			continue
+		}
+		if v.Min() != 0 {
+			fmt.Fprintf(out, "%v >= %v;\n", name, lpNumber(v.Min()))
+		}
+		if !math.IsInf(v.Max(), 1) {
+			fmt.Fprintf(out, "%v <= %v;\n", name, lpNumber(v.Max()))
+		}
+	}
+
+	if len(integers) > 0 {
+		fmt.Fprintf(out, "\nint %v;\n", strings.Join(integers, ","))
+	}
+	return out.Flush()
+}
+
+// writtenModel returns the variables and constraints that WriteLP and
+// WriteMPS write: those of the specification without the slack variables
+// and the penalized rows that the solver added for soft inequalities. The
+// slacks are returned as well, to leave them out of the left sides.
+func (self *LinearSpec) writtenModel() (*VariableList, *ConstraintList, map[*Variable]bool) {
+	slacks := make(map[*Variable]bool)
+	slackRows := make(map[*Constraint]bool)
+	if solver, ok := self.solver.(interface {
+		softInEqSlacks(map[*Variable]bool, map[*Constraint]bool)
+	}); ok {
+		solver.softInEqSlacks(slacks, slackRows)
+	}
+	variables := newVariableList()
+	for i := 0; i < self.variables.Len(); i++ {
+		if v := self.variables.GetAt(i); !slacks[v] {
+			variables.AddItem(v)
+		}
+	}
+	constraints := newConstraintList()
+	for i := 0; i < self.constraints.Len(); i++ {
+		if c := self.constraints.GetAt(i); !slackRows[c] {
+			constraints.AddItem(c)
+		}
+	}
+	return variables, constraints, slacks
+}
+
+// writtenLeftSide returns the left side of the constraint without the
+// slacks.
+func writtenLeftSide(c *Constraint, slacks map[*Variable]bool) *SummandList {
+	leftSide := c.LeftSide()
+	if len(slacks) == 0 {
+		return leftSide
+	}
+	written := newSummandList()
+	for i := 0; i < leftSide.Len(); i++ {
+		if s := leftSide.GetAt(i); !slacks[s.Var()] {
+			written.AddItem(s)
+		}
+	}
+	return written
+}
+
+// lpVariableNames returns a unique LP name for every variable: its label
+// if that is a valid identifier, otherwise C<n> with n the 1-based index
+// in the list.
+func lpVariableNames(variables *VariableList) map[*Variable]string {
+	names := make(map[*Variable]string)
+	used := make(map[string]bool)
+	for i := 0; i < variables.Len(); i++ {
+		v := variables.GetAt(i)
+		if isLPIdentifier(v.Label()) && !used[v.Label()] {
+			names[v] = v.Label()
+			used[v.Label()] = true
+		}
+	}
+	for i := 0; i < variables.Len(); i++ {
+		v := variables.GetAt(i)
+		if _, ok := names[v]; ok {
+			continue
+		}
+		name := "C" + strconv.Itoa(i+1)
+		for n := 2; used[name]; n++ {
+			name = "C" + strconv.Itoa(i+1) + "_" + strconv.Itoa(n)
+		}
+		names[v] = name
+		used[name] = true
+	}
+	return names
+}
+
+func lpTerm(coeff float64, name string) string {
+	switch coeff {
+	case 1:
+		return "+" + name
+	case -1:
+		return "-" + name
+	}
+	if coeff >= 0 {
+		return "+" + lpNumber(coeff) + " " + name
+	}
+	return lpNumber(coeff) + " " + name
+}
+
+func lpNumber(value float64) string {
+	if math.IsInf(value, 1) {
+		return "1e30"
+	}
+	if math.IsInf(value, -1) {
+		return "-1e30"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func isLPIdentifierStart(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+		strings.IndexByte("_[]{}/.&#$%~'@^", c) >= 0
+}
+
+func isLPIdentifierChar(c byte) bool {
+	return isLPIdentifierStart(c) || c >= '0' && c <= '9'
+}
+
+func isLPIdentifier(name string) bool {
+	if name == "" || !isLPIdentifierStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isLPIdentifierChar(name[i]) {
+			return false
+		}
+	}
+	// a leading '.' followed by a digit is a number
+	if name[0] == '.' && len(name) > 1 && name[1] >= '0' && name[1] <= '9' {
+		return false
+	}
+	return !isLPKeyword(name)
+}
+
+func isLPKeyword(name string) bool {
+	switch strings.ToLower(name) {
+	case "int", "bin", "free", "sec", "sin", "sos", "sos1", "sos2",
+		"max", "min", "maximize", "maximise", "minimize", "minimise":
+		return true
+	}
+	return false
+}
+
+// saveLP writes ls in LP format to the named file.
+func saveLP(ls *LinearSpec, fileName string) error {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	if err := ls.WriteLP(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func tokenizeLP(input string) ([]lpToken, error) {
+	tokens := []lpToken{}
+	line := 1
+	for i := 0; i < len(input); {
+		c := input[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(input[i:], "//"):
+			for i < len(input) && input[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(input[i:], "/*"):
+			end := strings.Index(input[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %v: unterminated comment", line)
+			}
+			comment := input[i+2 : i+2+end]
+			if token, ok := parsePenaltyComment(comment, line); ok {
+				tokens = append(tokens, token)
+			}
+			line += strings.Count(comment, "\n")
+			i += end + 4
+		case c >= '0' && c <= '9' ||
+			c == '.' && i+1 < len(input) && input[i+1] >= '0' && input[i+1] <= '9':
+			start := i
+			for i < len(input) && (input[i] >= '0' && input[i] <= '9' || input[i] == '.') {
+				i++
+			}
+			// an exponent needs digits, otherwise 'e' starts an identifier
+			if i < len(input) && (input[i] == 'e' || input[i] == 'E') {
+				j := i + 1
+				if j < len(input) && (input[j] == '+' || input[j] == '-') {
+					j++
+				}
+				if j < len(input) && input[j] >= '0' && input[j] <= '9' {
+					for j < len(input) && input[j] >= '0' && input[j] <= '9' {
+						j++
+					}
+					i = j
+				}
+			}
+			value, err := strconv.ParseFloat(input[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: invalid number %q", line, input[start:i])
+			}
+			tokens = append(tokens, lpToken{lpTokenNumber, input[start:i], value, line})
+		case isLPIdentifierStart(c):
+			start := i
+			for i < len(input) && isLPIdentifierChar(input[i]) {
+				i++
+			}
+			tokens = append(tokens, lpToken{lpTokenIdentifier, input[start:i], 0, line})
+		case c == '<' || c == '>' || c == '=':
+			start := i
+			i++
+			if i < len(input) && (input[i] == '=' || input[i] == '<' || input[i] == '>') &&
+				input[i] != c {
+				i++
+			}
+			text := input[start:i]
+			switch text {
+			case "<", "<=", "=<":
+				text = "<="
+			case ">", ">=", "=>":
+				text = ">="
+			case "=":
+			default:
+				return nil, fmt.Errorf("line %v: invalid operator %q", line, text)
+			}
+			tokens = append(tokens, lpToken{lpTokenOperator, text, 0, line})
+		case c == '+' || c == '-':
+			tokens = append(tokens, lpToken{lpTokenSign, string(c), 0, line})
+			i++
+		case c == ':':
+			tokens = append(tokens, lpToken{lpTokenColon, ":", 0, line})
+			i++
+		case c == ';':
+			tokens = append(tokens, lpToken{lpTokenSemicolon, ";", 0, line})
+			i++
+		case c == '*':
+			tokens = append(tokens, lpToken{lpTokenStar, "*", 0, line})
+			i++
+		case c == ',':
+			// only used in declarations, where it separates names
+			i++
+		default:
+			return nil, fmt.Errorf("line %v: unexpected character %q", line, c)
+		}
+	}
+	return tokens, nil
+}
+
+// parsePenaltyComment recognizes the "penalties: neg pos" comment written
+// after soft constraints.
+func parsePenaltyComment(comment string, line int) (lpToken, bool) {
+	fields := strings.Fields(comment)
+	if len(fields) != 3 || fields[0] != "penalties:" {
+		return lpToken{}, false
+	}
+	text := fields[1] + " " + fields[2]
+	return lpToken{lpTokenPenalty, text, 0, line}, true
+}
+
+// lpExpression is a linear expression sum(coeffs[i] * names[i]) + constant.
+type lpExpression struct {
+	names    []string
+	coeffs   []float64
+	constant float64
+}
+
+func (self *lpExpression) add(name string, coeff float64) {
+	for i, n := range self.names {
+		if n == name {
+			self.coeffs[i] += coeff
+			return
+		}
+	}
+	self.names = append(self.names, name)
+	self.coeffs = append(self.coeffs, coeff)
+}
+
+type lpRow struct {
+	label                  string
+	expression             *lpExpression
+	op                     int
+	rightSide              float64
+	penaltyNeg, penaltyPos float64
+}
+
+type lpParser struct {
+	tokens []lpToken
+	pos    int
+
+	names      []string
+	lower      map[string]float64
+	upper      map[string]float64
+	integers   map[string]bool
+	objective  *lpExpression
+	optType    int
+	rows       []*lpRow
+	statements int
+}
+
+func (self *lpParser) declare(name string) {
+	if _, ok := self.lower[name]; ok {
+		return
+	}
+	self.names = append(self.names, name)
+	self.lower[name] = 0
+	self.upper[name] = math.Inf(1)
+}
+
+func (self *lpParser) peek() *lpToken {
+	if self.pos >= len(self.tokens) {
+		return nil
+	}
+	return &self.tokens[self.pos]
+}
+
+func (self *lpParser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if self.pos < len(self.tokens) {
+		line = self.tokens[self.pos].line
+	} else if len(self.tokens) > 0 {
+		line = self.tokens[len(self.tokens)-1].line
+	}
+	return fmt.Errorf("line %v: %v", line, fmt.Sprintf(format, args...))
+}
+
+// expression parses terms up to the next operator or semicolon.
+func (self *lpParser) expression() (*lpExpression, error) {
+	expression := &lpExpression{}
+	terms := 0
+	for {
+		token := self.peek()
+		if token == nil {
+			return nil, self.errorf("missing ';'")
+		}
+		if token.kind == lpTokenOperator || token.kind == lpTokenSemicolon {
+			break
+		}
+
+		sign := 1.0
+		for token != nil && token.kind == lpTokenSign {
+			if token.text == "-" {
+				sign = -sign
+			}
+			self.pos++
+			token = self.peek()
+		}
+		if token == nil {
+			return nil, self.errorf("missing ';'")
+		}
+
+		coeff := sign
+		hasNumber := false
+		if token.kind == lpTokenNumber {
+			coeff *= token.value
+			hasNumber = true
+			self.pos++
+			token = self.peek()
+			if token != nil && token.kind == lpTokenStar {
+				self.pos++
+				token = self.peek()
+			}
+		}
+		if token != nil && token.kind == lpTokenIdentifier {
+			self.declare(token.text)
+			expression.add(token.text, coeff)
+			self.pos++
+		} else if hasNumber {
+			expression.constant += coeff
+		} else {
+			return nil, self.errorf("unexpected %q", tokenText(token))
+		}
+		terms++
+	}
+	if terms == 0 {
+		return nil, self.errorf("empty expression")
+	}
+	return expression, nil
+}
+
+func tokenText(token *lpToken) string {
+	if token == nil {
+		return "end of file"
+	}
+	return token.text
+}
+
+func lpOperator(text string) int {
+	switch text {
+	case "<=":
+		return OperatorLE
+	case ">=":
+		return OperatorGE
+	}
+	return OperatorEQ
+}
+
+func flipOperator(op int) int {
+	switch op {
+	case OperatorLE:
+		return OperatorGE
+	case OperatorGE:
+		return OperatorLE
+	}
+	return op
+}
+
+func (self *lpParser) statement() error {
+	first := self.peek()
+	self.statements++
+
+	// objective function
+	if first.kind == lpTokenIdentifier && self.pos+1 < len(self.tokens) &&
+		self.tokens[self.pos+1].kind == lpTokenColon {
+		switch strings.ToLower(first.text) {
+		case "max", "maximize", "maximise", "min", "minimize", "minimise":
+			if self.statements != 1 {
+				return self.errorf("objective function must be the first statement")
+			}
+			self.optType = OptMinimize
+			if strings.HasPrefix(strings.ToLower(first.text), "max") {
+				self.optType = OptMaximize
+			}
+			self.pos += 2
+			if token := self.peek(); token != nil && token.kind == lpTokenSemicolon {
+				self.pos++
+				return nil
+			}
+			objective, err := self.expression()
+			if err != nil {
+				return err
+			}
+			self.objective = objective
+			return self.expect(lpTokenSemicolon)
+		}
+	}
+
+	// declarations
+	if first.kind == lpTokenIdentifier && self.pos+1 < len(self.tokens) &&
+		self.tokens[self.pos+1].kind == lpTokenIdentifier {
+		keyword := strings.ToLower(first.text)
+		switch keyword {
+		case "int", "bin", "free":
+			self.pos++
+			for token := self.peek(); token != nil && token.kind == lpTokenIdentifier; token = self.peek() {
+				self.declare(token.text)
+				switch keyword {
+				case "int":
+					self.integers[token.text] = true
+				case "bin":
+					self.integers[token.text] = true
+					self.lower[token.text] = 0
+					self.upper[token.text] = 1
+				case "free":
+					self.lower[token.text] = math.Inf(-1)
+				}
+				self.pos++
+			}
+			return self.expect(lpTokenSemicolon)
+		case "sec", "sin", "sos", "sos1", "sos2":
+			return self.errorf("%q sections are not supported", first.text)
+		}
+	}
+
+	label := ""
+	if first.kind == lpTokenIdentifier && self.pos+1 < len(self.tokens) &&
+		self.tokens[self.pos+1].kind == lpTokenColon {
+		label = first.text
+		self.pos += 2
+	}
+
+	// a statement without operator is the objective function
+	expressions := []*lpExpression{}
+	ops := []int{}
+	for {
+		expression, err := self.expression()
+		if err != nil {
+			return err
+		}
+		expressions = append(expressions, expression)
+		token := self.peek()
+		if token.kind == lpTokenSemicolon {
+			self.pos++
+			break
+		}
+		ops = append(ops, lpOperator(token.text))
+		self.pos++
+	}
+	if len(ops) == 0 {
+		if self.statements != 1 || label != "" {
+			return self.errorf("missing operator")
+		}
+		self.objective = expressions[0]
+		self.optType = OptMinimize
+		return nil
+	}
+	if len(ops) > 2 {
+		return self.errorf("too many operators")
+	}
+
+	penaltyNeg, penaltyPos := -1.0, -1.0
+	if token := self.peek(); token != nil && token.kind == lpTokenPenalty {
+		fields := strings.Fields(token.text)
+		var err1, err2 error
+		penaltyNeg, err1 = strconv.ParseFloat(fields[0], 64)
+		penaltyPos, err2 = strconv.ParseFloat(fields[1], 64)
+		if err1 != nil || err2 != nil {
+			return self.errorf("invalid penalties %q", token.text)
+		}
+		self.pos++
+	}
+
+	for i, op := range ops {
+		left, right := expressions[i], expressions[i+1]
+		// a double inequality constant <= expression <= constant
+		if len(ops) == 2 && i == 0 && len(left.names) == 0 {
+			left, right = right, left
+			op = flipOperator(op)
+		}
+		if err := self.addRow(label, left, op, right, penaltyNeg, penaltyPos); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addRow adds left op right, either as a bound or as a constraint.
+func (self *lpParser) addRow(label string, left *lpExpression, op int, right *lpExpression,
+	penaltyNeg, penaltyPos float64) error {
+	expression := &lpExpression{}
+	for i, name := range left.names {
+		expression.add(name, left.coeffs[i])
+	}
+	for i, name := range right.names {
+		expression.add(name, -right.coeffs[i])
+	}
+	rightSide := right.constant - left.constant
+
+	nonZero := 0
+	for _, coeff := range expression.coeffs {
+		if coeff != 0 {
+			nonZero++
+		}
+	}
+	if len(expression.names) == 0 {
+		return self.errorf("constraint without variables")
+	}
+
+	// an unnamed constraint on a single variable is a bound
+	if label == "" && len(expression.names) == 1 && nonZero == 1 {
+		name := expression.names[0]
+		coeff := expression.coeffs[0]
+		value := rightSide / coeff
+		if coeff < 0 {
+			op = flipOperator(op)
+		}
+		if value >= lpInfinity {
+			value = math.Inf(1)
+		} else if value <= -lpInfinity {
+			value = math.Inf(-1)
+		}
+		switch op {
+		case OperatorLE:
+			self.upper[name] = value
+		case OperatorGE:
+			self.lower[name] = value
+		default:
+			self.lower[name] = value
+			self.upper[name] = value
+		}
+		return nil
+	}
+
+	// the default names R1, R2, ... are not kept as labels
+	if label == "R"+strconv.Itoa(len(self.rows)+1) {
+		label = ""
+	}
+	self.rows = append(self.rows, &lpRow{label, expression, op, rightSide, penaltyNeg, penaltyPos})
+	return nil
+}
+
+func (self *lpParser) expect(kind int) error {
+	token := self.peek()
+	if token == nil || token.kind != kind {
+		return self.errorf("unexpected %q", tokenText(token))
+	}
+	self.pos++
+	return nil
+}
+
+// ReadLP reads a model in the LP format of lp_solve and adds its variables,
+// constraints and objective function to the specification. Variables are
+// labeled with their names and have the range [0, +inf) unless bounds are
+// given. Soft constraint penalties written by WriteLP are restored.
+func (self *LinearSpec) ReadLP(r io.Reader) error {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	tokens, err := tokenizeLP(string(input))
+	if err != nil {
+		return err
+	}
+
+	parser := &lpParser{}
+	parser.tokens = tokens
+	parser.lower = make(map[string]float64)
+	parser.upper = make(map[string]float64)
+	parser.integers = make(map[string]bool)
+	parser.optType = OptMinimize
+	for parser.peek() != nil {
+		if token := parser.peek(); token.kind == lpTokenSemicolon {
+			parser.pos++
+			continue
+		}
+		if err := parser.statement(); err != nil {
+			return err
+		}
+	}
+
+	variables := make(map[string]*Variable)
+	for _, name := range parser.names {
+		v, err := self.AddVariable(nil)
+		if err != nil {
+			return err
+		}
+		v.SetLabel(name)
+		v.SetInteger(parser.integers[name])
+		if err := v.SetRange(parser.lower[name], parser.upper[name]); err != nil {
+			return err
+		}
+		variables[name] = v
+	}
+
+	for _, row := range parser.rows {
+		vars := make([]*Variable, len(row.expression.names))
+		for i, name := range row.expression.names {
+			vars[i] = variables[name]
+		}
+		c, err := self.AddConstraint4(row.expression.coeffs, vars, row.op, row.rightSide,
+			row.penaltyNeg, row.penaltyPos)
+		if err != nil {
+			return err
+		}
+		c.SetLabel(row.label)
+	}
+
+	if parser.objective != nil {
+		summands := newSummandList()
+		for i, name := range parser.objective.names {
+			if parser.objective.coeffs[i] != 0 {
+				summands.AddItem(NewSummand(parser.objective.coeffs[i], variables[name]))
+			}
+		}
+		if summands.Len() > 0 {
+			if err := self.SetObjective(summands, parser.optType); err != nil {
+				return err
+			}
+		} else {
+			self.optType = parser.optType
+		}
+	}
+	return nil
+}
+
+// Load reads a specification in LP format from a file and adds it to this
+// specification.
+func (self *LinearSpec) Load(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return self.ReadLP(file)
+}
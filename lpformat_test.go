@@ -0,0 +1,257 @@
+package lp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadLP(t *testing.T) {
+	fmt.Println("Test ReadLP")
+
+	model := `/* test.lp */
+max: 143 x + 60y;
+
+// constraints
+120 x + 210 y <= 15000;
+110 x + 30 y =< 4000;
+capacity: x + y < 75;
+`
+	ls := newSimplexSpec()
+	if err := ls.ReadLP(strings.NewReader(model)); err != nil {
+		t.Fatal(err)
+	}
+	if ls.AllVariables().Len() != 2 || ls.Constraints().Len() != 3 {
+		t.Fatalf("read %v variables and %v constraints", ls.AllVariables().Len(),
+			ls.Constraints().Len())
+	}
+	if label := ls.Constraints().GetAt(2).Label(); label != "capacity" {
+		t.Errorf("label = %q, want capacity", label)
+	}
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", ls.AllVariables().GetAt(0).Value(), 21.875)
+	checkValue(t, "y", ls.AllVariables().GetAt(1).Value(), 53.125)
+	checkValue(t, "objective", ls.ObjectiveValue(), 6315.625)
+}
+
+func TestReadLPBounds(t *testing.T) {
+	fmt.Println("Test ReadLP Bounds")
+
+	model := `min: 2a + 3 * b - c;
+R1: a >= 1;
+c1: 3 a + 2 b >= 2 b + 4;
+-5 <= a - c <= 10;
+-b >= -8;
+3 c <= 6;
+a <= 1e30;
+d >= -1e30;
+e = 2;
+int b
+, c;
+free f;`
+	ls := newSimplexSpec()
+	if err := ls.ReadLP(strings.NewReader(model)); err != nil {
+		t.Fatal(err)
+	}
+
+	variables := make(map[string]*Variable)
+	for i := 0; i < ls.AllVariables().Len(); i++ {
+		v := ls.AllVariables().GetAt(i)
+		variables[v.Label()] = v
+	}
+	for _, test := range []struct {
+		name      string
+		min, max  float64
+		isInteger bool
+	}{
+		{"a", 0, math.Inf(1), false},
+		{"b", 0, 8, true},
+		{"c", 0, 2, true},
+		{"d", math.Inf(-1), math.Inf(1), false},
+		{"e", 2, 2, false},
+		{"f", math.Inf(-1), math.Inf(1), false},
+	} {
+		v := variables[test.name]
+		if v == nil {
+			t.Errorf("variable %v missing", test.name)
+			continue
+		}
+		if v.Min() != test.min || v.Max() != test.max || v.IsInteger() != test.isInteger {
+			t.Errorf("%v: [%v, %v] integer %v, want [%v, %v] integer %v", test.name,
+				v.Min(), v.Max(), v.IsInteger(), test.min, test.max, test.isInteger)
+		}
+	}
+
+	// R1 names the first row and is not kept, the range gives two rows
+	constraints := ls.Constraints()
+	if constraints.Len() != 4 {
+		t.Fatalf("read %v constraints, want 4", constraints.Len())
+	}
+	if constraints.GetAt(0).Label() != "" || constraints.GetAt(1).Label() != "c1" {
+		t.Errorf("labels %q %q, want \"\" c1", constraints.GetAt(0).Label(),
+			constraints.GetAt(1).Label())
+	}
+	// 3 a + 2 b - 2 b >= 4
+	c1 := constraints.GetAt(1)
+	if c1.LeftSide().Len() != 2 || c1.LeftSide().GetAt(1).Coeff() != 0 || c1.RightSide() != 4 {
+		t.Errorf("c1 parsed wrong")
+	}
+	if constraints.GetAt(2).Op() != OperatorGE || constraints.GetAt(2).RightSide() != -5 ||
+		constraints.GetAt(3).Op() != OperatorLE || constraints.GetAt(3).RightSide() != 10 {
+		t.Errorf("range parsed wrong")
+	}
+}
+
+func TestReadLPErrors(t *testing.T) {
+	fmt.Println("Test ReadLP Errors")
+
+	for _, model := range []string{
+		"max: 3x + 2y",
+		"max: 3x; c1: x + y <= 4; max: y;",
+		"x + y <= 4; sin x;",
+		"x + y <= 4 <= 5 <= 6;",
+		"x + y ? 4;",
+		"c1: <= 4;",
+		"/* open comment",
+	} {
+		ls := newSimplexSpec()
+		if err := ls.ReadLP(strings.NewReader(model)); err == nil {
+			t.Errorf("no error for %q", model)
+		}
+	}
+
+	// a lower bound above the upper bound
+	ls := newSimplexSpec()
+	if err := ls.ReadLP(strings.NewReader("max: x; x >= 5; x <= 1;")); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("contradictory bounds: %v", err)
+	}
+}
+
+func TestWriteLP(t *testing.T) {
+	fmt.Println("Test WriteLP")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	x.SetLabel("x")
+	x.SetRange(0, math.Inf(1))
+	y, _ := ls.AddVariable(nil)
+	y.SetLabel("y")
+	y.SetRange(math.Inf(-1), 6)
+	z, _ := ls.AddVariable(nil)
+	z.SetRange(1, 1)
+	n, _ := ls.AddVariable(nil)
+	n.SetLabel("not a name")
+	n.SetBinary()
+
+	ls.AddConstraint2([]float64{1.0}, []*Variable{x}, OperatorLE, 4)
+	c, _ := ls.AddConstraint2([]float64{3.0, 2.0, -1.0}, []*Variable{x, y, n}, OperatorLE, 18)
+	c.SetLabel("capacity")
+	ls.AddConstraint4([]float64{1.0, -1.0}, []*Variable{x, y}, OperatorGE, 1.5, 2, 0)
+	ls.SetObjective1([]float64{3.0, 5.0}, []*Variable{x, y}, OptMaximize)
+
+	var buffer bytes.Buffer
+	if err := ls.WriteLP(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written := buffer.String()
+	want := `/* Objective function */
+max: +3 x +5 y +0 C3 +0 C4;
+
+/* Constraints */
+R1: +x <= 4;
+capacity: +3 x +2 y -C4 <= 18;
++x -y >= 1.5; /* penalties: 2 0 */
+
+/* Bounds */
+y >= -1e30;
+y <= 6;
+C3 = 1;
+C4 <= 1;
+
+int C4;
+`
+	if written != want {
+		t.Errorf("WriteLP wrote\n%v\nwant\n%v", written, want)
+	}
+
+	// read it back and write it again
+	read := newSimplexSpec()
+	if err := read.ReadLP(strings.NewReader(written)); err != nil {
+		t.Fatal(err)
+	}
+	buffer.Reset()
+	read.WriteLP(&buffer)
+	if buffer.String() != written {
+		t.Errorf("round trip wrote\n%v\nwant\n%v", buffer.String(), written)
+	}
+	soft := read.Constraints().GetAt(2)
+	if soft.PenaltyNeg() != 2 || soft.PenaltyPos() != 0 || !soft.IsSoft() {
+		t.Errorf("penalties %v %v, want 2 0", soft.PenaltyNeg(), soft.PenaltyPos())
+	}
+
+	ls.Solve()
+	read.Solve()
+	checkValue(t, "objective", read.ObjectiveValue(), ls.ObjectiveValue())
+}
+
+func TestWriteLPSoftInequality(t *testing.T) {
+	fmt.Println("Test WriteLP SoftInequality")
+
+	// the active set solver adds a slack and a penalized row to the soft
+	// inequality, neither is written
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	x.SetLabel("x")
+	y, _ := ls.AddVariable(nil)
+	y.SetLabel("y")
+	ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{x, y}, OperatorEQ, 10)
+	ls.AddConstraint4([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorLE, 5, 0, 3)
+
+	var buffer bytes.Buffer
+	if err := ls.WriteLP(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	written := buffer.String()
+	want := `/* Objective function */
+min: +0 x +0 y;
+
+/* Constraints */
++x -y = 10;
++x +y <= 5; /* penalties: 0 3 */
+
+/* Bounds */
+x >= -1e30;
+y >= -1e30;
+`
+	if written != want {
+		t.Errorf("WriteLP wrote\n%v\nwant\n%v", written, want)
+	}
+
+	read := NewLinearSpec()
+	if err := read.ReadLP(strings.NewReader(written)); err != nil {
+		t.Fatal(err)
+	}
+	buffer.Reset()
+	read.WriteLP(&buffer)
+	if buffer.String() != written {
+		t.Errorf("round trip wrote\n%v\nwant\n%v", buffer.String(), written)
+	}
+	if read.Constraints().Len() != ls.Constraints().Len() ||
+		read.AllVariables().Len() != ls.AllVariables().Len() {
+		t.Errorf("read %v constraints and %v variables, want %v and %v",
+			read.Constraints().Len(), read.AllVariables().Len(),
+			ls.Constraints().Len(), ls.AllVariables().Len())
+	}
+
+	if result, _ := read.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	ls.Solve()
+	checkValue(t, "x", read.AllVariables().GetAt(0).Value(), x.Value())
+	checkValue(t, "y", read.AllVariables().GetAt(1).Value(), y.Value())
+}
@@ -0,0 +1,408 @@
+package lp
+
+import (
+	"math"
+	"slices"
+)
+
+// lpModel is a snapshot of a LinearSpec in the form used by the simplex
+// based solvers:
+//
+//	min c^Tx  s.t.  a_i^Tx (op_i) b_i,  lower <= x <= upper
+//
+// A maximization objective is turned into a minimization by negating c.
+// Soft constraints get a column for each penalized deviation, whose
+// penalty is added to c.
+type lpModel struct {
+	rows, columns int
+	// A by columns: row j of a is column j of A
+	a            *sparseMatrix
+	b            []float64
+	ops          []int
+	c            []float64
+	lower, upper []float64
+
+	// column -> variable, nil for the deviation columns of soft constraints
+	variables []*Variable
+	// column -> identity of the column across snapshots
+	keys []columnKey
+	// row -> constraint
+	constraints []*Constraint
+	// 1 for minimization, -1 for maximization
+	sense float64
+	// constant of the objective, e.g. of variables removed by the presolve
+	offset     float64
+	tolerances Tolerances
+	// factors of the rows and columns, nil if the model is not scaled
+	rowScale, columnScale []float64
+}
+
+// columnKey identifies a column of an lpModel: either a variable or the
+// negative or positive deviation of a soft constraint.
+type columnKey struct {
+	variable   *Variable
+	constraint *Constraint
+	positive   bool
+}
+
+// newLPModel builds the model of all variables and constraints of ls. If
+// withSoft is false the soft constraints are left out.
+func newLPModel(ls *LinearSpec, withSoft bool) *lpModel {
+	model := newEmptyLPModel(ls)
+	allVariables := ls.AllVariables()
+	for i := 0; i < allVariables.Len(); i++ {
+		v := allVariables.GetAt(i)
+		model.addColumn(v, v.Min(), v.Max(), 0)
+	}
+
+	model.setObjective(ls)
+
+	var rows []*Constraint
+	constraints := ls.Constraints()
+	for i := 0; i < constraints.Len(); i++ {
+		constraint := constraints.GetAt(i)
+		if constraint.IsSoft() && !withSoft {
+			continue
+		}
+		rows = append(rows, constraint)
+	}
+	model.setRows(rows)
+	model.scale(ls.Scaling())
+
+	return model
+}
+
+// setObjective sets the costs of the variable columns to the objective of
+// ls, scaled like the columns.
+func (self *lpModel) setObjective(ls *LinearSpec) {
+	self.sense = 1
+	if ls.OptimizationType() == OptMaximize {
+		self.sense = -1
+	}
+	for j, v := range self.variables {
+		if v != nil {
+			self.c[j] = 0
+		}
+	}
+	columnOf := self.columnMap()
+	objective := ls.Objective()
+	for i := 0; i < objective.Len(); i++ {
+		s := objective.GetAt(i)
+		self.c[columnOf[s.Var()]] += self.sense * s.Coeff()
+	}
+	for j, v := range self.variables {
+		if v != nil {
+			self.c[j] *= self.columnFactor(j)
+		}
+	}
+}
+
+func newEmptyLPModel(ls *LinearSpec) *lpModel {
+	model := &lpModel{}
+	model.sense = 1
+	model.tolerances = ls.Tolerances()
+	if ls.OptimizationType() == OptMaximize {
+		model.sense = -1
+	}
+	return model
+}
+
+// addColumn adds the column of a variable with cost c in the direction of
+// the model.
+func (self *lpModel) addColumn(v *Variable, lower, upper, c float64) {
+	self.variables = append(self.variables, v)
+	self.keys = append(self.keys, columnKey{v, nil, false})
+	self.lower = append(self.lower, lower)
+	self.upper = append(self.upper, upper)
+	self.c = append(self.c, c)
+}
+
+func (self *lpModel) columnMap() map[*Variable]int {
+	columnOf := make(map[*Variable]int, len(self.variables))
+	for j, v := range self.variables {
+		if v != nil {
+			columnOf[v] = j
+		}
+	}
+	return columnOf
+}
+
+// setRows sets the rows of the model to the constraints, after the columns
+// of the variables were added. The summands must only use these
+// variables.
+func (self *lpModel) setRows(constraints []*Constraint) {
+	columnOf := self.columnMap()
+	self.constraints = constraints
+	self.rows = len(self.constraints)
+
+	// deviation columns of the soft constraints
+	deviationColumns := make([][2]int, self.rows)
+	for r, constraint := range self.constraints {
+		deviationColumns[r] = [2]int{-1, -1}
+		if !constraint.IsSoft() {
+			continue
+		}
+		if constraint.PenaltyNeg() > 0 {
+			deviationColumns[r][0] = self.addDeviationColumn(constraint, false)
+		}
+		if constraint.PenaltyPos() > 0 {
+			deviationColumns[r][1] = self.addDeviationColumn(constraint, true)
+		}
+	}
+	self.columns = len(self.variables)
+
+	self.a = newSparseMatrix(self.columns, self.rows)
+	self.b = make([]float64, self.rows)
+	self.ops = make([]int, self.rows)
+	for r, constraint := range self.constraints {
+		leftSide := constraint.LeftSide()
+		for s := 0; s < leftSide.Len(); s++ {
+			summand := leftSide.GetAt(s)
+			*self.a.ref(columnOf[summand.Var()], r) += summand.Coeff()
+		}
+		// a negative deviation means the left side is too large
+		if deviationColumns[r][0] >= 0 {
+			*self.a.ref(deviationColumns[r][0], r) = -1
+		}
+		if deviationColumns[r][1] >= 0 {
+			*self.a.ref(deviationColumns[r][1], r) = 1
+		}
+		self.b[r] = constraint.RightSide()
+		self.ops[r] = constraint.Op()
+	}
+	// summands that cancel out
+	for j := range self.a.rows {
+		self.a.rows[j].dropZeros()
+	}
+}
+
+// growColumns adds empty columns to a built model for the columns added
+// with addColumn or addDeviationColumn since. Their factor is 1.
+func (self *lpModel) growColumns() {
+	for self.columns < len(self.variables) {
+		self.a.rows = append(self.a.rows, sparseVector{})
+		if self.columnScale != nil {
+			self.columnScale = append(self.columnScale, 1)
+		}
+		self.columns++
+	}
+	self.a.m = self.columns
+}
+
+// removeColumn removes column j from a built model.
+func (self *lpModel) removeColumn(j int) {
+	self.variables = slices.Delete(self.variables, j, j+1)
+	self.keys = slices.Delete(self.keys, j, j+1)
+	self.lower = slices.Delete(self.lower, j, j+1)
+	self.upper = slices.Delete(self.upper, j, j+1)
+	self.c = slices.Delete(self.c, j, j+1)
+	self.a.rows = slices.Delete(self.a.rows, j, j+1)
+	if self.columnScale != nil {
+		self.columnScale = slices.Delete(self.columnScale, j, j+1)
+	}
+	self.columns--
+	self.a.m = self.columns
+}
+
+// appendRow adds the row of a constraint to a built model, with the
+// deviation columns of a soft constraint. The row is scaled for the mode
+// like the other rows. Returns false if the constraint uses a variable
+// without a column.
+func (self *lpModel) appendRow(constraint *Constraint, columnOf map[*Variable]int, mode int) bool {
+	var columns []int
+	var values []float64
+	position := make(map[int]int)
+	leftSide := constraint.LeftSide()
+	for s := 0; s < leftSide.Len(); s++ {
+		summand := leftSide.GetAt(s)
+		j, ok := columnOf[summand.Var()]
+		if !ok {
+			return false
+		}
+		if k, ok := position[j]; ok {
+			values[k] += summand.Coeff()
+			continue
+		}
+		position[j] = len(columns)
+		columns = append(columns, j)
+		values = append(values, summand.Coeff())
+	}
+	if constraint.IsSoft() {
+		if constraint.PenaltyNeg() > 0 {
+			columns = append(columns, self.addDeviationColumn(constraint, false))
+			values = append(values, -1)
+		}
+		if constraint.PenaltyPos() > 0 {
+			columns = append(columns, self.addDeviationColumn(constraint, true))
+			values = append(values, 1)
+		}
+		self.growColumns()
+	}
+
+	factor := 1.0
+	if self.rowScale != nil {
+		for k, j := range columns {
+			values[k] *= self.columnScale[j]
+		}
+		factor = rowScaleFactor(values, mode)
+		self.rowScale = append(self.rowScale, factor)
+	}
+	i := self.rows
+	for k, j := range columns {
+		if values[k] == 0 {
+			continue
+		}
+		column := &self.a.rows[j]
+		column.index = append(column.index, i)
+		column.value = append(column.value, values[k]*factor)
+	}
+	self.b = append(self.b, constraint.RightSide()*factor)
+	self.ops = append(self.ops, constraint.Op())
+	self.constraints = append(self.constraints, constraint)
+	self.rows++
+	self.a.n = self.rows
+	return true
+}
+
+// removeRow removes row i from a built model; the deviation columns of a
+// soft constraint stay.
+func (self *lpModel) removeRow(i int) {
+	for j := range self.a.rows {
+		column := &self.a.rows[j]
+		column.remove(i)
+		for k, r := range column.index {
+			if r > i {
+				column.index[k]--
+			}
+		}
+	}
+	self.b = slices.Delete(self.b, i, i+1)
+	self.ops = slices.Delete(self.ops, i, i+1)
+	self.constraints = slices.Delete(self.constraints, i, i+1)
+	if self.rowScale != nil {
+		self.rowScale = slices.Delete(self.rowScale, i, i+1)
+	}
+	self.rows--
+	self.a.n = self.rows
+}
+
+// column returns column j of A.
+func (self *lpModel) column(j int) *sparseVector {
+	return &self.a.rows[j]
+}
+
+func (self *lpModel) addDeviationColumn(constraint *Constraint, positive bool) int {
+	penalty := constraint.PenaltyNeg()
+	if positive {
+		penalty = constraint.PenaltyPos()
+	}
+	self.variables = append(self.variables, nil)
+	self.keys = append(self.keys, columnKey{nil, constraint, positive})
+	self.lower = append(self.lower, 0)
+	self.upper = append(self.upper, math.Inf(1))
+	self.c = append(self.c, penalty)
+	return len(self.variables) - 1
+}
+
+// withBounds returns a copy of the model with other column bounds; the
+// coefficients are shared.
+func (self *lpModel) withBounds(lower, upper []float64) *lpModel {
+	model := *self
+	model.lower = lower
+	model.upper = upper
+	return &model
+}
+
+// objective returns the objective value of x in the direction of the
+// LinearSpec, i.e. without the sign change for maximization.
+func (self *lpModel) objective(x []float64) float64 {
+	value := 0.0
+	for j := 0; j < self.columns; j++ {
+		if self.variables[j] == nil {
+			continue
+		}
+		value += self.c[j] * x[j]
+	}
+	return self.sense * (value + self.offset)
+}
+
+// setValues writes the structural values of x back to the variables.
+func (self *lpModel) setValues(x []float64) {
+	for j, v := range self.variables {
+		if v != nil {
+			v.SetValue(x[j] * self.columnFactor(j))
+		}
+	}
+}
+
+// setDuals writes the simplex multipliers y of the rows and the reduced
+// costs d of the structural columns back to the constraints and variables,
+// in the direction of the LinearSpec. nil vectors clear them.
+func (self *lpModel) setDuals(y, d []float64) {
+	for i, constraint := range self.constraints {
+		constraint.dual = 0
+		if y != nil && y[i] != 0 {
+			constraint.dual = self.sense * y[i] * self.rowFactor(i)
+		}
+	}
+	for j, v := range self.variables {
+		if v == nil {
+			continue
+		}
+		v.reducedCost = 0
+		if d != nil && d[j] != 0 {
+			v.reducedCost = self.sense * d[j] / self.columnFactor(j)
+		}
+	}
+}
+
+// subset returns a copy of the model without objective that has only the
+// given rows and other column bounds.
+func (self *lpModel) subset(rows []bool, lower, upper []float64) *lpModel {
+	model := *self
+	model.c = make([]float64, self.columns)
+	model.lower = lower
+	model.upper = upper
+	model.b, model.ops, model.constraints = nil, nil, nil
+	model.rowScale = nil
+	// the rows of the subset, -1 for the ones left out
+	rowOf := make([]int, self.rows)
+	for i, keep := range rows {
+		rowOf[i] = -1
+		if !keep {
+			continue
+		}
+		if self.rowScale != nil {
+			model.rowScale = append(model.rowScale, self.rowScale[i])
+		}
+		rowOf[i] = len(model.constraints)
+		model.b = append(model.b, self.b[i])
+		model.ops = append(model.ops, self.ops[i])
+		model.constraints = append(model.constraints, self.constraints[i])
+	}
+	model.rows = len(model.constraints)
+	model.a = newSparseMatrix(self.columns, model.rows)
+	for j := range self.a.rows {
+		column := self.column(j)
+		for k, i := range column.index {
+			if rowOf[i] >= 0 {
+				model.a.rows[j].index = append(model.a.rows[j].index, rowOf[i])
+				model.a.rows[j].value = append(model.a.rows[j].value, column.value[k])
+			}
+		}
+	}
+	return &model
+}
+
+// isFeasible returns whether the constraints and bounds of the model can be
+// satisfied together.
+func (self *lpModel) isFeasible() (bool, error) {
+	result := newSimplex(self).solve()
+	switch result {
+	case ResultOptimal, ResultUnbounded:
+		return true, nil
+	case ResultInfeasible:
+		return false, nil
+	}
+	return false, resultError(result)
+}
@@ -0,0 +1,313 @@
+package lp
+
+import "math"
+
+// sparsePivotThreshold is the least ratio of a pivot to the largest entry
+// of its column that sparseLU accepts for a sparser pivot.
+const sparsePivotThreshold = 0.1
+
+// sparseSearchColumns is the number of columns sparseLU examines for a
+// pivot once it has found one.
+const sparseSearchColumns = 4
+
+// eta is a sparse column of an elementary transformation at a position.
+type eta struct {
+	position int
+	pivot    float64
+	index    []int
+	value    []float64
+}
+
+// sparseLU is a sparse LU factorization by Gaussian elimination on the
+// rows. It picks the pivots by the Markowitz criterion, i.e. the least
+// (r-1)(c-1) for r entries in the row and c in the column, among the
+// entries that pass the threshold, to keep the fill-in low. Updates of the
+// basis are kept in product form.
+type sparseLU struct {
+	m int
+	// largest entry of a column that counts as zero
+	singular float64
+
+	pivotRows    []int
+	pivotColumns []int
+	pivots       []float64
+	// the multipliers of the rows eliminated by each step
+	lower []eta
+	// the pivot rows, which only keep entries in the columns of later steps
+	upper *sparseMatrix
+	// product form of the updates since the factorization
+	updates []eta
+	temp    []float64
+}
+
+func newSparseLU(m int, singular float64) *sparseLU {
+	lu := &sparseLU{}
+	lu.m = m
+	lu.singular = singular
+	lu.temp = make([]float64, m)
+
+	return lu
+}
+
+func (self *sparseLU) factorize(columns []sparseVector) bool {
+	a := newSparseMatrix(self.m, self.m)
+	for k := 0; k < self.m; k++ {
+		column := &columns[k]
+		for l, i := range column.index {
+			if column.value[l] != 0 {
+				a.rows[i].index = append(a.rows[i].index, k)
+				a.rows[i].value = append(a.rows[i].value, column.value[l])
+			}
+		}
+	}
+	return self.factorizeRows(a)
+}
+
+// factorizeRows factorizes the m x n matrix a with m <= n and overwrites
+// it. It fails if a has less than m independent columns. Only a square a
+// can be solved with afterwards.
+func (self *sparseLU) factorizeRows(a *sparseMatrix) bool {
+	return self.eliminate(a) == a.m
+}
+
+// eliminate runs the elimination of factorizeRows on the m x n matrix a
+// until no column has a pivot left and returns the number of steps. The
+// columns of the first steps are linearly independent.
+func (self *sparseLU) eliminate(a *sparseMatrix) int {
+	m, n := a.m, a.n
+	self.upper = a
+	self.pivotRows = make([]int, m)
+	self.pivotColumns = make([]int, m)
+	self.pivots = make([]float64, m)
+	self.lower = make([]eta, m)
+	self.updates = nil
+	eliminated := make([]bool, m)
+	done := make([]bool, n)
+
+	// rows with an entry in each column, including the fill-in and rows
+	// that lost the entry since
+	columnRows := make([][]int, n)
+	count := make([]int, n)
+	for i := range a.rows {
+		// the elimination drops zeros, so they must not count as entries
+		a.rows[i].dropZeros()
+		for _, j := range a.rows[i].index {
+			columnRows[j] = append(columnRows[j], i)
+			count[j]++
+		}
+	}
+
+	// the columns in lists by their count
+	head := make([]int, m+1)
+	next := make([]int, n)
+	prev := make([]int, n)
+	for c := range head {
+		head[c] = -1
+	}
+	insert := func(j int) {
+		next[j], prev[j] = head[count[j]], -1
+		if head[count[j]] >= 0 {
+			prev[head[count[j]]] = j
+		}
+		head[count[j]] = j
+	}
+	unlink := func(j int) {
+		if prev[j] >= 0 {
+			next[prev[j]] = next[j]
+		} else {
+			head[count[j]] = next[j]
+		}
+		if next[j] >= 0 {
+			prev[next[j]] = prev[j]
+		}
+		done[j] = true
+	}
+	addCount := func(j, delta int) {
+		if !done[j] {
+			unlink(j)
+			done[j] = false
+			count[j] += delta
+			insert(j)
+		}
+	}
+	for j := 0; j < n; j++ {
+		insert(j)
+	}
+	has := func(row *sparseVector, j int) bool {
+		k := row.search(j)
+		return k < len(row.index) && row.index[k] == j
+	}
+
+	for step := 0; step < m; step++ {
+		pivotRow, pivotColumn := -1, -1
+		bestCost := -1
+		searched := 0
+		for c := 1; c <= m && (pivotRow < 0 || searched < sparseSearchColumns); c++ {
+			for j := head[c]; j >= 0 && (pivotRow < 0 || searched < sparseSearchColumns); {
+				nextColumn := next[j]
+				maxValue := 0.0
+				for _, i := range columnRows[j] {
+					if !eliminated[i] {
+						maxValue = math.Max(maxValue, math.Abs(a.at(i, j)))
+					}
+				}
+				if maxValue <= self.singular {
+					// numerically empty
+					unlink(j)
+					j = nextColumn
+					continue
+				}
+				for _, i := range columnRows[j] {
+					if eliminated[i] || math.Abs(a.at(i, j)) < sparsePivotThreshold*maxValue {
+						continue
+					}
+					cost := (len(a.rows[i].index) - 1) * (c - 1)
+					if bestCost < 0 || cost < bestCost {
+						pivotRow, pivotColumn, bestCost = i, j, cost
+					}
+				}
+				searched++
+				j = nextColumn
+			}
+		}
+		if pivotRow < 0 {
+			return step
+		}
+
+		eliminated[pivotRow] = true
+		unlink(pivotColumn)
+		pivot := &a.rows[pivotRow]
+		pivotValue := pivot.at(pivotColumn)
+		self.pivotRows[step] = pivotRow
+		self.pivotColumns[step] = pivotColumn
+		self.pivots[step] = pivotValue
+		self.lower[step].position = pivotRow
+		for _, j := range pivot.index {
+			addCount(j, -1)
+		}
+
+		for _, i := range columnRows[pivotColumn] {
+			row := &a.rows[i]
+			if eliminated[i] || !has(row, pivotColumn) {
+				continue
+			}
+			before := make([]bool, len(pivot.index))
+			for k, j := range pivot.index {
+				before[k] = has(row, j)
+			}
+			q := -row.at(pivotColumn) / pivotValue
+			row.axpy(q, pivot)
+			row.remove(pivotColumn)
+			self.lower[step].index = append(self.lower[step].index, i)
+			self.lower[step].value = append(self.lower[step].value, q)
+
+			// the counts only change in the columns of the pivot row
+			for k, j := range pivot.index {
+				switch after := has(row, j); {
+				case before[k] && !after:
+					addCount(j, -1)
+				case !before[k] && after:
+					addCount(j, 1)
+					columnRows[j] = append(columnRows[j], i)
+				}
+			}
+		}
+	}
+	return m
+}
+
+// ftran overwrites a with B^-1 a.
+func (self *sparseLU) ftran(a []float64) {
+	for _, l := range self.lower {
+		if a[l.position] == 0 {
+			continue
+		}
+		for k, i := range l.index {
+			a[i] += l.value[k] * a[l.position]
+		}
+	}
+
+	x := self.temp
+	for j := range x {
+		x[j] = 0
+	}
+	for step := self.m - 1; step >= 0; step-- {
+		row := &self.upper.rows[self.pivotRows[step]]
+		// x of the pivot column is still zero
+		x[self.pivotColumns[step]] = (a[self.pivotRows[step]] - row.dot(x)) / self.pivots[step]
+	}
+	copy(a, x)
+
+	for _, u := range self.updates {
+		xr := a[u.position] / u.pivot
+		a[u.position] = xr
+		if xr == 0 {
+			continue
+		}
+		for k, i := range u.index {
+			a[i] -= u.value[k] * xr
+		}
+	}
+}
+
+// btran overwrites c with B^-T c.
+func (self *sparseLU) btran(c []float64) {
+	for k := len(self.updates) - 1; k >= 0; k-- {
+		u := &self.updates[k]
+		sum := c[u.position]
+		for l, i := range u.index {
+			sum -= u.value[l] * c[i]
+		}
+		c[u.position] = sum / u.pivot
+	}
+
+	z := self.temp
+	for step := 0; step < self.m; step++ {
+		p, q := self.pivotRows[step], self.pivotColumns[step]
+		z[p] = c[q] / self.pivots[step]
+		if z[p] == 0 {
+			continue
+		}
+		row := &self.upper.rows[p]
+		for k, j := range row.index {
+			if j != q {
+				c[j] -= row.value[k] * z[p]
+			}
+		}
+	}
+
+	for step := len(self.lower) - 1; step >= 0; step-- {
+		l := &self.lower[step]
+		for k, i := range l.index {
+			z[l.position] += l.value[k] * z[i]
+		}
+	}
+	copy(c, z)
+}
+
+func (self *sparseLU) update(r int, w []float64) bool {
+	if math.Abs(w[r]) < self.singular {
+		return false
+	}
+	u := eta{position: r, pivot: w[r]}
+	for i, value := range w {
+		if i != r && value != 0 {
+			u.index = append(u.index, i)
+			u.value = append(u.value, value)
+		}
+	}
+	self.updates = append(self.updates, u)
+	return true
+}
+
+// sparseSolve solves the square system ax = b and overwrites b with x. A
+// column whose entries are not larger than singular fails the solve. a is
+// overwritten.
+func sparseSolve(a *sparseMatrix, b []float64, singular float64) bool {
+	lu := newSparseLU(a.m, singular)
+	if !lu.factorizeRows(a) {
+		return false
+	}
+	lu.ftran(b)
+	return true
+}
@@ -0,0 +1,261 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSparseLU(t *testing.T) {
+	fmt.Println("Test SparseLU")
+
+	const m = 12
+	random := rand.New(rand.NewSource(3))
+	columns := initMatrixSlice(m, m)
+	for k := 0; k < m; k++ {
+		columns[k][k] = 4 + random.Float64()
+		for l := 0; l < 3; l++ {
+			columns[k][random.Intn(m)] += random.Float64() - 0.5
+		}
+	}
+
+	lu := newSparseLU(m, simplexPivotEpsilon)
+	dense := newDenseInverse(m)
+	if !lu.factorize(sparseColumns(columns)) || !dense.factorize(sparseColumns(columns)) {
+		t.Fatal("regular basis not factorized")
+	}
+
+	compare := func(name string) {
+		a := make([]float64, m)
+		c := make([]float64, m)
+		for i := range a {
+			a[i] = random.Float64()
+			c[i] = random.Float64()
+		}
+		a2 := append([]float64(nil), a...)
+		c2 := append([]float64(nil), c...)
+		lu.ftran(a)
+		dense.ftran(a2)
+		lu.btran(c)
+		dense.btran(c2)
+		for i := 0; i < m; i++ {
+			if !fuzzyEquals(a[i], a2[i]) || !fuzzyEquals(c[i], c2[i]) {
+				t.Fatalf("%v: sparse and dense solves differ in %v", name, i)
+			}
+		}
+	}
+	compare("factorization")
+
+	// replace basis columns in product form
+	for r := 0; r < 5; r++ {
+		w := make([]float64, m)
+		for i := range w {
+			w[i] = random.Float64() - 0.5
+		}
+		w[r] = 2
+		lu.ftran(w)
+		w2 := append([]float64(nil), w...)
+		if !lu.update(r, w) || !dense.update(r, w2) {
+			t.Fatal("update failed")
+		}
+		compare(fmt.Sprintf("update %v", r))
+	}
+
+	// an arrow: pivots on the dense row or column first fill everything
+	const n = 40
+	a := newSparseMatrix(n, n)
+	for i := 0; i < n; i++ {
+		*a.ref(i, i) = 4
+		*a.ref(0, i) = 1
+		*a.ref(i, 0) = 1
+	}
+	*a.ref(0, 0) = n
+	lu = newSparseLU(n, EqualsEpsilon)
+	if !lu.factorizeRows(a) {
+		t.Fatal("arrow not factorized")
+	}
+	fill := 0
+	for i := range lu.lower {
+		fill += len(lu.lower[i].index)
+	}
+	for i := range lu.upper.rows {
+		fill += len(lu.upper.rows[i].index)
+	}
+	if fill > 4*n {
+		t.Errorf("%v entries in L and U for %v in the arrow", fill, 3*n-2)
+	}
+
+	// zeros stored as entries must not confuse the counts of the columns
+	for seed := int64(0); seed < 400; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		a := newSparseMatrix(10, 10)
+		for i := 0; i < 10; i++ {
+			for j := 0; j < 10; j++ {
+				if value := float64(random.Intn(3)); value != 0 || random.Intn(4) == 0 {
+					*a.ref(i, j) = value
+				}
+			}
+		}
+		original := a.copy()
+		lu = newSparseLU(10, EqualsEpsilon)
+		if !lu.factorizeRows(a) {
+			continue
+		}
+		x := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+		b := make([]float64, 10)
+		original.multiplyVector(x, b)
+		lu.ftran(b)
+		for i := range x {
+			if !fuzzyEquals(b[i], x[i]) {
+				t.Fatalf("seed %v: x[%v] = %v, want %v", seed, i, b[i], x[i])
+			}
+		}
+	}
+
+	singular := initMatrixSlice(3, 3)
+	singular[0][0], singular[1][0] = 1, 2
+	singular[0][1], singular[1][1] = 2, 4
+	singular[2][2] = 1
+	if newSparseLU(3, simplexPivotEpsilon).factorize(sparseColumns(singular)) {
+		t.Error("singular basis factorized")
+	}
+
+	// the elimination of the singular matrix stops after the independent
+	// columns
+	a = newSparseMatrix(3, 3)
+	for i, row := range [][]float64{{1, 2, 0}, {2, 4, 0}, {0, 0, 1}} {
+		for j, value := range row {
+			if value != 0 {
+				*a.ref(i, j) = value
+			}
+		}
+	}
+	lu = newSparseLU(3, simplexPivotEpsilon)
+	if steps := lu.eliminate(a); steps != 2 {
+		t.Fatalf("%v steps, want 2", steps)
+	}
+	if columns := lu.pivotColumns[:2]; columns[0] != 2 && columns[1] != 2 {
+		t.Errorf("pivot columns %v without the independent column 2", columns)
+	}
+}
+
+func fuzzyEquals(a, b float64) bool {
+	return math.Abs(a-b) < EqualsEpsilon
+}
+
+// sparseColumns returns the non-zero entries of the dense columns.
+func sparseColumns(columns [][]float64) []sparseVector {
+	sparse := make([]sparseVector, len(columns))
+	for k, column := range columns {
+		for i, value := range column {
+			if value != 0 {
+				sparse[k].index = append(sparse[k].index, i)
+				sparse[k].value = append(sparse[k].value, value)
+			}
+		}
+	}
+	return sparse
+}
+
+// denseInverse keeps the explicit inverse of the basis and updates it with
+// the product form of the inverse. It is the reference for the sparse LU.
+type denseInverse struct {
+	m    int
+	inv  [][]float64
+	temp []float64
+}
+
+func newDenseInverse(m int) *denseInverse {
+	di := &denseInverse{}
+	di.m = m
+	di.inv = initMatrixSlice(m, m)
+	di.temp = make([]float64, m)
+
+	return di
+}
+
+func (self *denseInverse) factorize(columns []sparseVector) bool {
+	m := self.m
+	// Gauss-Jordan elimination on [B | I] with partial pivoting
+	b := initMatrixSlice(m, m)
+	for k := 0; k < m; k++ {
+		for l, i := range columns[k].index {
+			b[i][k] = columns[k].value[l]
+		}
+	}
+	zeroMatrix(self.inv, m, m)
+	for i := 0; i < m; i++ {
+		self.inv[i][i] = 1
+	}
+
+	for k := 0; k < m; k++ {
+		pivot := k
+		pivotValue := math.Abs(b[k][k])
+		for i := k + 1; i < m; i++ {
+			if math.Abs(b[i][k]) > pivotValue {
+				pivot = i
+				pivotValue = math.Abs(b[i][k])
+			}
+		}
+		if pivotValue < simplexPivotEpsilon {
+			return false
+		}
+		b[k], b[pivot] = b[pivot], b[k]
+		self.inv[k], self.inv[pivot] = self.inv[pivot], self.inv[k]
+
+		q := 1 / b[k][k]
+		for j := 0; j < m; j++ {
+			b[k][j] *= q
+			self.inv[k][j] *= q
+		}
+		for i := 0; i < m; i++ {
+			if i == k || b[i][k] == 0 {
+				continue
+			}
+			q = b[i][k]
+			for j := 0; j < m; j++ {
+				b[i][j] -= q * b[k][j]
+				self.inv[i][j] -= q * self.inv[k][j]
+			}
+		}
+	}
+	return true
+}
+
+func (self *denseInverse) ftran(a []float64) {
+	copy(self.temp, a)
+	multiplyMatrixVector(self.inv, self.temp, self.m, self.m, a)
+}
+
+func (self *denseInverse) btran(c []float64) {
+	copy(self.temp, c)
+	for j := 0; j < self.m; j++ {
+		sum := 0.0
+		for i := 0; i < self.m; i++ {
+			sum += self.inv[i][j] * self.temp[i]
+		}
+		c[j] = sum
+	}
+}
+
+func (self *denseInverse) update(r int, w []float64) bool {
+	if math.Abs(w[r]) < simplexPivotEpsilon {
+		return false
+	}
+	pivotRow := self.inv[r]
+	q := 1 / w[r]
+	for j := 0; j < self.m; j++ {
+		pivotRow[j] *= q
+	}
+	for i := 0; i < self.m; i++ {
+		if i == r || w[i] == 0 {
+			continue
+		}
+		row := self.inv[i]
+		for j := 0; j < self.m; j++ {
+			row[j] -= w[i] * pivotRow[j]
+		}
+	}
+	return true
+}
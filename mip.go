@@ -0,0 +1,370 @@
+package lp
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	// a value closer than this to an integer counts as integer
+	integerEpsilon = 1e-7
+)
+
+type branchNode struct {
+	lower, upper []float64
+	// objective of the parent relaxation, a lower bound for the node
+	bound float64
+	basis []int
+}
+
+// BranchAndBoundSolver solves linear programs with integer variables (see
+// Variable.SetInteger) by depth-first branch and bound on the LP
+// relaxation. Every node is re-optimized with the dual simplex method from
+// the basis of its parent.
+//
+// Solve returns ResultOptimal if the best integer solution is proven
+// optimal, ResultSubOptimal if the search skipped nodes that could only
+// improve it by less than the MIP gap, ResultInfeasible if there is no
+// integer solution and ResultUnbounded if the relaxation is unbounded. If
+// the node limit stops the search the result is ResultFeasFound when an
+// integer solution was found and ResultNoFeasFound otherwise.
+//
+// The duals, reduced costs and the sensitivity analysis are those of the LP
+// relaxation at the node of the best integer solution.
+type BranchAndBoundSolver struct {
+	ls               *LinearSpec
+	nodeLimit        int
+	relativeGap      float64
+	absoluteGap      float64
+	maxIterations    int
+	nodes            int
+	iterations       int
+	bestBound        float64
+	incumbentValue   float64
+	hasIncumbent     bool
+	prunedWithinGaps bool
+	infeasible       *Constraint
+	tolerances       Tolerances
+	// the LP of the best integer solution
+	incumbentLP *simplex
+	ray         *UnboundedRay
+	// rows and columns removed by the presolve
+	presolvedRows, presolvedColumns int
+}
+
+func NewBranchAndBoundSolver(ls *LinearSpec) *BranchAndBoundSolver {
+	bb := &BranchAndBoundSolver{}
+	bb.ls = ls
+	bb.relativeGap = 1e-9
+	bb.absoluteGap = 1e-11
+
+	return bb
+}
+
+// InfeasibleConstraint gets a constraint that could not be satisfied by the
+// LP relaxation if the last solve was infeasible at the root node.
+func (self *BranchAndBoundSolver) InfeasibleConstraint() *Constraint {
+	return self.infeasible
+}
+
+// Stats gets the statistics of the last solve.
+func (self *BranchAndBoundSolver) Stats() SolveStats {
+	return SolveStats{SimplexIterations: self.iterations, Nodes: self.nodes,
+		PresolvedRows: self.presolvedRows, PresolvedColumns: self.presolvedColumns}
+}
+
+// UnboundedRay gets the direction of unboundedness of the relaxation if
+// the last solve was unbounded, nil otherwise.
+func (self *BranchAndBoundSolver) UnboundedRay() *UnboundedRay {
+	return self.ray
+}
+
+// Sensitivity gets the sensitivity analysis of the LP relaxation at the
+// node of the best integer solution, nil if there is none.
+func (self *BranchAndBoundSolver) Sensitivity() *Sensitivity {
+	if self.incumbentLP == nil {
+		return nil
+	}
+	return self.incumbentLP.sensitivity()
+}
+
+// SetNodeLimit sets the maximum number of nodes to explore; 0 means no
+// limit.
+func (self *BranchAndBoundSolver) SetNodeLimit(nodes int) {
+	self.nodeLimit = nodes
+}
+
+// SetMIPGap sets the relative and absolute gaps between a node bound and
+// the best integer solution below which the node is not explored.
+func (self *BranchAndBoundSolver) SetMIPGap(relative, absolute float64) {
+	self.relativeGap = relative
+	self.absoluteGap = absolute
+}
+
+// SetMaxIterations sets the simplex iteration limit of each node; 0
+// selects a limit based on the size of the problem.
+func (self *BranchAndBoundSolver) SetMaxIterations(iterations int) {
+	self.maxIterations = iterations
+}
+
+// Nodes gets the number of nodes explored by the last solve.
+func (self *BranchAndBoundSolver) Nodes() int {
+	return self.nodes
+}
+
+// Iterations gets the total number of simplex iterations of the last solve.
+func (self *BranchAndBoundSolver) Iterations() int {
+	return self.iterations
+}
+
+// BestBound gets the best bound on the objective value of an integer
+// solution known when the last solve stopped.
+func (self *BranchAndBoundSolver) BestBound() float64 {
+	return self.bestBound
+}
+
+func (self *BranchAndBoundSolver) Solve() int {
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves like Solve but stops with ResultTimeout or
+// ResultUserAbort when ctx is done. The variables get the best integer
+// solution found so far.
+func (self *BranchAndBoundSolver) SolveContext(ctx context.Context) int {
+	self.nodes = 0
+	self.iterations = 0
+	self.hasIncumbent = false
+	self.prunedWithinGaps = false
+	self.incumbentValue = math.Inf(1)
+	self.bestBound = math.Inf(-1)
+	self.incumbentLP = nil
+	self.ray = nil
+	presolved := newPresolve(self.ls)
+	self.infeasible = presolved.infeasible
+	self.presolvedRows = presolved.removedRowCount
+	self.presolvedColumns = presolved.removedColumnCount
+	if presolved.result != ResultOptimal {
+		solved := presolved.result == ResultPresolve
+		presolved.postsolve(solved, solved)
+		if solved {
+			self.bestBound = self.ls.evalObjective()
+		}
+		return presolved.result
+	}
+	model := presolved.model()
+	self.tolerances = model.tolerances
+
+	incumbent := make([]float64, model.columns)
+	stack := []*branchNode{&branchNode{model.lower, model.upper, math.Inf(-1), nil}}
+	limitReached := false
+	interruptResult, interruptedSolve := ResultOptimal, false
+
+	for len(stack) > 0 {
+		if self.nodeLimit > 0 && self.nodes >= self.nodeLimit {
+			limitReached = true
+			break
+		}
+		if interruptResult, interruptedSolve = interrupted(ctx); interruptedSolve {
+			break
+		}
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if self.prune(node.bound) {
+			continue
+		}
+		self.nodes++
+
+		s := newSimplex(model.withBounds(node.lower, node.upper))
+		s.maxIterations = self.maxIterations
+		s.ctx = ctx
+		var result int
+		if node.basis != nil && s.setBasis(node.basis) {
+			result = s.warmSolve()
+		} else {
+			result = s.solve()
+		}
+		self.iterations += s.iterations
+		if self.nodes == 1 {
+			self.infeasible = presolved.original(s.infeasibleConstraint())
+		}
+
+		if result == ResultTimeout || result == ResultUserAbort {
+			// the node stays open
+			stack = append(stack, node)
+			interruptResult, interruptedSolve = result, true
+			break
+		}
+		if result == ResultUnbounded && self.nodes == 1 {
+			if s.feasible {
+				model.setValues(s.x)
+			}
+			model.setDuals(nil, nil)
+			presolved.postsolve(s.feasible, false)
+			self.ray = presolved.postsolveRay(model.unboundedRay(s.ray))
+			return ResultUnbounded
+		}
+		if result != ResultOptimal {
+			// infeasible, or the node could not be solved
+			if result != ResultInfeasible {
+				self.prunedWithinGaps = true
+			}
+			continue
+		}
+
+		value := s.objectiveValue()
+		if self.prune(value) {
+			continue
+		}
+
+		branch := self.branchingColumn(model, s.x)
+		if branch < 0 {
+			self.hasIncumbent = true
+			self.incumbentValue = value
+			copy(incumbent, s.x[:model.columns])
+			self.incumbentLP = s
+			continue
+		}
+
+		// the child closer to the relaxation is explored first
+		xj := s.x[branch]
+		down := &branchNode{node.lower, copyVector(node.upper), value, s.basisStatus()}
+		down.upper[branch] = math.Floor(xj)
+		up := &branchNode{copyVector(node.lower), node.upper, value, s.basisStatus()}
+		up.lower[branch] = math.Ceil(xj)
+		if xj-math.Floor(xj) < 0.5 {
+			stack = append(stack, up, down)
+		} else {
+			stack = append(stack, down, up)
+		}
+	}
+
+	self.bestBound = self.incumbentValue
+	for _, node := range stack {
+		self.bestBound = math.Min(self.bestBound, node.bound)
+	}
+	self.bestBound *= model.sense
+
+	if self.hasIncumbent {
+		for j, v := range model.variables {
+			if v != nil && v.IsInteger() {
+				incumbent[j] = math.Floor(incumbent[j] + 0.5)
+			}
+		}
+		model.setValues(incumbent)
+	}
+	if self.incumbentLP != nil {
+		model.setDuals(self.incumbentLP.y, self.incumbentLP.d)
+	} else {
+		model.setDuals(nil, nil)
+	}
+	presolved.postsolve(self.hasIncumbent, self.incumbentLP != nil)
+
+	switch {
+	case interruptedSolve:
+		return interruptResult
+	case limitReached && self.hasIncumbent:
+		return ResultFeasFound
+	case limitReached:
+		return ResultNoFeasFound
+	case !self.hasIncumbent:
+		return ResultInfeasible
+	case self.prunedWithinGaps:
+		return ResultSubOptimal
+	}
+	return ResultOptimal
+}
+
+// prune returns true if a node with the given bound can not improve the
+// incumbent by more than the MIP gap.
+func (self *BranchAndBoundSolver) prune(bound float64) bool {
+	if !self.hasIncumbent {
+		return false
+	}
+	gap := math.Max(self.absoluteGap, self.relativeGap*math.Abs(self.incumbentValue))
+	if bound < self.incumbentValue-gap {
+		return false
+	}
+	if bound < self.incumbentValue-self.tolerances.DualFeasibility {
+		self.prunedWithinGaps = true
+	}
+	return true
+}
+
+// branchingColumn returns the most fractional integer column of x or -1 if
+// x is integral.
+func (self *BranchAndBoundSolver) branchingColumn(model *lpModel, x []float64) int {
+	branch := -1
+	best := integerEpsilon
+	for j, v := range model.variables {
+		if v == nil || !v.IsInteger() {
+			continue
+		}
+		fraction := math.Abs(x[j] - math.Floor(x[j]+0.5))
+		if fraction > best {
+			best = fraction
+			branch = j
+		}
+	}
+	return branch
+}
+
+func copyVector(x []float64) []float64 {
+	y := make([]float64, len(x))
+	copy(y, x)
+	return y
+}
+
+func (self *BranchAndBoundSolver) VariableAdded(variable *Variable) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) VariableRemoved(variable *Variable) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) VariableRangeChanged(variable *Variable) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) ConstraintAdded(constraint *Constraint) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) ConstraintRemoved(constraint *Constraint) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) LeftSideChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) RightSideChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) OperatorChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *BranchAndBoundSolver) ObjectiveChanged() bool {
+	return true
+}
+
+// SaveModel writes the specification in LP format.
+func (self *BranchAndBoundSolver) SaveModel(fileName string) error {
+	return saveLP(self.ls, fileName)
+}
+
+// MinSize minimizes width and height of the LP relaxation independently
+// subject to the hard constraints. An unbounded dimension is reported as
+// -Inf.
+func (self *BranchAndBoundSolver) MinSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, 1, self.maxIterations)
+}
+
+// MaxSize maximizes width and height of the LP relaxation independently
+// subject to the hard constraints. An unbounded dimension is reported as
+// +Inf.
+func (self *BranchAndBoundSolver) MaxSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, -1, self.maxIterations)
+}
@@ -0,0 +1,103 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func newKnapsackSpec() (*LinearSpec, *BranchAndBoundSolver, []*Variable) {
+	ls := NewLinearSpec()
+	solver := NewBranchAndBoundSolver(ls)
+	ls.SetSolver(solver)
+
+	x := make([]*Variable, 4)
+	for i := range x {
+		x[i], _ = ls.AddVariable(nil)
+		x[i].SetBinary()
+	}
+	ls.AddConstraint2([]float64{3, 4, 2, 3}, x, OperatorLE, 7)
+	ls.SetObjective1([]float64{10, 13, 7, 8}, x, OptMaximize)
+
+	return ls, solver, x
+}
+
+func TestBranchAndBoundKnapsack(t *testing.T) {
+	fmt.Println("Test BranchAndBound Knapsack")
+
+	ls, solver, x := newKnapsackSpec()
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 23)
+	for i, want := range []float64{1, 1, 0, 0} {
+		checkValue(t, x[i].String(), x[i].Value(), want)
+	}
+	checkValue(t, "best bound", solver.BestBound(), 23)
+
+	// the root relaxation is fractional
+	ls, solver, _ = newKnapsackSpec()
+	solver.SetNodeLimit(1)
+	if result, _ := ls.Solve(); result != ResultNoFeasFound {
+		t.Errorf("result = %v, want %v", result, ResultNoFeasFound)
+	}
+
+	ls, solver, _ = newKnapsackSpec()
+	solver.SetNodeLimit(4)
+	if result, _ := ls.Solve(); result != ResultFeasFound {
+		t.Errorf("result = %v, want %v", result, ResultFeasFound)
+	}
+	if solver.BestBound() < ls.ObjectiveValue() {
+		t.Errorf("best bound %v below incumbent %v", solver.BestBound(), ls.ObjectiveValue())
+	}
+}
+
+func TestBranchAndBoundGeneralInteger(t *testing.T) {
+	fmt.Println("Test BranchAndBound GeneralInteger")
+
+	ls := NewLinearSpec()
+	ls.SetSolver(NewBranchAndBoundSolver(ls))
+	x := make([]*Variable, 3)
+	for i := range x {
+		x[i], _ = ls.AddVariable(nil)
+		x[i].SetRange(0, math.Inf(1))
+		x[i].SetInteger(true)
+	}
+	ls.AddConstraint2([]float64{2, 3, 1}, x, OperatorLE, 5)
+	ls.AddConstraint2([]float64{4, 1, 2}, x, OperatorLE, 11)
+	ls.AddConstraint2([]float64{3, 4, 2}, x, OperatorLE, 8)
+	ls.SetObjective1([]float64{5, 4, 3}, x, OptMaximize)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 13)
+}
+
+func TestBranchAndBoundInfeasible(t *testing.T) {
+	fmt.Println("Test BranchAndBound Infeasible")
+
+	ls := NewLinearSpec()
+	ls.SetSolver(NewBranchAndBoundSolver(ls))
+	x, _ := ls.AddVariable(nil)
+	x.SetInteger(true)
+	ls.AddConstraint2([]float64{2}, []*Variable{x}, OperatorEQ, 1)
+
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("result = %v, want %v", result, ResultInfeasible)
+	}
+}
+
+func TestBranchAndBoundGap(t *testing.T) {
+	fmt.Println("Test BranchAndBound Gap")
+
+	ls, solver, _ := newKnapsackSpec()
+	solver.SetMIPGap(0.5, 0)
+	result, _ := ls.Solve()
+	if result != ResultOptimal && result != ResultSubOptimal {
+		t.Fatalf("result = %v, want %v or %v", result, ResultOptimal, ResultSubOptimal)
+	}
+	if ls.ObjectiveValue() < 23*0.5 {
+		t.Errorf("objective = %v, not within the gap", ls.ObjectiveValue())
+	}
+}
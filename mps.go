@@ -0,0 +1,738 @@
+package lp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MPS file formats
+const (
+	MPSFixed = iota
+	MPSFree
+)
+
+const (
+	mpsObjectiveRow = "R0"
+)
+
+type mpsRow struct {
+	name                   string
+	op                     int
+	isObjective            bool
+	names                  []string
+	coeffs                 []float64
+	rightSide              float64
+	hasRange               bool
+	rangeValue             float64
+	penaltyNeg, penaltyPos float64
+}
+
+type mpsReader struct {
+	format  int
+	line    int
+	section string
+
+	rows      []*mpsRow
+	rowIndex  map[string]*mpsRow
+	objective *mpsRow
+	optType   int
+
+	columns       []string
+	lower, upper  map[string]float64
+	explicitLower map[string]bool
+	integers      map[string]bool
+	integerMarker bool
+
+	// name of the first RHS, RANGES and BOUNDS set
+	setNames map[string]string
+}
+
+func (self *mpsReader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %v: %v", self.line, fmt.Sprintf(format, args...))
+}
+
+// fields splits a data line into its fields. Fixed MPS uses the columns
+// 2-3, 5-12, 15-22, 25-36, 40-47 and 50-61, names may contain spaces.
+func (self *mpsReader) fields(line string) []string {
+	if self.format == MPSFree || self.section == "OBJSENSE" {
+		return strings.Fields(line)
+	}
+	fields := []string{}
+	for _, bounds := range [][2]int{{1, 3}, {4, 12}, {14, 22}, {24, 36}, {39, 47}, {49, 61}} {
+		if bounds[0] >= len(line) {
+			break
+		}
+		end := bounds[1]
+		if end > len(line) {
+			end = len(line)
+		}
+		fields = append(fields, strings.TrimSpace(line[bounds[0]:end]))
+	}
+	// drop the empty leading field of sections without a type field
+	if self.section == "COLUMNS" || self.section == "RHS" || self.section == "RANGES" {
+		fields = fields[1:]
+	}
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	return fields
+}
+
+func (self *mpsReader) number(text string) (float64, error) {
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, self.errorf("invalid number %q", text)
+	}
+	if value >= lpInfinity {
+		return math.Inf(1), nil
+	}
+	if value <= -lpInfinity {
+		return math.Inf(-1), nil
+	}
+	return value, nil
+}
+
+func (self *mpsReader) declare(column string) {
+	if _, ok := self.lower[column]; ok {
+		return
+	}
+	self.columns = append(self.columns, column)
+	self.lower[column] = 0
+	self.upper[column] = math.Inf(1)
+	if self.integerMarker {
+		self.integers[column] = true
+	}
+}
+
+// isMPSMarker returns true for the MARKER lines around integer columns.
+func isMPSMarker(fields []string) bool {
+	for _, field := range fields[1:] {
+		if field == "'MARKER'" {
+			return true
+		}
+	}
+	return false
+}
+
+// pairs handles the "row value [row value]" part of COLUMNS, RHS and
+// RANGES lines.
+func (self *mpsReader) pairs(fields []string, apply func(row *mpsRow, value float64)) error {
+	if len(fields) != 2 && len(fields) != 4 {
+		return self.errorf("expected one or two row/value pairs")
+	}
+	for i := 0; i < len(fields); i += 2 {
+		row := self.rowIndex[fields[i]]
+		if row == nil {
+			return self.errorf("unknown row %q", fields[i])
+		}
+		value, err := self.number(fields[i+1])
+		if err != nil {
+			return err
+		}
+		apply(row, value)
+	}
+	return nil
+}
+
+func (self *mpsReader) dataLine(line string) error {
+	fields := self.fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch self.section {
+	case "OBJSENSE":
+		switch strings.ToUpper(fields[0]) {
+		case "MAX", "MAXIMIZE":
+			self.optType = OptMaximize
+		case "MIN", "MINIMIZE":
+			self.optType = OptMinimize
+		default:
+			return self.errorf("unknown objective sense %q", fields[0])
+		}
+
+	case "ROWS":
+		if len(fields) != 2 {
+			return self.errorf("expected row type and name")
+		}
+		if self.rowIndex[fields[1]] != nil {
+			return self.errorf("duplicate row %q", fields[1])
+		}
+		row := &mpsRow{name: fields[1], penaltyNeg: -1, penaltyPos: -1}
+		switch strings.ToUpper(fields[0]) {
+		case "N":
+			// only the first free row is the objective
+			if self.objective != nil {
+				self.rowIndex[fields[1]] = row
+				return nil
+			}
+			row.isObjective = true
+			self.objective = row
+		case "L":
+			row.op = OperatorLE
+		case "G":
+			row.op = OperatorGE
+		case "E":
+			row.op = OperatorEQ
+		default:
+			return self.errorf("unknown row type %q", fields[0])
+		}
+		self.rowIndex[fields[1]] = row
+		if !row.isObjective {
+			self.rows = append(self.rows, row)
+		}
+
+	case "COLUMNS":
+		if isMPSMarker(fields) {
+			switch strings.Trim(fields[len(fields)-1], "'") {
+			case "INTORG":
+				self.integerMarker = true
+			case "INTEND":
+				self.integerMarker = false
+			default:
+				return self.errorf("unknown marker %q", fields[len(fields)-1])
+			}
+			return nil
+		}
+		column := fields[0]
+		self.declare(column)
+		return self.pairs(fields[1:], func(row *mpsRow, value float64) {
+			row.names = append(row.names, column)
+			row.coeffs = append(row.coeffs, value)
+		})
+
+	case "RHS", "RANGES":
+		// the set name is optional in free MPS
+		name := ""
+		if len(fields)%2 == 1 {
+			name = fields[0]
+			fields = fields[1:]
+		}
+		if !self.firstSet(name) {
+			return nil
+		}
+		if self.section == "RHS" {
+			return self.pairs(fields, func(row *mpsRow, value float64) {
+				row.rightSide = value
+			})
+		}
+		return self.pairs(fields, func(row *mpsRow, value float64) {
+			row.hasRange = true
+			row.rangeValue = value
+		})
+
+	case "BOUNDS":
+		return self.bound(fields)
+
+	default:
+		return self.errorf("data outside of a section")
+	}
+	return nil
+}
+
+// firstSet returns true if name is the first set of the current section;
+// the other sets are ignored.
+func (self *mpsReader) firstSet(name string) bool {
+	first, ok := self.setNames[self.section]
+	if !ok {
+		self.setNames[self.section] = name
+		return true
+	}
+	return first == name
+}
+
+func (self *mpsReader) bound(fields []string) error {
+	boundType := strings.ToUpper(fields[0])
+	needsValue := true
+	switch boundType {
+	case "UP", "LO", "FX", "LI", "UI":
+	case "MI", "PL", "BV", "FR":
+		needsValue = false
+	case "SC":
+		return self.errorf("semi-continuous bounds are not supported")
+	default:
+		return self.errorf("unknown bound type %q", fields[0])
+	}
+
+	// the bound set name is optional in free MPS
+	name := ""
+	rest := fields[1:]
+	if self.format == MPSFixed || needsValue && len(rest) == 3 || !needsValue && len(rest) >= 2 {
+		if len(rest) < 2 {
+			return self.errorf("missing bound column")
+		}
+		name = rest[0]
+		rest = rest[1:]
+	}
+	if !self.firstSet(name) {
+		return nil
+	}
+	if len(rest) == 0 {
+		return self.errorf("missing bound column")
+	}
+	column := rest[0]
+	if _, ok := self.lower[column]; !ok {
+		return self.errorf("unknown column %q", column)
+	}
+
+	value := 0.0
+	if needsValue {
+		if len(rest) < 2 {
+			return self.errorf("missing bound value")
+		}
+		var err error
+		if value, err = self.number(rest[1]); err != nil {
+			return err
+		}
+	}
+
+	switch boundType {
+	case "UP", "UI":
+		self.upper[column] = value
+		// a negative upper bound makes an implicit lower bound of 0 -inf
+		if value < 0 && self.lower[column] == 0 && !self.explicitLower[column] {
+			self.lower[column] = math.Inf(-1)
+		}
+	case "LO", "LI":
+		self.lower[column] = value
+		self.explicitLower[column] = true
+	case "FX":
+		self.lower[column] = value
+		self.upper[column] = value
+		self.explicitLower[column] = true
+	case "MI":
+		self.lower[column] = math.Inf(-1)
+		self.explicitLower[column] = true
+	case "PL":
+		self.upper[column] = math.Inf(1)
+	case "BV":
+		self.lower[column] = 0
+		self.upper[column] = 1
+		self.explicitLower[column] = true
+	case "FR":
+		self.lower[column] = math.Inf(-1)
+		self.upper[column] = math.Inf(1)
+		self.explicitLower[column] = true
+	}
+	if boundType == "BV" || boundType == "LI" || boundType == "UI" {
+		self.integers[column] = true
+	}
+	return nil
+}
+
+// comment handles the "* penalties <row> <neg> <pos>" comments written for
+// soft constraints.
+func (self *mpsReader) comment(line string) {
+	fields := strings.Fields(line[1:])
+	if len(fields) != 4 || fields[0] != "penalties" {
+		return
+	}
+	row := self.rowIndex[fields[1]]
+	if row == nil {
+		return
+	}
+	neg, err1 := strconv.ParseFloat(fields[2], 64)
+	pos, err2 := strconv.ParseFloat(fields[3], 64)
+	if err1 == nil && err2 == nil {
+		row.penaltyNeg = neg
+		row.penaltyPos = pos
+	}
+}
+
+// ReadMPS reads a model in fixed or free MPS format and adds its variables,
+// constraints and objective function to the specification. Variables and
+// constraints are labeled with their column and row names. Ranged rows
+// become a pair of constraints with the same label.
+func (self *LinearSpec) ReadMPS(r io.Reader, format int) error {
+	reader := &mpsReader{}
+	reader.format = format
+	reader.rowIndex = make(map[string]*mpsRow)
+	reader.lower = make(map[string]float64)
+	reader.upper = make(map[string]float64)
+	reader.explicitLower = make(map[string]bool)
+	reader.integers = make(map[string]bool)
+	reader.setNames = make(map[string]string)
+	reader.optType = OptMinimize
+
+	scanner := bufio.NewScanner(r)
+	ended := false
+	for scanner.Scan() {
+		reader.line++
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" {
+			continue
+		}
+		if line[0] == '*' {
+			reader.comment(line)
+			continue
+		}
+		if ended {
+			return reader.errorf("data after ENDATA")
+		}
+
+		// section headers start in the first column
+		if line[0] != ' ' && line[0] != '\t' {
+			fields := strings.Fields(line)
+			reader.section = strings.ToUpper(fields[0])
+			switch reader.section {
+			case "NAME", "ROWS", "COLUMNS", "RHS", "RANGES", "BOUNDS":
+			case "OBJSENSE":
+				// free MPS allows the sense on the same line
+				if len(fields) > 1 {
+					if err := reader.dataLine(" " + strings.Join(fields[1:], " ")); err != nil {
+						return err
+					}
+				}
+			case "ENDATA":
+				ended = true
+			default:
+				return reader.errorf("unknown section %q", fields[0])
+			}
+			continue
+		}
+		if err := reader.dataLine(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !ended {
+		return reader.errorf("missing ENDATA")
+	}
+
+	variables := make(map[string]*Variable)
+	for _, name := range reader.columns {
+		v, err := self.AddVariable(nil)
+		if err != nil {
+			return err
+		}
+		v.SetLabel(name)
+		v.SetInteger(reader.integers[name])
+		if err := v.SetRange(reader.lower[name], reader.upper[name]); err != nil {
+			return err
+		}
+		variables[name] = v
+	}
+
+	for _, row := range reader.rows {
+		vars := make([]*Variable, len(row.names))
+		for i, name := range row.names {
+			vars[i] = variables[name]
+		}
+
+		op, rightSide := row.op, row.rightSide
+		rangeOp, rangeRightSide := -1, 0.0
+		if row.hasRange {
+			width := math.Abs(row.rangeValue)
+			switch {
+			case row.op == OperatorLE:
+				rangeOp, rangeRightSide = OperatorGE, rightSide-width
+			case row.op == OperatorGE:
+				rangeOp, rangeRightSide = OperatorLE, rightSide+width
+			case row.rangeValue > 0:
+				op, rangeOp, rangeRightSide = OperatorGE, OperatorLE, rightSide+width
+			case row.rangeValue < 0:
+				op, rangeOp, rangeRightSide = OperatorLE, OperatorGE, rightSide-width
+			}
+		}
+
+		c, err := self.AddConstraint4(row.coeffs, vars, op, rightSide, row.penaltyNeg, row.penaltyPos)
+		if err != nil {
+			return err
+		}
+		c.SetLabel(row.name)
+		if rangeOp >= 0 {
+			c, err = self.AddConstraint2(row.coeffs, vars, rangeOp, rangeRightSide)
+			if err != nil {
+				return err
+			}
+			c.SetLabel(row.name)
+		}
+	}
+
+	summands := newSummandList()
+	if reader.objective != nil {
+		for i, name := range reader.objective.names {
+			if reader.objective.coeffs[i] != 0 {
+				summands.AddItem(NewSummand(reader.objective.coeffs[i], variables[name]))
+			}
+		}
+	}
+	if summands.Len() > 0 {
+		if err := self.SetObjective(summands, reader.optType); err != nil {
+			return err
+		}
+	} else {
+		self.optType = reader.optType
+	}
+	return nil
+}
+
+// mpsNames returns unique row or column names: the labels where they are
+// usable in the format, otherwise prefix<n> with the 1-based index.
+func mpsNames(labels []string, prefix string, format int, reserved string) []string {
+	names := make([]string, len(labels))
+	used := map[string]bool{reserved: true}
+	valid := func(label string) bool {
+		if label == "" || strings.ContainsAny(label, " \t") || label[0] == '*' {
+			return false
+		}
+		return format == MPSFree || len(label) <= 8
+	}
+	for i, label := range labels {
+		if valid(label) && !used[label] {
+			names[i] = label
+			used[label] = true
+		}
+	}
+	for i := range labels {
+		if names[i] != "" {
+			continue
+		}
+		name := prefix + strconv.Itoa(i+1)
+		for n := 2; used[name]; n++ {
+			name = prefix + strconv.Itoa(i+1) + "_" + strconv.Itoa(n)
+		}
+		names[i] = name
+		used[name] = true
+	}
+	return names
+}
+
+// mpsNumber formats value to fit into the 12 character number fields of
+// fixed MPS.
+func mpsNumber(value float64) string {
+	if math.IsInf(value, 1) {
+		return "1e30"
+	}
+	if math.IsInf(value, -1) {
+		return "-1e30"
+	}
+	text := strconv.FormatFloat(value, 'g', -1, 64)
+	for precision := 12; len(text) > 12 && precision > 0; precision-- {
+		text = strconv.FormatFloat(value, 'g', precision, 64)
+	}
+	return text
+}
+
+type mpsWriter struct {
+	out    *bufio.Writer
+	format int
+}
+
+// number formats value for the format, in full precision for free MPS.
+func (self *mpsWriter) number(value float64) string {
+	if self.format == MPSFree && !math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+	return mpsNumber(value)
+}
+
+func (self *mpsWriter) line(field1, field2, field3 string, field4 float64, pairs ...interface{}) {
+	if self.format == MPSFree {
+		fields := []string{}
+		for _, field := range []string{field1, field2, field3, self.number(field4)} {
+			if field != "" {
+				fields = append(fields, field)
+			}
+		}
+		if len(pairs) == 2 {
+			fields = append(fields, pairs[0].(string), self.number(pairs[1].(float64)))
+		}
+		fmt.Fprintf(self.out, " %v\n", strings.Join(fields, " "))
+		return
+	}
+
+	text := fmt.Sprintf(" %-2s %-8s  %-8s  %12s", field1, field2, field3, mpsNumber(field4))
+	if len(pairs) == 2 {
+		text += fmt.Sprintf("   %-8s  %12s", pairs[0], mpsNumber(pairs[1].(float64)))
+	}
+	fmt.Fprintf(self.out, "%v\n", text)
+}
+
+// WriteMPS writes the specification in fixed or free MPS format. Soft
+// constraints are written as hard rows with a "* penalties row neg pos"
+// comment, which ReadMPS understands. The slack variables and rows that
+// the solver adds to soft inequalities are left out. In fixed MPS labels
+// that are longer than 8 characters are replaced by generated names. A
+// quadratic objective gives ErrQuadratic.
+func (self *LinearSpec) WriteMPS(w io.Writer, format int) error {
+	if self.quadratic {
+		return ErrQuadratic
+	}
+	out := bufio.NewWriter(w)
+	writer := &mpsWriter{out, format}
+	variables, constraints, slacks := self.writtenModel()
+
+	labels := make([]string, variables.Len())
+	for i := range labels {
+		labels[i] = variables.GetAt(i).Label()
+	}
+	columnNames := mpsNames(labels, "C", format, mpsObjectiveRow)
+	labels = make([]string, constraints.Len())
+	for i := range labels {
+		labels[i] = constraints.GetAt(i).Label()
+	}
+	rowNames := mpsNames(labels, "R", format, mpsObjectiveRow)
+
+	fmt.Fprintf(out, "NAME\n")
+	if self.optType == OptMaximize {
+		fmt.Fprintf(out, "OBJSENSE\n    MAX\n")
+	}
+
+	fmt.Fprintf(out, "ROWS\n")
+	fmt.Fprintf(out, " N  %v\n", mpsObjectiveRow)
+	for i := 0; i < constraints.Len(); i++ {
+		c := constraints.GetAt(i)
+		switch c.Op() {
+		case OperatorLE:
+			fmt.Fprintf(out, " L  %v\n", rowNames[i])
+		case OperatorGE:
+			fmt.Fprintf(out, " G  %v\n", rowNames[i])
+		default:
+			fmt.Fprintf(out, " E  %v\n", rowNames[i])
+		}
+	}
+
+	// collect the columns
+	type entry struct {
+		row   string
+		value float64
+	}
+	columnEntries := make(map[*Variable][]entry)
+	for i := 0; i < self.objective.Len(); i++ {
+		s := self.objective.GetAt(i)
+		columnEntries[s.Var()] = append(columnEntries[s.Var()], entry{mpsObjectiveRow, s.Coeff()})
+	}
+	for i := 0; i < constraints.Len(); i++ {
+		leftSide := writtenLeftSide(constraints.GetAt(i), slacks)
+		for j := 0; j < leftSide.Len(); j++ {
+			s := leftSide.GetAt(j)
+			columnEntries[s.Var()] = append(columnEntries[s.Var()], entry{rowNames[i], s.Coeff()})
+		}
+	}
+
+	fmt.Fprintf(out, "COLUMNS\n")
+	integerMarker := false
+	markers := 0
+	for i := 0; i < variables.Len(); i++ {
+		v := variables.GetAt(i)
+		if v.IsInteger() != integerMarker {
+			integerMarker = v.IsInteger()
+			marker := "'INTEND'"
+			if integerMarker {
+				marker = "'INTORG'"
+			}
+			if format == MPSFree {
+				fmt.Fprintf(out, " MARKER%v 'MARKER' %v\n", markers, marker)
+			} else {
+				fmt.Fprintf(out, "    %-8s  %-8s                 %-8s\n",
+					"MARKER"+strconv.Itoa(markers), "'MARKER'", marker)
+			}
+			markers++
+		}
+
+		entries := columnEntries[v]
+		// a column without coefficients is declared in the objective
+		if len(entries) == 0 {
+			entries = []entry{{mpsObjectiveRow, 0}}
+		}
+		for k := 0; k < len(entries); k += 2 {
+			if k+1 < len(entries) {
+				writer.line("", columnNames[i], entries[k].row, entries[k].value,
+					entries[k+1].row, entries[k+1].value)
+			} else {
+				writer.line("", columnNames[i], entries[k].row, entries[k].value)
+			}
+		}
+	}
+	if integerMarker {
+		if format == MPSFree {
+			fmt.Fprintf(out, " MARKER%v 'MARKER' 'INTEND'\n", markers)
+		} else {
+			fmt.Fprintf(out, "    %-8s  %-8s                 %-8s\n",
+				"MARKER"+strconv.Itoa(markers), "'MARKER'", "'INTEND'")
+		}
+	}
+
+	fmt.Fprintf(out, "RHS\n")
+	for i := 0; i < constraints.Len(); i++ {
+		if rightSide := constraints.GetAt(i).RightSide(); rightSide != 0 {
+			writer.line("", "RHS", rowNames[i], rightSide)
+		}
+	}
+
+	boundsHeader := false
+	bound := func(boundType string, column string, value float64, hasValue bool) {
+		if !boundsHeader {
+			fmt.Fprintf(out, "BOUNDS\n")
+			boundsHeader = true
+		}
+		if hasValue {
+			writer.line(boundType, "BND", column, value)
+		} else if format == MPSFree {
+			fmt.Fprintf(out, " %v BND %v\n", boundType, column)
+		} else {
+			fmt.Fprintf(out, " %-2s %-8s  %v\n", boundType, "BND", column)
+		}
+	}
+	for i := 0; i < variables.Len(); i++ {
+		v := variables.GetAt(i)
+		lower, upper := v.Min(), v.Max()
+		switch {
+		case lower == upper:
+			bound("FX", columnNames[i], lower, true)
+		case math.IsInf(lower, -1) && math.IsInf(upper, 1):
+			bound("FR", columnNames[i], 0, false)
+		default:
+			if math.IsInf(lower, -1) {
+				bound("MI", columnNames[i], 0, false)
+			} else if lower != 0 || upper < 0 {
+				bound("LO", columnNames[i], lower, true)
+			}
+			if !math.IsInf(upper, 1) {
+				bound("UP", columnNames[i], upper, true)
+			}
+		}
+	}
+
+	for i := 0; i < constraints.Len(); i++ {
+		c := constraints.GetAt(i)
+		if c.PenaltyNeg() > 0 || c.PenaltyPos() > 0 {
+			fmt.Fprintf(out, "* penalties %v %v %v\n", rowNames[i], writer.number(c.PenaltyNeg()),
+				writer.number(c.PenaltyPos()))
+		}
+	}
+	fmt.Fprintf(out, "ENDATA\n")
+	return out.Flush()
+}
+
+// LoadMPS reads a specification in fixed or free MPS format from a file and
+// adds it to this specification.
+func (self *LinearSpec) LoadMPS(filename string, format int) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return self.ReadMPS(file, format)
+}
+
+// SaveMPS writes the specification in fixed or free MPS format to a file.
+func (self *LinearSpec) SaveMPS(filename string, format int) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := self.WriteMPS(file, format); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,297 @@
+package lp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadMPSFixed(t *testing.T) {
+	fmt.Println("Test ReadMPS Fixed")
+
+	model := `NAME          TESTLP
+* the example of the lp_solve MPS documentation
+ROWS
+ N  COST
+ L  LIM1
+ G  LIM2
+ E  MYEQN
+COLUMNS
+    X1        COST               1.0   LIM1               1.0
+    X1        LIM2               1.0
+    X2        COST               2.0   LIM1               1.0
+    X2        MYEQN             -1.0
+    X3        COST              -1.0   MYEQN              1.0
+    X4        COST               1.0   LIM1               1.0
+    X4        LIM2               1.0
+RHS
+    RHS       LIM1               4.0   LIM2               1.0
+    RHS       MYEQN              7.0
+RANGES
+    RNG       LIM1               2.5
+BOUNDS
+ UP BND       X1                 4.0
+ LO BND       X2                -1.0
+ UP BND       X2                 1.0
+ MI BND       X4
+ENDATA
+`
+	ls := newSimplexSpec()
+	if err := ls.ReadMPS(strings.NewReader(model), MPSFixed); err != nil {
+		t.Fatal(err)
+	}
+	if ls.AllVariables().Len() != 4 || ls.Constraints().Len() != 4 {
+		t.Fatalf("read %v variables and %v constraints", ls.AllVariables().Len(),
+			ls.Constraints().Len())
+	}
+
+	// the range of LIM1 is a second row with the same label
+	ranged := ls.Constraints().GetAt(1)
+	if ranged.Label() != "LIM1" || ranged.Op() != OperatorGE || ranged.RightSide() != 1.5 {
+		t.Errorf("range row = %v %v %v", ranged.Label(), ranged.Op(), ranged.RightSide())
+	}
+
+	x4 := ls.AllVariables().GetAt(3)
+	if x4.Label() != "X4" || !math.IsInf(x4.Min(), -1) || !math.IsInf(x4.Max(), 1) {
+		t.Errorf("X4 = %v [%v, %v]", x4.Label(), x4.Min(), x4.Max())
+	}
+
+	// with x3 = 7 + x2 the objective is x1 + x2 + x4 - 7, bounded by the
+	// range of LIM1
+	x4.SetRange(-10, math.Inf(1))
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 1.5-7)
+}
+
+func TestReadMPSFree(t *testing.T) {
+	fmt.Println("Test ReadMPS Free")
+
+	model := `NAME knapsack
+OBJSENSE MAX
+ROWS
+ N obj
+ L weight
+ E fixed
+COLUMNS
+ MARKER 'MARKER' 'INTORG'
+ a obj 5 weight 4
+ b obj 4 weight 3
+ MARKER 'MARKER' 'INTEND'
+ c obj 3 weight 2
+ d fixed 1
+RHS
+ weight 6
+ fixed 2
+BOUNDS
+ UP a 1
+ BV b
+ UP c -2
+ LO d 0
+ UP d 5
+ENDATA
+`
+	ls := NewLinearSpec()
+	ls.SetSolver(NewBranchAndBoundSolver(ls))
+	err := ls.ReadMPS(strings.NewReader(model), MPSFree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ls.OptimizationType() != OptMaximize {
+		t.Errorf("optimization type = %v, want %v", ls.OptimizationType(), OptMaximize)
+	}
+
+	variables := make(map[string]*Variable)
+	for i := 0; i < ls.AllVariables().Len(); i++ {
+		v := ls.AllVariables().GetAt(i)
+		variables[v.Label()] = v
+	}
+	for _, test := range []struct {
+		name      string
+		min, max  float64
+		isInteger bool
+	}{
+		{"a", 0, 1, true},
+		{"b", 0, 1, true},
+		{"c", math.Inf(-1), -2, false},
+		{"d", 0, 5, false},
+	} {
+		v := variables[test.name]
+		if v == nil {
+			t.Errorf("variable %v missing", test.name)
+			continue
+		}
+		if v.Min() != test.min || v.Max() != test.max || v.IsInteger() != test.isInteger {
+			t.Errorf("%v = [%v, %v] integer %v, want [%v, %v] integer %v", test.name,
+				v.Min(), v.Max(), v.IsInteger(), test.min, test.max, test.isInteger)
+		}
+	}
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), 5+4+3*-2)
+}
+
+func TestReadMPSErrors(t *testing.T) {
+	fmt.Println("Test ReadMPS Errors")
+
+	for _, model := range []string{
+		"ROWS\n N obj\n X r\nENDATA\n",
+		"ROWS\n N obj\nCOLUMNS\n x unknown 1\nENDATA\n",
+		"ROWS\n N obj\nCOLUMNS\n x obj 1\nBOUNDS\n SC BND x 1\nENDATA\n",
+		"ROWS\n N obj\nCOLUMNS\n x obj one\nENDATA\n",
+		"ROWS\n N obj\nCOLUMNS\n x obj 1\n",
+		"SECTION\nENDATA\n",
+	} {
+		ls := newSimplexSpec()
+		if err := ls.ReadMPS(strings.NewReader(model), MPSFree); err == nil {
+			t.Errorf("no error for %q", model)
+		}
+	}
+
+	// an explicit lower bound above the upper bound
+	model := "ROWS\n N obj\nCOLUMNS\n x obj 1\nBOUNDS\n LO BND x 5\n UP BND x 1\nENDATA\n"
+	ls := newSimplexSpec()
+	if err := ls.ReadMPS(strings.NewReader(model), MPSFree); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("contradictory bounds: %v", err)
+	}
+}
+
+func TestWriteMPS(t *testing.T) {
+	fmt.Println("Test WriteMPS")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	x.SetLabel("x")
+	y, _ := ls.AddVariable(nil)
+	y.SetLabel("a long name")
+	z, _ := ls.AddVariable(nil)
+	z.SetRange(math.Inf(-1), 3)
+	z.SetInteger(true)
+	w, _ := ls.AddVariable(nil)
+	w.SetRange(-2, -2)
+	free, _ := ls.AddVariable(nil)
+	free.SetRange(math.Inf(-1), math.Inf(1))
+
+	c, _ := ls.AddConstraint2([]float64{1, 2, 1}, []*Variable{x, y, z}, OperatorLE, 10)
+	c.SetLabel("capacity")
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{x, w}, OperatorGE, 0.1)
+	ls.AddConstraint4([]float64{1}, []*Variable{y}, OperatorEQ, 2, 3, 4)
+	ls.SetObjective1([]float64{3, 2, 1}, []*Variable{x, y, z}, OptMaximize)
+
+	for _, format := range []int{MPSFixed, MPSFree} {
+		var first bytes.Buffer
+		if err := ls.WriteMPS(&first, format); err != nil {
+			t.Fatal(err)
+		}
+
+		read := newSimplexSpec()
+		if err := read.ReadMPS(bytes.NewReader(first.Bytes()), format); err != nil {
+			t.Fatalf("%v\n%v", err, first.String())
+		}
+		if read.AllVariables().Len() != 5 || read.Constraints().Len() != 3 {
+			t.Fatalf("read %v variables and %v constraints", read.AllVariables().Len(),
+				read.Constraints().Len())
+		}
+		if read.OptimizationType() != OptMaximize || !read.AllVariables().GetAt(2).IsInteger() {
+			t.Errorf("objective direction or integer lost:\n%v", first.String())
+		}
+		soft := read.Constraints().GetAt(2)
+		if soft.PenaltyNeg() != 3 || soft.PenaltyPos() != 4 {
+			t.Errorf("penalties = %v %v, want 3 4", soft.PenaltyNeg(), soft.PenaltyPos())
+		}
+
+		var second bytes.Buffer
+		if err := read.WriteMPS(&second, format); err != nil {
+			t.Fatal(err)
+		}
+		if first.String() != second.String() {
+			t.Errorf("round trip differs:\n%v\n%v", first.String(), second.String())
+		}
+	}
+}
+
+func TestWriteMPSPrecision(t *testing.T) {
+	fmt.Println("Test WriteMPS Precision")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{0.12345678901234567, 1}, []*Variable{x, y}, OperatorLE, 3.14159265358979)
+	ls.AddConstraint2([]float64{1, 3.14159265358979}, []*Variable{x, y}, OperatorGE, 0.12345678901234567)
+	ls.SetObjective1([]float64{3.14159265358979, 0.12345678901234567}, []*Variable{x, y}, OptMaximize)
+
+	var out bytes.Buffer
+	if err := ls.WriteMPS(&out, MPSFree); err != nil {
+		t.Fatal(err)
+	}
+	read := newSimplexSpec()
+	if err := read.ReadMPS(bytes.NewReader(out.Bytes()), MPSFree); err != nil {
+		t.Fatalf("%v\n%v", err, out.String())
+	}
+	for i := 0; i < 2; i++ {
+		want := ls.Constraints().GetAt(i)
+		got := read.Constraints().GetAt(i)
+		if got.RightSide() != want.RightSide() {
+			t.Errorf("right side %v = %v, want %v", i, got.RightSide(), want.RightSide())
+		}
+		for k := 0; k < want.LeftSide().Len(); k++ {
+			coeff, wantCoeff := got.LeftSide().GetAt(k).Coeff(), want.LeftSide().GetAt(k).Coeff()
+			if coeff != wantCoeff {
+				t.Errorf("coefficient %v,%v = %v, want %v", i, k, coeff, wantCoeff)
+			}
+		}
+	}
+	for k := 0; k < ls.Objective().Len(); k++ {
+		coeff, want := read.Objective().GetAt(k).Coeff(), ls.Objective().GetAt(k).Coeff()
+		if coeff != want {
+			t.Errorf("objective coefficient %v = %v, want %v", k, coeff, want)
+		}
+	}
+}
+
+func TestWriteMPSSoftInequality(t *testing.T) {
+	fmt.Println("Test WriteMPS SoftInequality")
+
+	// the active set solver adds a slack and a penalized row to the soft
+	// inequality, neither is written
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorEQ, 10)
+	ls.AddConstraint4([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 5, 0, 3)
+
+	for _, format := range []int{MPSFixed, MPSFree} {
+		var first bytes.Buffer
+		if err := ls.WriteMPS(&first, format); err != nil {
+			t.Fatal(err)
+		}
+		read := NewLinearSpec()
+		if err := read.ReadMPS(bytes.NewReader(first.Bytes()), format); err != nil {
+			t.Fatalf("%v\n%v", err, first.String())
+		}
+		if read.AllVariables().Len() != ls.AllVariables().Len() ||
+			read.Constraints().Len() != ls.Constraints().Len() {
+			t.Fatalf("read %v variables and %v constraints, want %v and %v\n%v",
+				read.AllVariables().Len(), read.Constraints().Len(),
+				ls.AllVariables().Len(), ls.Constraints().Len(), first.String())
+		}
+		soft := read.Constraints().GetAt(1)
+		if soft.PenaltyNeg() != 0 || soft.PenaltyPos() != 3 || soft.Op() != OperatorLE {
+			t.Errorf("soft inequality read as %v", soft)
+		}
+
+		var second bytes.Buffer
+		if err := read.WriteMPS(&second, format); err != nil {
+			t.Fatal(err)
+		}
+		if first.String() != second.String() {
+			t.Errorf("round trip differs:\n%v\n%v", first.String(), second.String())
+		}
+	}
+}
@@ -0,0 +1,685 @@
+package lp
+
+import "math"
+
+// presolveMaxFill is the largest number of entries the substitution of a
+// variable may add to the other rows.
+const presolveMaxFill = 8
+
+// presolveStability is the least ratio of the coefficient of a substituted
+// variable to the largest coefficient of its equation.
+const presolveStability = 0.01
+
+// kinds of presolve steps
+const (
+	// a column fixed at its bounds
+	presolveFixed = iota
+	// a row with a single entry that became a bound of its column
+	presolveSingleton
+	// a column replaced by the other columns of an equation
+	presolveSubstitution
+)
+
+// presolveStep records a reduction for the postsolve.
+type presolveStep struct {
+	kind        int
+	row, column int
+	// the value of a fixed column, the coefficient of the column in the row
+	// otherwise
+	value float64
+	// cost and entries in the other rows of the removed column
+	cost    float64
+	entries sparseVector
+	// the equation of a substitution
+	equation  sparseVector
+	rightSide float64
+	// the bounds the step tightened, NaN if it did not: of the column of a
+	// singleton row, of the other column of a substituted equation with
+	// two entries
+	lower, upper float64
+	other        int
+}
+
+// presolve reduces a LinearSpec before a solve. It fixes the variables with
+// equal bounds, turns rows with a single entry into bounds, drops
+// inequalities that hold within the bounds and substitutes variables that
+// an equation defines. Only hard rows are reduced; soft rows just lose the
+// removed variables. The variables of a quadratic objective are kept.
+//
+// Bounds implied by the rows only decide whether a variable is free; the
+// reduced problem keeps the declared ones, so its duals stay those of the
+// rows. The postsolve recovers the values of the removed variables and the
+// duals of the removed rows.
+type presolve struct {
+	ls         *LinearSpec
+	active     bool
+	tolerances Tolerances
+	// 1 for minimization, -1 for maximization
+	sense float64
+
+	variables          []*Variable
+	columnOf           map[*Variable]int
+	lower, upper, cost []float64
+	integer            []bool
+	removedColumns     []bool
+	// columns whose bounds made a substituted variable free
+	locked []bool
+	// columns of a quadratic objective, which are neither fixed nor
+	// substituted
+	quadratic []bool
+	// rows with an entry in each column, including stale ones
+	columns [][]int
+	// constant of the objective
+	offset float64
+
+	originals   []*Constraint
+	rows        []sparseVector
+	b           []float64
+	ops         []int
+	soft        []bool
+	removedRows []bool
+	changed     []bool
+	mark        []int
+	steps       []presolveStep
+
+	// ResultInfeasible if a row can not be satisfied, ResultPresolve if no
+	// row and column is left and ResultOptimal otherwise
+	result     int
+	infeasible *Constraint
+	// the rows of the reduced problem: the constraint of the row or a
+	// detached copy if the row changed
+	constraints *ConstraintList
+	kept        []*Constraint
+	rowOf       map[*Constraint]int
+
+	removedRowCount, removedColumnCount int
+}
+
+// newPresolve presolves ls if presolving is enabled. Otherwise the reduced
+// problem is ls itself.
+func newPresolve(ls *LinearSpec) *presolve {
+	p := &presolve{}
+	p.ls = ls
+	p.result = ResultOptimal
+	if !ls.Presolve() {
+		p.constraints = ls.Constraints()
+		return p
+	}
+	p.active = true
+	p.tolerances = ls.Tolerances()
+	p.sense = 1
+	if ls.OptimizationType() == OptMaximize {
+		p.sense = -1
+	}
+
+	p.load()
+	p.reduce()
+	p.build()
+	return p
+}
+
+func (self *presolve) load() {
+	allVariables := self.ls.AllVariables()
+	n := allVariables.Len()
+	self.columnOf = make(map[*Variable]int, n)
+	for j := 0; j < n; j++ {
+		v := allVariables.GetAt(j)
+		self.variables = append(self.variables, v)
+		self.columnOf[v] = j
+		self.lower = append(self.lower, v.Min())
+		self.upper = append(self.upper, v.Max())
+		self.integer = append(self.integer, v.IsInteger())
+	}
+	self.cost = make([]float64, n)
+	self.removedColumns = make([]bool, n)
+	self.locked = make([]bool, n)
+	self.quadratic = make([]bool, n)
+	self.columns = make([][]int, n)
+
+	objective := self.ls.Objective()
+	for i := 0; i < objective.Len(); i++ {
+		s := objective.GetAt(i)
+		self.cost[self.columnOf[s.Var()]] += s.Coeff()
+	}
+	if self.ls.HasQuadraticObjective() {
+		left, right := self.ls.QuadraticObjective()
+		for _, summands := range []*SummandList{objective, left, right} {
+			for i := 0; i < summands.Len(); i++ {
+				self.quadratic[self.columnOf[summands.GetAt(i).Var()]] = true
+			}
+		}
+	}
+
+	constraints := self.ls.Constraints()
+	m := constraints.Len()
+	self.rows = make([]sparseVector, m)
+	for r := 0; r < m; r++ {
+		constraint := constraints.GetAt(r)
+		self.originals = append(self.originals, constraint)
+		self.b = append(self.b, constraint.RightSide())
+		self.ops = append(self.ops, constraint.Op())
+		self.soft = append(self.soft, constraint.IsSoft())
+		leftSide := constraint.LeftSide()
+		for s := 0; s < leftSide.Len(); s++ {
+			summand := leftSide.GetAt(s)
+			*self.rows[r].ref(self.columnOf[summand.Var()]) += summand.Coeff()
+		}
+		row := &self.rows[r]
+		for k := 0; k < len(row.index); k++ {
+			if row.value[k] == 0 {
+				row.remove(row.index[k])
+				k--
+				continue
+			}
+			self.columns[row.index[k]] = append(self.columns[row.index[k]], r)
+		}
+	}
+	self.removedRows = make([]bool, m)
+	self.changed = make([]bool, m)
+	self.mark = make([]int, m)
+	for r := range self.mark {
+		self.mark[r] = -1
+	}
+}
+
+// reduce applies the reductions until none applies any more.
+func (self *presolve) reduce() {
+	for changed := true; changed; {
+		changed = false
+		for j := range self.variables {
+			if !self.removedColumns[j] && !self.quadratic[j] &&
+				self.lower[j] == self.upper[j] && !math.IsInf(self.lower[j], 0) {
+				self.fixColumn(j)
+				changed = true
+			}
+		}
+		for r := range self.rows {
+			if self.removedRows[r] || self.soft[r] {
+				continue
+			}
+			if self.reduceRow(r) {
+				changed = true
+			}
+			if self.result == ResultInfeasible {
+				return
+			}
+		}
+		for r := range self.rows {
+			if self.removedRows[r] || self.soft[r] || self.ops[r] != OperatorEQ {
+				continue
+			}
+			if self.substitute(r) {
+				changed = true
+			}
+			if self.result == ResultInfeasible {
+				return
+			}
+		}
+	}
+}
+
+// column returns the rows that are left with an entry in column j.
+func (self *presolve) column(j int) []int {
+	rows := self.columns[j][:0]
+	for _, r := range self.columns[j] {
+		if self.removedRows[r] || self.mark[r] == j || self.rows[r].at(j) == 0 {
+			continue
+		}
+		self.mark[r] = j
+		rows = append(rows, r)
+	}
+	for _, r := range rows {
+		self.mark[r] = -1
+	}
+	self.columns[j] = rows
+	return rows
+}
+
+// removeColumn removes column j from its rows and returns its entries.
+func (self *presolve) removeColumn(j int) sparseVector {
+	var entries sparseVector
+	for _, r := range self.column(j) {
+		entries.index = append(entries.index, r)
+		entries.value = append(entries.value, self.rows[r].at(j))
+		self.rows[r].remove(j)
+		self.changed[r] = true
+	}
+	self.columns[j] = nil
+	self.removedColumns[j] = true
+	self.removedColumnCount++
+	return entries
+}
+
+func (self *presolve) fixColumn(j int) {
+	value := self.lower[j]
+	step := presolveStep{kind: presolveFixed, column: j, value: value, cost: self.cost[j]}
+	step.entries = self.removeColumn(j)
+	for k, r := range step.entries.index {
+		self.b[r] -= step.entries.value[k] * value
+	}
+	self.offset += self.cost[j] * value
+	self.steps = append(self.steps, step)
+}
+
+func (self *presolve) removeRow(r int) {
+	self.removedRows[r] = true
+	self.removedRowCount++
+}
+
+func (self *presolve) fail(r int) {
+	self.result = ResultInfeasible
+	self.infeasible = self.originals[r]
+}
+
+// activity returns the least and the largest value of the left side of row
+// r within the bounds.
+func (self *presolve) activity(r int) (float64, float64) {
+	least, largest := 0.0, 0.0
+	row := &self.rows[r]
+	for k, j := range row.index {
+		if a := row.value[k]; a > 0 {
+			least += a * self.lower[j]
+			largest += a * self.upper[j]
+		} else {
+			least += a * self.upper[j]
+			largest += a * self.lower[j]
+		}
+	}
+	return least, largest
+}
+
+// reduceRow removes row r if it is empty, has a single entry or holds
+// within the bounds. It returns whether it removed the row.
+func (self *presolve) reduceRow(r int) bool {
+	tolerance := self.tolerances.PrimalFeasibility
+	if len(self.rows[r].index) == 1 {
+		return self.singleton(r)
+	}
+
+	least, largest := self.activity(r)
+	op, b := self.ops[r], self.b[r]
+	if (op != OperatorGE && least > b+tolerance) || (op != OperatorLE && largest < b-tolerance) {
+		self.fail(r)
+		return false
+	}
+	if len(self.rows[r].index) == 0 || (op == OperatorLE && largest <= b) ||
+		(op == OperatorGE && least >= b) {
+		self.removeRow(r)
+		return true
+	}
+	return false
+}
+
+// singleton turns row r with a single entry into a bound of its column.
+func (self *presolve) singleton(r int) bool {
+	j := self.rows[r].index[0]
+	a := self.rows[r].value[0]
+	bound := self.b[r] / a
+	op := self.ops[r]
+	if a < 0 && op == OperatorLE {
+		op = OperatorGE
+	} else if a < 0 && op == OperatorGE {
+		op = OperatorLE
+	}
+
+	step := presolveStep{kind: presolveSingleton, row: r, column: j, value: a}
+	step.lower, step.upper = self.tighten(j, bound, op != OperatorLE, op != OperatorGE)
+	if self.result == ResultInfeasible {
+		self.fail(r)
+		return false
+	}
+	self.removeRow(r)
+	self.steps = append(self.steps, step)
+	return true
+}
+
+// tighten sets the lower and the upper bound of column j to bound where
+// that is tighter. It returns the bounds it set, NaN for the others, and
+// sets the result to ResultInfeasible if the bounds cross.
+func (self *presolve) tighten(j int, bound float64, lower, upper bool) (float64, float64) {
+	newLower, newUpper := math.NaN(), math.NaN()
+	if lower {
+		value := bound
+		if self.integer[j] {
+			value = math.Ceil(bound - integerEpsilon)
+		}
+		if value > self.lower[j] {
+			self.lower[j], newLower = value, value
+		}
+	}
+	if upper {
+		value := bound
+		if self.integer[j] {
+			value = math.Floor(bound + integerEpsilon)
+		}
+		if value < self.upper[j] {
+			self.upper[j], newUpper = value, value
+		}
+	}
+
+	if self.lower[j] > self.upper[j] {
+		if self.lower[j]-self.upper[j] > self.tolerances.PrimalFeasibility {
+			self.result = ResultInfeasible
+			return newLower, newUpper
+		}
+		middle := (self.lower[j] + self.upper[j]) / 2
+		self.lower[j], self.upper[j] = middle, middle
+	}
+	return newLower, newUpper
+}
+
+// impliedFree returns whether the equation r limits the variable of its
+// entry k to its bounds, given the bounds of the other variables.
+func (self *presolve) impliedFree(r, k int) bool {
+	row := &self.rows[r]
+	least, largest := 0.0, 0.0
+	for l, j := range row.index {
+		if l == k {
+			continue
+		}
+		if a := row.value[l]; a > 0 {
+			least += a * self.lower[j]
+			largest += a * self.upper[j]
+		} else {
+			least += a * self.upper[j]
+			largest += a * self.lower[j]
+		}
+	}
+	j, a := row.index[k], row.value[k]
+	lower, upper := (self.b[r]-largest)/a, (self.b[r]-least)/a
+	if a < 0 {
+		lower, upper = upper, lower
+	}
+	return lower >= self.lower[j] && upper <= self.upper[j]
+}
+
+// substitute removes the equation r and one of its variables from the
+// problem if the variable is free or implied free, or if the equation has
+// two entries, whose bounds then carry over to the other variable.
+func (self *presolve) substitute(r int) bool {
+	row := &self.rows[r]
+	n := len(row.index)
+	if n < 2 {
+		return false
+	}
+	largest := 0.0
+	for _, a := range row.value {
+		largest = math.Max(largest, math.Abs(a))
+	}
+
+	best, bestCount, implied := -1, 0, false
+	for k, j := range row.index {
+		if self.integer[j] || self.quadratic[j] ||
+			math.Abs(row.value[k]) < presolveStability*largest {
+			continue
+		}
+		count := len(self.column(j))
+		if (count-1)*(n-2) > presolveMaxFill || (best >= 0 && count >= bestCount) {
+			continue
+		}
+		free := math.IsInf(self.lower[j], -1) && math.IsInf(self.upper[j], 1)
+		if !free && n > 2 && (self.locked[j] || !self.impliedFree(r, k)) {
+			continue
+		}
+		best, bestCount, implied = k, count, !free && n > 2
+	}
+	if best < 0 {
+		return false
+	}
+	if implied {
+		for k, j := range row.index {
+			if k != best {
+				self.locked[j] = true
+			}
+		}
+	}
+	self.eliminate(r, best)
+	return self.result != ResultInfeasible
+}
+
+// eliminate substitutes the variable of entry k of equation r in the other
+// rows and the objective and removes the row and the column.
+func (self *presolve) eliminate(r, k int) {
+	equation := self.rows[r].copy()
+	j, a := equation.index[k], equation.value[k]
+	step := presolveStep{kind: presolveSubstitution, row: r, column: j, value: a,
+		cost: self.cost[j], equation: equation, rightSide: self.b[r],
+		lower: math.NaN(), upper: math.NaN(), other: -1}
+	self.removeRow(r)
+
+	for _, i := range self.column(j) {
+		row := &self.rows[i]
+		q := row.at(j) / a
+		step.entries.index = append(step.entries.index, i)
+		step.entries.value = append(step.entries.value, row.at(j))
+		for _, l := range equation.index {
+			if l != j && row.at(l) == 0 {
+				self.columns[l] = append(self.columns[l], i)
+			}
+		}
+		row.axpy(-q, &equation)
+		self.b[i] -= q * self.b[r]
+		self.changed[i] = true
+	}
+	self.removeColumn(j)
+
+	if q := self.cost[j] / a; q != 0 {
+		for l, i := range equation.index {
+			self.cost[i] -= q * equation.value[l]
+		}
+		self.offset += q * self.b[r]
+	}
+
+	// the bounds of x_j = (b - a_o x_o) / a carry over to x_o
+	if len(equation.index) == 2 {
+		o := equation.index[1-k]
+		ao := equation.value[1-k]
+		atLower := (self.b[r] - a*self.lower[j]) / ao
+		atUpper := (self.b[r] - a*self.upper[j]) / ao
+		if a/ao < 0 {
+			atLower, atUpper = atUpper, atLower
+		}
+		step.other = o
+		if !math.IsInf(atUpper, 0) {
+			step.lower, _ = self.tighten(o, atUpper, true, false)
+		}
+		if !math.IsInf(atLower, 0) && self.result != ResultInfeasible {
+			_, step.upper = self.tighten(o, atLower, false, true)
+		}
+		if self.result == ResultInfeasible {
+			self.fail(r)
+		}
+	}
+	self.steps = append(self.steps, step)
+}
+
+// build collects the rows of the reduced problem.
+func (self *presolve) build() {
+	self.constraints = newConstraintList()
+	self.kept = make([]*Constraint, len(self.rows))
+	self.rowOf = make(map[*Constraint]int)
+	for r := range self.rows {
+		if self.removedRows[r] {
+			continue
+		}
+		constraint := self.originals[r]
+		if self.changed[r] {
+			constraint = self.copyRow(r)
+		}
+		self.constraints.AddItem(constraint)
+		self.kept[r] = constraint
+		self.rowOf[constraint] = r
+	}
+	if self.result == ResultOptimal && self.constraints.Len() == 0 &&
+		self.removedColumnCount == len(self.variables) {
+		self.result = ResultPresolve
+	}
+}
+
+// copyRow returns a constraint for row r that is not added to the
+// specification.
+func (self *presolve) copyRow(r int) *Constraint {
+	original := self.originals[r]
+	c := &Constraint{}
+	c.ls = self.ls
+	c.leftSide = newSummandList()
+	row := &self.rows[r]
+	for k, j := range row.index {
+		c.leftSide.AddItem(NewSummand(row.value[k], self.variables[j]))
+	}
+	c.opType = self.ops[r]
+	c.rightSide = self.b[r]
+	c.penaltyNeg = original.PenaltyNeg()
+	c.penaltyPos = original.PenaltyPos()
+	c.label = original.Label()
+	c.isValid = true
+	return c
+}
+
+// bounds returns the bounds of v in the reduced problem. A removed
+// variable is fixed at 0 until the postsolve, so that its column is not
+// empty.
+func (self *presolve) bounds(v *Variable) (float64, float64) {
+	if !self.active {
+		return v.Min(), v.Max()
+	}
+	j := self.columnOf[v]
+	if self.removedColumns[j] {
+		return 0, 0
+	}
+	return self.lower[j], self.upper[j]
+}
+
+// model returns the lpModel of the reduced problem.
+func (self *presolve) model() *lpModel {
+	if !self.active {
+		return newLPModel(self.ls, true)
+	}
+	model := newEmptyLPModel(self.ls)
+	for j, v := range self.variables {
+		if !self.removedColumns[j] {
+			model.addColumn(v, self.lower[j], self.upper[j], model.sense*self.cost[j])
+		}
+	}
+	model.offset = model.sense * self.offset
+	rows := make([]*Constraint, self.constraints.Len())
+	for i := range rows {
+		rows[i] = self.constraints.GetAt(i)
+	}
+	model.setRows(rows)
+	model.scale(self.ls.Scaling())
+	return model
+}
+
+// original returns the constraint of the specification for a row of the
+// reduced problem.
+func (self *presolve) original(c *Constraint) *Constraint {
+	if r, ok := self.rowOf[c]; ok {
+		return self.originals[r]
+	}
+	return c
+}
+
+// atBound returns whether the value of a column is at a bound the step
+// tightened and the reduced cost d holds it there.
+func (self *presolve) atBound(step *presolveStep, value, d float64) bool {
+	tolerance := self.tolerances.PrimalFeasibility
+	if !math.IsNaN(step.lower) && math.Abs(value-step.lower) <= tolerance && self.sense*d > 0 {
+		return true
+	}
+	return !math.IsNaN(step.upper) && math.Abs(value-step.upper) <= tolerance && self.sense*d < 0
+}
+
+// postsolve completes the solution of the reduced problem: the values of
+// the removed variables if values is true, and the duals of the removed
+// rows and the reduced costs if duals is true. Otherwise these are 0.
+func (self *presolve) postsolve(values, duals bool) {
+	if !self.active {
+		return
+	}
+	x := make([]float64, len(self.variables))
+	d := make([]float64, len(self.variables))
+	for j, v := range self.variables {
+		if !self.removedColumns[j] {
+			x[j] = v.Value()
+			d[j] = v.reducedCost
+		}
+	}
+	y := make([]float64, len(self.rows))
+	for r, c := range self.kept {
+		if c != nil {
+			y[r] = c.dual
+		}
+	}
+
+	for s := len(self.steps) - 1; s >= 0; s-- {
+		step := &self.steps[s]
+		j := step.column
+		switch step.kind {
+		case presolveFixed:
+			x[j] = step.value
+			d[j] = step.cost - step.entries.dot(y)
+		case presolveSingleton:
+			// the row holds the column at its bound instead
+			if self.ops[step.row] == OperatorEQ || self.atBound(step, x[j], d[j]) {
+				y[step.row] = d[j] / step.value
+				d[j] = 0
+			}
+		case presolveSubstitution:
+			x[j] = (step.rightSide - step.equation.dot(x) + step.value*x[j]) / step.value
+			// the bound of x_j that holds the other column
+			dj := 0.0
+			if o := step.other; o >= 0 && self.atBound(step, x[o], d[o]) {
+				dj = -step.value / step.equation.at(o) * d[o]
+			}
+			y[step.row] = (step.cost - step.entries.dot(y) - dj) / step.value
+			for k, l := range step.equation.index {
+				if l != j {
+					d[l] += step.equation.value[k] / step.value * dj
+				}
+			}
+			d[j] = dj
+		}
+	}
+
+	for j, v := range self.variables {
+		if self.removedColumns[j] && values {
+			v.SetValue(x[j])
+		}
+		v.reducedCost = 0
+		if duals {
+			v.reducedCost = d[j]
+		}
+	}
+	for r, c := range self.originals {
+		c.dual = 0
+		if duals {
+			c.dual = y[r]
+		}
+	}
+}
+
+// postsolveRay extends a ray of the reduced problem to the removed
+// variables.
+func (self *presolve) postsolveRay(ray *UnboundedRay) *UnboundedRay {
+	if !self.active || ray == nil {
+		return ray
+	}
+	direction := make([]float64, len(self.variables))
+	for i := 0; i < ray.Variables.Len(); i++ {
+		direction[self.columnOf[ray.Variables.GetAt(i)]] = ray.Direction[i]
+	}
+	for s := len(self.steps) - 1; s >= 0; s-- {
+		step := &self.steps[s]
+		if step.kind == presolveSubstitution {
+			j := step.column
+			direction[j] = -(step.equation.dot(direction) - step.value*direction[j]) / step.value
+		}
+	}
+
+	result := &UnboundedRay{Variables: newVariableList(), Direction: direction}
+	for _, v := range self.variables {
+		result.Variables.AddItem(v)
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestPresolve(t *testing.T) {
+	fmt.Println("Test Presolve")
+
+	if NewLinearSpec().Presolve() {
+		t.Error("a new specification presolves")
+	}
+
+	// a chain of tabs that the presolve solves completely
+	for _, solver := range []string{"active set", "simplex"} {
+		ls := NewLinearSpec()
+		if solver == "simplex" {
+			ls.SetSolver(NewSimplexSolver(ls))
+		}
+		ls.SetPresolve(true)
+		x0, _ := ls.AddVariable(nil)
+		x1, _ := ls.AddVariable(nil)
+		x2, _ := ls.AddVariable(nil)
+		x0.SetRange(0, 0)
+		ls.AddConstraint2([]float64{1, -1}, []*Variable{x1, x0}, OperatorEQ, 10)
+		ls.AddConstraint2([]float64{1, -1}, []*Variable{x2, x1}, OperatorEQ, 20)
+		if result, _ := ls.Solve(); result != ResultPresolve {
+			t.Fatalf("%v: result = %v, want %v", solver, result, ResultPresolve)
+		}
+		checkValue(t, "x1", x1.Value(), 10)
+		checkValue(t, "x2", x2.Value(), 30)
+		if stats := ls.Stats(); stats.PresolvedRows != 2 || stats.PresolvedColumns != 3 {
+			t.Errorf("%v: stats = %+v", solver, stats)
+		}
+		if _, err := ls.Sensitivity(); err != ErrPresolved {
+			t.Errorf("%v: Sensitivity() = %v", solver, err)
+		}
+	}
+
+	// a fixed variable, a singleton row, a redundant row and a doubleton
+	// equation; the values and duals must be those without presolve
+	build := func(solver string, presolve bool) (*LinearSpec, []*Variable, []*Constraint) {
+		ls := NewLinearSpec()
+		if solver == "dual simplex" {
+			ls.SetSolver(NewDualSimplexSolver(ls))
+		} else {
+			ls.SetSolver(NewSimplexSolver(ls))
+		}
+		ls.SetPresolve(presolve)
+		x, _ := ls.AddVariable(nil)
+		y, _ := ls.AddVariable(nil)
+		z, _ := ls.AddVariable(nil)
+		w, _ := ls.AddVariable(nil)
+		x.SetRange(0, 10)
+		y.SetRange(0, 10)
+		z.SetRange(2, 2)
+		w.SetRange(0, math.Inf(1))
+		c1, _ := ls.AddConstraint2([]float64{1, 1, 1}, []*Variable{x, y, z}, OperatorGE, 8)
+		c2, _ := ls.AddConstraint2([]float64{2}, []*Variable{y}, OperatorLE, 8)
+		c3, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 30)
+		c4, _ := ls.AddConstraint2([]float64{1, -2}, []*Variable{w, x}, OperatorEQ, 1)
+		ls.SetObjective1([]float64{3, 1, 1, 1}, []*Variable{x, y, z, w}, OptMinimize)
+		return ls, []*Variable{x, y, z, w}, []*Constraint{c1, c2, c3, c4}
+	}
+	for _, solver := range []string{"simplex", "dual simplex"} {
+		reference, referenceVariables, referenceConstraints := build(solver, false)
+		if result, _ := reference.Solve(); result != ResultOptimal {
+			t.Fatalf("%v: result without presolve = %v", solver, result)
+		}
+		ls, variables, constraints := build(solver, true)
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("%v: result = %v", solver, result)
+		}
+		checkValue(t, "objective", ls.ObjectiveValue(), reference.ObjectiveValue())
+		for i, v := range variables {
+			checkValue(t, fmt.Sprintf("%v: value %v", solver, i), v.Value(),
+				referenceVariables[i].Value())
+			checkValue(t, fmt.Sprintf("%v: reduced cost %v", solver, i), v.ReducedCost(),
+				referenceVariables[i].ReducedCost())
+		}
+		for i, c := range constraints {
+			checkValue(t, fmt.Sprintf("%v: dual %v", solver, i), c.Dual(),
+				referenceConstraints[i].Dual())
+		}
+		if stats := ls.Stats(); stats.PresolvedRows == 0 || stats.PresolvedColumns == 0 {
+			t.Errorf("%v: stats = %+v", solver, stats)
+		}
+	}
+
+	// infeasibility found by the presolve
+	ls := NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	ls.SetPresolve(true)
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, 1)
+	y.SetRange(0, 1)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 4)
+	c, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorGE, 3)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("result = %v, want %v", result, ResultInfeasible)
+	}
+	if got := ls.Solver().(*SimplexSolver).InfeasibleConstraint(); got != c {
+		t.Errorf("infeasible constraint = %v, want %v", got, c)
+	}
+
+	// 2x = 1 has no integer solution
+	ls = NewLinearSpec()
+	ls.SetSolver(NewBranchAndBoundSolver(ls))
+	ls.SetPresolve(true)
+	x, _ = ls.AddVariable(nil)
+	x.SetRange(0, 5)
+	x.SetInteger(true)
+	ls.AddConstraint2([]float64{2}, []*Variable{x}, OperatorEQ, 1)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("integer result = %v, want %v", result, ResultInfeasible)
+	}
+}
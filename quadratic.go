@@ -0,0 +1,166 @@
+package lp
+
+import "math"
+
+// QuadraticSolver is implemented by solvers that minimize a convex
+// quadratic objective function, see LinearSpec.SetQuadraticObjective.
+type QuadraticSolver interface {
+	QuadraticObjectiveChanged() bool
+}
+
+// savedObjective is an objective function to restore when a solver
+// rejects a new one.
+type savedObjective struct {
+	objective                     *SummandList
+	optType                       int
+	quadratic                     bool
+	quadraticLeft, quadraticRight *SummandList
+}
+
+func (self *LinearSpec) saveObjective() savedObjective {
+	return savedObjective{self.objective, self.optType, self.quadratic,
+		self.quadraticLeft, self.quadraticRight}
+}
+
+func (self *LinearSpec) restoreObjective(old savedObjective) {
+	self.releaseObjective()
+	self.objective = old.objective
+	self.optType = old.optType
+	self.quadratic = old.quadratic
+	self.quadraticLeft = old.quadraticLeft
+	self.quadraticRight = old.quadraticRight
+	self.referenceObjective()
+}
+
+// referenceObjective makes the variables of a quadratic objective used
+// variables, like the constraints do with theirs.
+func (self *LinearSpec) referenceObjective() {
+	if !self.quadratic {
+		return
+	}
+	for _, summands := range []*SummandList{self.objective, self.quadraticLeft,
+		self.quadraticRight} {
+		for i := 0; i < summands.Len(); i++ {
+			v := summands.GetAt(i).Var()
+			if v.AddReference() == 1 {
+				self.usedVariables.AddItem(v)
+			}
+		}
+	}
+}
+
+// releaseObjective undoes referenceObjective.
+func (self *LinearSpec) releaseObjective() {
+	for _, summands := range []*SummandList{self.objective, self.quadraticLeft,
+		self.quadraticRight} {
+		for i := 0; i < summands.Len(); i++ {
+			self.releaseSummand(summands.GetAt(i))
+		}
+	}
+}
+
+// releaseSummand drops the reference of a summand of a quadratic objective
+// to its variable.
+func (self *LinearSpec) releaseSummand(s *Summand) {
+	if self.quadratic && s.Var().RemoveReference() == 0 {
+		self.usedVariables.RemoveItem(s.Var())
+	}
+}
+
+// quadraticMatrix returns the symmetric part of the matrix that the pairs
+// of summands give and the variables of its rows.
+func quadraticMatrix(left, right *SummandList) ([][]float64, []*Variable) {
+	var variables []*Variable
+	index := make(map[*Variable]int)
+	indexOf := func(v *Variable) int {
+		i, ok := index[v]
+		if !ok {
+			i = len(variables)
+			index[v] = i
+			variables = append(variables, v)
+		}
+		return i
+	}
+	for k := 0; k < left.Len(); k++ {
+		indexOf(left.GetAt(k).Var())
+		indexOf(right.GetAt(k).Var())
+	}
+	q := make([][]float64, len(variables))
+	for i := range q {
+		q[i] = make([]float64, len(variables))
+	}
+	for k := 0; k < left.Len(); k++ {
+		i, j := index[left.GetAt(k).Var()], index[right.GetAt(k).Var()]
+		value := left.GetAt(k).Coeff() * right.GetAt(k).Coeff() / 2
+		q[i][j] += value
+		q[j][i] += value
+	}
+	return q, variables
+}
+
+// semidefinite returns whether the symmetric matrix a is positive
+// semidefinite, eliminating with the largest diagonal entry as pivot until
+// the remaining ones are zero relative to the largest entry. It overwrites
+// a.
+func semidefinite(a [][]float64, zero float64) bool {
+	largest := 0.0
+	for i := range a {
+		for _, value := range a[i] {
+			largest = math.Max(largest, math.Abs(value))
+		}
+	}
+	tolerance := zero * largest
+	eliminated := make([]bool, len(a))
+	for range a {
+		p := -1
+		for i := range a {
+			if !eliminated[i] && (p < 0 || a[i][i] > a[p][p]) {
+				p = i
+			}
+		}
+		if a[p][p] <= tolerance {
+			// a semidefinite rest with no positive diagonal entry is zero
+			for i := range a {
+				for j := range a {
+					if !eliminated[i] && !eliminated[j] && math.Abs(a[i][j]) > tolerance {
+						return false
+					}
+				}
+			}
+			return true
+		}
+		eliminated[p] = true
+		for i := range a {
+			if eliminated[i] {
+				continue
+			}
+			factor := a[i][p] / a[p][p]
+			for j := range a {
+				if !eliminated[j] {
+					a[i][j] -= factor * a[p][j]
+				}
+			}
+		}
+	}
+	return true
+}
+
+// quadraticObjective returns Q and c of a quadratic objective function in
+// the order of the used variables, with Q symmetric.
+func (self *LinearSpec) quadraticObjective() (*sparseMatrix, []float64) {
+	n := self.usedVariables.Len()
+	q := newSparseMatrix(n, n)
+	for k := 0; k < self.quadraticLeft.Len(); k++ {
+		left, right := self.quadraticLeft.GetAt(k), self.quadraticRight.GetAt(k)
+		i, j := left.Var().Index(), right.Var().Index()
+		value := left.Coeff() * right.Coeff() / 2
+		*q.ref(i, j) += value
+		*q.ref(j, i) += value
+	}
+	c := make([]float64, n)
+	for k := 0; k < self.objective.Len(); k++ {
+		s := self.objective.GetAt(k)
+		c[s.Var().Index()] += s.Coeff()
+	}
+	return q, c
+}
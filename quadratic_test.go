@@ -0,0 +1,123 @@
+package lp
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestQuadraticObjective(t *testing.T) {
+	fmt.Println("Test Quadratic Objective")
+
+	// least squares fit of a + b t to (0, 1), (1, 3) and (2, 4)
+	ls := NewLinearSpec()
+	a, _ := ls.AddVariable(nil)
+	b, _ := ls.AddVariable(nil)
+	err := ls.SetQuadraticObjective1([][]float64{{6, 6}, {6, 10}}, []*Variable{a, b},
+		[]float64{-16, -22})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ls.HasQuadraticObjective() || ls.UsedVariables().Len() != 2 {
+		t.Errorf("objective variables are not used")
+	}
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "a", a.Value(), 7.0/6)
+	checkValue(t, "b", b.Value(), 1.5)
+	// the sum of the squares is 1/6
+	checkValue(t, "objective", ls.ObjectiveValue(), 1.0/6-26)
+
+	b.SetMax(1)
+	c, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{a, b}, OperatorLE, 2)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "a", a.Value(), 1)
+	checkValue(t, "b", b.Value(), 1)
+	checkValue(t, "dual", c.Dual(), -4)
+	checkValue(t, "reduced cost", b.ReducedCost(), -2)
+
+	if err := ls.SetQuadraticObjective1([][]float64{{1, 2}, {2, 1}}, []*Variable{a, b},
+		nil); err != ErrNotConvex {
+		t.Errorf("indefinite objective: %v", err)
+	}
+	if err := ls.SetQuadraticObjective1([][]float64{{1}}, []*Variable{a, b},
+		nil); err != ErrLengthMismatch {
+		t.Errorf("short matrix: %v", err)
+	}
+	if err := ls.WriteLP(&bytes.Buffer{}); err != ErrQuadratic {
+		t.Errorf("WriteLP() = %v", err)
+	}
+	checkValue(t, "kept objective", ls.Objective().GetAt(1).Coeff(), -22)
+
+	// the variance of a portfolio of two assets
+	ls = NewLinearSpec()
+	x1, _ := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
+	x1.SetMin(0)
+	x2.SetMin(0)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x1, x2}, OperatorEQ, 1)
+	ls.SetQuadraticObjective1([][]float64{{2, 0}, {0, 8}}, []*Variable{x1, x2}, nil)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x1", x1.Value(), 0.8)
+	checkValue(t, "x2", x2.Value(), 0.2)
+	checkValue(t, "variance", ls.ObjectiveValue(), 0.8)
+	size, err := ls.MinSize(x2, x2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, "min size", size.W, 0)
+
+	// a smooth curve between fixed ends, from pairs that are not
+	// symmetric: (x_{i+1} - x_i)^2 = 1/2 (2x_{i+1}^2 + 2x_i^2 - 4x_{i+1}x_i)
+	ls = NewLinearSpec()
+	ls.SetPresolve(true)
+	x := make([]*Variable, 5)
+	for i := range x {
+		x[i], _ = ls.AddVariable(nil)
+	}
+	x[0].SetRange(0, 0)
+	x[4].SetRange(4, 4)
+	left, right := newSummandList(), newSummandList()
+	for i := 0; i < 4; i++ {
+		left.AddItem(NewSummand(2, x[i+1]))
+		right.AddItem(NewSummand(1, x[i+1]))
+		left.AddItem(NewSummand(2, x[i]))
+		right.AddItem(NewSummand(1, x[i]))
+		left.AddItem(NewSummand(-4, x[i+1]))
+		right.AddItem(NewSummand(1, x[i]))
+	}
+	if err := ls.SetQuadraticObjective(left, right, nil); err != nil {
+		t.Fatal(err)
+	}
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	for i := range x {
+		checkValue(t, fmt.Sprintf("x%v", i), x[i].Value(), float64(i))
+	}
+	checkValue(t, "smoothness", ls.ObjectiveValue(), 4)
+
+	ls.RemoveVariable(x[2])
+	if left, _ := ls.QuadraticObjective(); left.Len() != 8 {
+		t.Errorf("%v pairs left", left.Len())
+	}
+	if err := ls.SetObjective(nil, OptMinimize); err != nil {
+		t.Fatal(err)
+	}
+	if ls.HasQuadraticObjective() || ls.UsedVariables().Len() != 0 {
+		t.Errorf("quadratic objective not replaced")
+	}
+
+	ls = NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	y, _ := ls.AddVariable(nil)
+	if err := ls.SetQuadraticObjective1([][]float64{{1}}, []*Variable{y},
+		nil); err != ErrUnsupported {
+		t.Errorf("simplex: %v", err)
+	}
+}
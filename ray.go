@@ -0,0 +1,32 @@
+package lp
+
+// UnboundedRay is a direction along which the variables can move from the
+// solution of an unbounded solve without violating a hard constraint or a
+// bound, while the objective improves without limit.
+type UnboundedRay struct {
+	Variables *VariableList
+	// one component per variable
+	Direction []float64
+}
+
+// RayReporter is implemented by solvers that detect unboundedness.
+type RayReporter interface {
+	// UnboundedRay returns nil if the last solve was not unbounded.
+	UnboundedRay() *UnboundedRay
+}
+
+// unboundedRay maps a direction over the columns of the model to the
+// variables, nil if there is none.
+func (self *lpModel) unboundedRay(direction []float64) *UnboundedRay {
+	if direction == nil {
+		return nil
+	}
+	ray := &UnboundedRay{Variables: newVariableList()}
+	for j, v := range self.variables {
+		if v != nil {
+			ray.Variables.AddItem(v)
+			ray.Direction = append(ray.Direction, direction[j]*self.columnFactor(j))
+		}
+	}
+	return ray
+}
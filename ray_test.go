@@ -0,0 +1,78 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestUnboundedRay(t *testing.T) {
+	fmt.Println("Test UnboundedRay")
+
+	for _, solver := range []string{"primal", "dual", "branch and bound"} {
+		ls := NewLinearSpec()
+		switch solver {
+		case "primal":
+			ls.SetSolver(NewSimplexSolver(ls))
+		case "dual":
+			ls.SetSolver(NewDualSimplexSolver(ls))
+		default:
+			ls.SetSolver(NewBranchAndBoundSolver(ls))
+		}
+		x, _ := ls.AddVariable(nil)
+		y, _ := ls.AddVariable(nil)
+		x.SetRange(0, math.Inf(1))
+		y.SetRange(0, math.Inf(1))
+		ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorLE, 1)
+		ls.SetObjective1([]float64{1, -0.5}, []*Variable{x, y}, OptMaximize)
+
+		if _, err := ls.UnboundedRay(); err != ErrNoRay {
+			t.Errorf("%v: ray before a solve: %v", solver, err)
+		}
+		if result, _ := ls.Solve(); result != ResultUnbounded {
+			t.Fatalf("%v: result = %v, want %v", solver, result, ResultUnbounded)
+		}
+		ray, err := ls.UnboundedRay()
+		if err != nil {
+			t.Fatalf("%v: %v", solver, err)
+		}
+		dx := ray.Direction[ray.Variables.IndexOf(x)]
+		dy := ray.Direction[ray.Variables.IndexOf(y)]
+		if dx < 0 || dy < 0 || dx-dy > EqualsEpsilon || dx-0.5*dy <= 0 {
+			t.Errorf("%v: ray (%v, %v) is not a direction of unboundedness", solver, dx, dy)
+		}
+	}
+
+	if _, err := NewLinearSpec().UnboundedRay(); err != ErrUnsupported {
+		t.Errorf("ray of the ActiveSetSolver: %v", err)
+	}
+}
+
+func TestActiveSetMaxSize(t *testing.T) {
+	fmt.Println("Test ActiveSet MaxSize")
+
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	y.SetMin(0)
+	ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorGE, 10)
+	ls.AddConstraint2([]float64{1}, []*Variable{y}, OperatorLE, 50)
+	ls.AddConstraint4([]float64{1}, []*Variable{y}, OperatorGE, 20, 1, 1)
+
+	size, err := ls.MaxSize(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(size.W, 1) {
+		t.Errorf("max width = %v, want unbounded", size.W)
+	}
+	checkValue(t, "max height", size.H, 50)
+
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 100)
+	size, err = ls.MaxSize(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkValue(t, "max width", size.W, 100)
+	checkValue(t, "max height", size.H, 50)
+}
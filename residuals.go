@@ -0,0 +1,186 @@
+package lp
+
+import "math"
+
+// Residuals tell how well the values of a solve satisfy the hard
+// constraints and the variable bounds. Variables that appear in no
+// constraint are not determined by a solve and are left out.
+type Residuals struct {
+	// the hard constraints and how much the values violate each
+	Constraints *ConstraintList
+	Violations  []float64
+	// the variables and how much their values violate their bounds
+	Variables       *VariableList
+	BoundViolations []float64
+	// steps of iterative refinement before the check
+	RefinementSteps int
+}
+
+// ResidualReporter is implemented by solvers that check their values.
+type ResidualReporter interface {
+	// Residuals returns nil if the last solve found no values.
+	Residuals() *Residuals
+}
+
+// newResiduals computes the residuals of the current values for the hard
+// constraints of the list and the variables they use. Constraints with
+// penalties, soft inequalities as well as equalities, are left out. A NaN
+// value violates without limit.
+func newResiduals(constraints *ConstraintList) *Residuals {
+	residuals := &Residuals{Constraints: newConstraintList(), Variables: newVariableList()}
+	seen := make(map[*Variable]bool)
+	for c := 0; c < constraints.Len(); c++ {
+		constraint := constraints.GetAt(c)
+		leftSide := constraint.LeftSide()
+		for s := 0; s < leftSide.Len(); s++ {
+			v := leftSide.GetAt(s).Var()
+			if !seen[v] {
+				seen[v] = true
+				residuals.Variables.AddItem(v)
+				residuals.BoundViolations = append(residuals.BoundViolations,
+					violation(v.Min()-v.Value(), v.Value()-v.Max()))
+			}
+		}
+		if constraint.PenaltyNeg() > 0 || constraint.PenaltyPos() > 0 {
+			continue
+		}
+		residuals.Constraints.AddItem(constraint)
+		r := constraint.residual()
+		switch constraint.Op() {
+		case OperatorLE:
+			r = violation(-r)
+		case OperatorGE:
+			r = violation(r)
+		default:
+			r = violation(r, -r)
+		}
+		residuals.Violations = append(residuals.Violations, r)
+	}
+	return residuals
+}
+
+// violation returns the largest of the amounts, at least 0; NaN gives
+// +Inf.
+func violation(amounts ...float64) float64 {
+	largest := 0.0
+	for _, amount := range amounts {
+		if math.IsNaN(amount) {
+			return math.Inf(1)
+		}
+		largest = math.Max(largest, amount)
+	}
+	return largest
+}
+
+// residual returns b - a^Tx for the current values.
+func (self *Constraint) residual() float64 {
+	r := self.rightSide
+	for s := 0; s < self.leftSide.Len(); s++ {
+		summand := self.leftSide.GetAt(s)
+		r -= summand.Coeff() * summand.Var().Value()
+	}
+	return r
+}
+
+// MaxViolation returns the largest violation of a hard constraint or a
+// bound.
+func (self *Residuals) MaxViolation() float64 {
+	largest := 0.0
+	for _, value := range self.Violations {
+		largest = math.Max(largest, value)
+	}
+	for _, value := range self.BoundViolations {
+		largest = math.Max(largest, value)
+	}
+	return largest
+}
+
+// refine moves the values of the variables by the least-norm correction
+// that makes the equalities and the inequalities and bounds that are
+// violated or hold within tolerance hold exactly. It fails if the
+// correction can not be computed.
+func (self *Residuals) refine(tolerances Tolerances) bool {
+	n := self.Variables.Len()
+	column := make(map[*Variable]int, n)
+	for j := 0; j < n; j++ {
+		column[self.Variables.GetAt(j)] = j
+	}
+	var rows []sparseVector
+	var rightSide []float64
+	for c := 0; c < self.Constraints.Len(); c++ {
+		constraint := self.Constraints.GetAt(c)
+		r := constraint.residual()
+		if (constraint.Op() == OperatorLE && r > tolerances.PrimalFeasibility) ||
+			(constraint.Op() == OperatorGE && r < -tolerances.PrimalFeasibility) {
+			continue
+		}
+		row := sparseVector{}
+		leftSide := constraint.LeftSide()
+		for s := 0; s < leftSide.Len(); s++ {
+			summand := leftSide.GetAt(s)
+			*row.ref(column[summand.Var()]) += summand.Coeff()
+		}
+		rows = append(rows, row)
+		rightSide = append(rightSide, r)
+	}
+	for j := 0; j < n; j++ {
+		v := self.Variables.GetAt(j)
+		bound := 0.0
+		switch {
+		case v.Value()-v.Min() <= tolerances.PrimalFeasibility:
+			bound = v.Min()
+		case v.Max()-v.Value() <= tolerances.PrimalFeasibility:
+			bound = v.Max()
+		default:
+			continue
+		}
+		row := sparseVector{}
+		*row.ref(j) = 1
+		rows = append(rows, row)
+		rightSide = append(rightSide, bound-v.Value())
+	}
+	if len(rows) == 0 {
+		return true
+	}
+
+	// the correction A^T l with (A A^T) l = r of the independent rows
+	a := newSparseMatrix(len(rows), n)
+	for i := range rows {
+		a.rows[i] = rows[i].copy()
+	}
+	independent := make([]bool, len(rows))
+	sparseDependencies(a, independent, tolerances.Zero, nil)
+	var kept []sparseVector
+	var b []float64
+	for i, row := range rows {
+		if independent[i] {
+			kept = append(kept, row)
+			b = append(b, rightSide[i])
+		}
+	}
+	product := newSparseMatrix(len(kept), len(kept))
+	dense := make([]float64, n)
+	for i := range kept {
+		for k, j := range kept[i].index {
+			dense[j] = kept[i].value[k]
+		}
+		for k := range kept {
+			if value := kept[k].dot(dense); value != 0 {
+				*product.ref(i, k) = value
+			}
+		}
+		for _, j := range kept[i].index {
+			dense[j] = 0
+		}
+	}
+	if !sparseSolve(product, b, tolerances.Zero) {
+		return false
+	}
+	for i, row := range kept {
+		for k, j := range row.index {
+			v := self.Variables.GetAt(j)
+			v.SetValue(v.Value() + row.value[k]*b[i])
+		}
+	}
+	return true
+}
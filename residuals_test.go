@@ -0,0 +1,85 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestResiduals(t *testing.T) {
+	fmt.Println("Test Residuals")
+
+	ls := NewLinearSpec()
+	solver := ls.Solver().(*ActiveSetSolver)
+	x1, _ := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
+	x3, _ := ls.AddVariable(nil)
+	x1.SetRange(0, 0)
+	x3.SetRange(0, 100)
+	c1, _ := ls.AddConstraint2([]float64{1, -1}, []*Variable{x2, x1}, OperatorEQ, 30)
+	c2, _ := ls.AddConstraint2([]float64{1, -1}, []*Variable{x3, x2}, OperatorGE, 20)
+	soft, _ := ls.AddConstraint4([]float64{1}, []*Variable{x3}, OperatorLE, 40, 1, 1)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	residuals, err := ls.Residuals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if residuals.MaxViolation() != 0 || residuals.RefinementSteps != 0 {
+		t.Errorf("residuals = %+v", residuals)
+	}
+	if residuals.Constraints.IndexOf(soft) >= 0 || residuals.Constraints.IndexOf(c1) < 0 {
+		t.Error("the soft constraint is checked or a hard one is not")
+	}
+
+	// values that violate an equation and a bound
+	perturb := func() {
+		x1.SetValue(1e-3)
+		x2.SetValue(30.5)
+		x3.SetValue(50.5)
+	}
+	perturb()
+	if result := solver.checkResiduals(ResultOptimal); result != ResultNumFailure {
+		t.Errorf("result = %v, want %v", result, ResultNumFailure)
+	}
+	residuals = solver.Residuals()
+	checkValue(t, "violation of c1", residuals.Violations[residuals.Constraints.IndexOf(c1)],
+		0.5-1e-3)
+	checkValue(t, "violation of c2", residuals.Violations[residuals.Constraints.IndexOf(c2)], 0)
+	checkValue(t, "bound violation of x1",
+		residuals.BoundViolations[residuals.Variables.IndexOf(x1)], 1e-3)
+
+	// refinement restores them
+	solver.SetRefinementSteps(3)
+	perturb()
+	if result := solver.checkResiduals(ResultOptimal); result != ResultSubOptimal {
+		t.Errorf("refined result = %v, want %v", result, ResultSubOptimal)
+	}
+	if steps := solver.Residuals().RefinementSteps; steps != 1 {
+		t.Errorf("refinement steps = %v", steps)
+	}
+	checkValue(t, "x1", x1.Value(), 0)
+	checkValue(t, "x2", x2.Value(), 30)
+	checkValue(t, "x3", x3.Value(), 50)
+
+	// a NaN value can not be refined
+	x2.SetValue(math.NaN())
+	if result := solver.checkResiduals(ResultOptimal); result != ResultNumFailure {
+		t.Errorf("NaN result = %v, want %v", result, ResultNumFailure)
+	}
+
+	x2.SetMax(10)
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Fatalf("result = %v, want %v", result, ResultInfeasible)
+	}
+	if _, err := ls.Residuals(); err != ErrNoResiduals {
+		t.Errorf("Residuals() after an infeasible solve = %v", err)
+	}
+
+	ls = NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	if _, err := ls.Residuals(); err != ErrUnsupported {
+		t.Errorf("Residuals() of the simplex = %v", err)
+	}
+}
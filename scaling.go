@@ -0,0 +1,168 @@
+package lp
+
+import "math"
+
+// Scaling modes of a LinearSpec. The solvers scale the rows and columns of
+// the constraint matrix by powers of 2, so the scaling itself adds no
+// rounding errors, and undo the scaling for the values and duals.
+const (
+	ScaleNone = iota
+	// rows and columns by the geometric mean of their largest and smallest
+	// entry, repeatedly
+	ScaleGeometric
+	// rows and then columns such that their largest entry is about 1
+	ScaleEquilibrate
+)
+
+// scaleIterations is the maximum number of passes of geometric scaling.
+const scaleIterations = 20
+
+// scaleFactors computes the row and column factors of a for the scaling
+// mode. The columns marked in fixed keep the factor 1.
+func scaleFactors(a *sparseMatrix, mode int, fixed []bool) (rowScale, columnScale []float64) {
+	rowScale = make([]float64, a.m)
+	columnScale = make([]float64, a.n)
+	for i := range rowScale {
+		rowScale[i] = 1
+	}
+	for j := range columnScale {
+		columnScale[j] = 1
+	}
+
+	// largest and smallest scaled absolute entry of each row and column
+	extremes := func() (rowMax, rowMin, columnMax, columnMin []float64) {
+		rowMax, rowMin = make([]float64, a.m), make([]float64, a.m)
+		columnMax, columnMin = make([]float64, a.n), make([]float64, a.n)
+		for j := range columnMin {
+			columnMin[j] = math.Inf(1)
+		}
+		for i := range a.rows {
+			rowMin[i] = math.Inf(1)
+			row := &a.rows[i]
+			for k, j := range row.index {
+				value := math.Abs(row.value[k]) * rowScale[i] * columnScale[j]
+				if value == 0 {
+					continue
+				}
+				rowMax[i] = math.Max(rowMax[i], value)
+				rowMin[i] = math.Min(rowMin[i], value)
+				columnMax[j] = math.Max(columnMax[j], value)
+				columnMin[j] = math.Min(columnMin[j], value)
+			}
+		}
+		return
+	}
+
+	switch mode {
+	case ScaleGeometric:
+		for pass := 0; pass < scaleIterations; pass++ {
+			changed := false
+			rowMax, rowMin, _, _ := extremes()
+			for i := range rowScale {
+				if rowMax[i] > 0 {
+					factor := powerOfTwo(1 / math.Sqrt(rowMax[i]*rowMin[i]))
+					rowScale[i] *= factor
+					changed = changed || factor != 1
+				}
+			}
+			_, _, columnMax, columnMin := extremes()
+			for j := range columnScale {
+				if columnMax[j] > 0 && (fixed == nil || !fixed[j]) {
+					factor := powerOfTwo(1 / math.Sqrt(columnMax[j]*columnMin[j]))
+					columnScale[j] *= factor
+					changed = changed || factor != 1
+				}
+			}
+			if !changed {
+				break
+			}
+		}
+	case ScaleEquilibrate:
+		rowMax, _, _, _ := extremes()
+		for i := range rowScale {
+			if rowMax[i] > 0 {
+				rowScale[i] = powerOfTwo(1 / rowMax[i])
+			}
+		}
+		_, _, columnMax, _ := extremes()
+		for j := range columnScale {
+			if columnMax[j] > 0 && (fixed == nil || !fixed[j]) {
+				columnScale[j] = powerOfTwo(1 / columnMax[j])
+			}
+		}
+	}
+	return rowScale, columnScale
+}
+
+// rowScaleFactor returns the factor of a row added to a scaled model, whose
+// entries are already multiplied by the factors of their columns.
+func rowScaleFactor(values []float64, mode int) float64 {
+	largest, smallest := 0.0, math.Inf(1)
+	for _, value := range values {
+		value = math.Abs(value)
+		if value == 0 {
+			continue
+		}
+		largest = math.Max(largest, value)
+		smallest = math.Min(smallest, value)
+	}
+	switch {
+	case largest == 0:
+		return 1
+	case mode == ScaleGeometric:
+		return powerOfTwo(1 / math.Sqrt(largest*smallest))
+	}
+	return powerOfTwo(1 / largest)
+}
+
+// powerOfTwo rounds a positive factor to the nearest power of 2.
+func powerOfTwo(factor float64) float64 {
+	return math.Exp2(math.Round(math.Log2(factor)))
+}
+
+// scale scales the rows and columns of the model. The columns of integer
+// variables are not scaled, so branching stays on integer values.
+func (self *lpModel) scale(mode int) {
+	if mode == ScaleNone {
+		return
+	}
+	fixed := make([]bool, self.columns)
+	for j, v := range self.variables {
+		fixed[j] = v != nil && v.IsInteger()
+	}
+	self.rowScale, self.columnScale = scaleFactors(self.a.transpose(), mode, fixed)
+
+	// the coefficients may be shared with other models
+	scaled := self.a.copy()
+	for j := range scaled.rows {
+		column := &scaled.rows[j]
+		for k, i := range column.index {
+			column.value[k] *= self.rowScale[i] * self.columnScale[j]
+		}
+	}
+	self.a = scaled
+	for i := 0; i < self.rows; i++ {
+		self.b[i] *= self.rowScale[i]
+	}
+	for j := 0; j < self.columns; j++ {
+		self.c[j] *= self.columnScale[j]
+		self.lower[j] /= self.columnScale[j]
+		self.upper[j] /= self.columnScale[j]
+	}
+}
+
+// columnFactor returns the factor of column j, x_j = factor * scaled x_j.
+func (self *lpModel) columnFactor(j int) float64 {
+	if self.columnScale == nil {
+		return 1
+	}
+	return self.columnScale[j]
+}
+
+// rowFactor returns the factor of row i, y_i = factor * scaled y_i.
+func (self *lpModel) rowFactor(i int) float64 {
+	if self.rowScale == nil {
+		return 1
+	}
+	return self.rowScale[i]
+}
@@ -0,0 +1,104 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestScaling(t *testing.T) {
+	fmt.Println("Test Scaling")
+
+	ls := NewLinearSpec()
+	if ls.Scaling() != ScaleNone {
+		t.Errorf("scaling of a new specification = %v", ls.Scaling())
+	}
+	if err := ls.SetScaling(7); err != ErrInvalidScaling {
+		t.Errorf("SetScaling(7) = %v", err)
+	}
+
+	// coefficients spread over seven orders of magnitude
+	build := func(solver string, mode int) (*LinearSpec, []*Variable, []*Constraint) {
+		ls := NewLinearSpec()
+		if solver == "simplex" {
+			ls.SetSolver(NewSimplexSolver(ls))
+		}
+		ls.SetScaling(mode)
+		x, _ := ls.AddVariable(nil)
+		y, _ := ls.AddVariable(nil)
+		z, _ := ls.AddVariable(nil)
+		for _, v := range []*Variable{x, y, z} {
+			v.SetMin(0)
+		}
+		c1, _ := ls.AddConstraint2([]float64{1e-3, 2e-3, 1e4},
+			[]*Variable{x, y, z}, OperatorGE, 3e4)
+		c2, _ := ls.AddConstraint2([]float64{1e4, 1, 1e-3},
+			[]*Variable{x, y, z}, OperatorGE, 2e4)
+		c3, _ := ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 5e3)
+		ls.SetObjective1([]float64{1, 2e3, 5}, []*Variable{x, y, z}, OptMinimize)
+		return ls, []*Variable{x, y, z}, []*Constraint{c1, c2, c3}
+	}
+
+	for _, solver := range []string{"active set", "simplex"} {
+		reference, referenceVariables, referenceConstraints := build(solver, ScaleNone)
+		if result, _ := reference.Solve(); result != ResultOptimal {
+			t.Fatalf("%v: unscaled result = %v", solver, result)
+		}
+		for _, mode := range []int{ScaleGeometric, ScaleEquilibrate} {
+			ls, variables, constraints := build(solver, mode)
+			if result, _ := ls.Solve(); result != ResultOptimal {
+				t.Fatalf("%v, scaling %v: result = %v", solver, mode, result)
+			}
+			for i, v := range variables {
+				want := referenceVariables[i].Value()
+				if math.Abs(v.Value()-want) > 1e-6*math.Max(1, math.Abs(want)) {
+					t.Errorf("%v, scaling %v: value %v = %v, want %v", solver, mode, i,
+						v.Value(), want)
+				}
+			}
+			for i, c := range constraints {
+				want := referenceConstraints[i].Dual()
+				if math.Abs(c.Dual()-want) > 1e-6*math.Max(1, math.Abs(want)) {
+					t.Errorf("%v, scaling %v: dual %v = %v, want %v", solver, mode, i,
+						c.Dual(), want)
+				}
+			}
+		}
+	}
+}
+
+// TestScalingPhaseOne solves a feasible, unbounded LP whose equilibrated
+// rows end phase 1 with a scaled down artificial that is still positive.
+func TestScalingPhaseOne(t *testing.T) {
+	fmt.Println("Test Scaling PhaseOne")
+
+	for _, solver := range []string{"primal", "dual", "branch and bound"} {
+		ls := NewLinearSpec()
+		switch solver {
+		case "primal":
+			ls.SetSolver(NewSimplexSolver(ls))
+		case "dual":
+			ls.SetSolver(NewDualSimplexSolver(ls))
+		default:
+			ls.SetSolver(NewBranchAndBoundSolver(ls))
+		}
+		ls.SetScaling(ScaleEquilibrate)
+		x := make([]*Variable, 5)
+		for i := range x {
+			x[i], _ = ls.AddVariable(nil)
+			x[i].SetMin(0)
+		}
+		x[4].SetFree()
+		ls.AddConstraint2([]float64{0.08, -0.2, 6, 0, 0}, x, OperatorLE, -1000)
+		ls.AddConstraint2([]float64{-0.3, 0, -4e4, -2, 8}, x, OperatorLE, -0.2)
+		ls.AddConstraint2([]float64{0, -7e4, 0, -5e-3, 5000}, x, OperatorLE, 0)
+		ls.AddConstraint2([]float64{-2e4, -30, -1e-4, -6e-3, 9}, x, OperatorLE, 0)
+		ls.AddConstraint2([]float64{-6e-4, 0, 1e4, -8e-4, -0.08}, x, OperatorLE, -5e4)
+		ls.AddConstraint2([]float64{0, 0, -2000, 4, -1e-3}, x, OperatorLE, -400)
+		ls.SetObjective1([]float64{5, -2e4, -6e4, -6e4, -2e-4}, x, OptMinimize)
+
+		if result, _ := ls.Solve(); result != ResultUnbounded {
+			t.Errorf("%v: result = %v, want %v", solver, result, ResultUnbounded)
+		}
+	}
+}
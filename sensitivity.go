@@ -0,0 +1,232 @@
+package lp
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// ConstraintRange is the ranging of the right side of a constraint.
+type ConstraintRange struct {
+	Constraint *Constraint
+	// shadow price, see Constraint.Dual
+	Dual float64
+	// interval of the right side over which the basis stays optimal and
+	// the dual stays valid
+	From, Till float64
+}
+
+// CostRange is the ranging of the objective coefficient of a variable.
+type CostRange struct {
+	Variable *Variable
+	Cost     float64
+	// interval of the coefficient over which the solution stays optimal
+	From, Till float64
+	Basic      bool
+}
+
+// BoundRange is the ranging of the bounds of a variable. Only the bound a
+// nonbasic variable is held at is restricted by the basis; the other one
+// may move up to the value of the variable.
+type BoundRange struct {
+	Variable *Variable
+	// interval of Min() over which the basis stays optimal
+	MinFrom, MinTill float64
+	// interval of Max() over which the basis stays optimal
+	MaxFrom, MaxTill float64
+}
+
+// Sensitivity is the sensitivity analysis of an optimal basis.
+type Sensitivity struct {
+	Constraints []ConstraintRange
+	Objective   []CostRange
+	Bounds      []BoundRange
+}
+
+// SensitivityReporter is implemented by solvers that can analyse the
+// optimal basis of the last solve.
+type SensitivityReporter interface {
+	// Sensitivity returns nil if the last solve was not optimal.
+	Sensitivity() *Sensitivity
+}
+
+// String formats the analysis like the sensitivity output of lp_solve.
+func (self *Sensitivity) String() string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString("Primal objective:\n\n")
+	fmt.Fprintf(&buffer, "  %-20s %15s %15s %15s\n", "Column name", "Value", "from", "till")
+	for _, r := range self.Objective {
+		if r.Basic {
+			fmt.Fprintf(&buffer, "  %-20s %15.7g %15.7g %15.7g\n", r.Variable.name(),
+				r.Cost, r.From, r.Till)
+		} else {
+			fmt.Fprintf(&buffer, "  %-20s %15.7g %15.7g %15.7g (nonbasic)\n",
+				r.Variable.name(), r.Cost, r.From, r.Till)
+		}
+	}
+
+	buffer.WriteString("\nDual value:\n\n")
+	fmt.Fprintf(&buffer, "  %-20s %15s %15s %15s\n", "Row name", "Value", "from", "till")
+	for _, r := range self.Constraints {
+		fmt.Fprintf(&buffer, "  %-20s %15.7g %15.7g %15.7g\n", r.Constraint.name(),
+			r.Dual, r.From, r.Till)
+	}
+
+	buffer.WriteString("\nBounds:\n\n")
+	fmt.Fprintf(&buffer, "  %-20s %15s %15s %15s %15s\n", "Column name", "min from",
+		"min till", "max from", "max till")
+	for _, r := range self.Bounds {
+		fmt.Fprintf(&buffer, "  %-20s %15.7g %15.7g %15.7g %15.7g\n", r.Variable.name(),
+			r.MinFrom, r.MinTill, r.MaxFrom, r.MaxTill)
+	}
+	return buffer.String()
+}
+
+// sensitivity analyses the optimal basis of the simplex.
+func (self *simplex) sensitivity() *Sensitivity {
+	model := self.model
+	sensitivity := &Sensitivity{}
+	w := make([]float64, self.m)
+
+	// a change t of b_i moves x_B by t B^-1e_i
+	for i, constraint := range model.constraints {
+		for k := range w {
+			w[k] = 0
+		}
+		w[i] = 1
+		self.factor.ftran(w)
+		down, up := self.stepRange(w)
+		r := model.rowFactor(i)
+		sensitivity.Constraints = append(sensitivity.Constraints, ConstraintRange{
+			constraint, constraint.Dual(), (model.b[i] - down) / r, (model.b[i] + up) / r})
+	}
+
+	rowOf := make([]int, self.n)
+	for j := range rowOf {
+		rowOf[j] = -1
+	}
+	for r, k := range self.head {
+		if k < self.n {
+			rowOf[k] = r
+		}
+	}
+
+	for j, v := range model.variables {
+		if v == nil {
+			continue
+		}
+
+		// the cost of the minimization
+		f := model.columnFactor(j)
+		cost := model.c[j] / f
+		from, till := self.costRange(j, rowOf[j])
+		from, till = from/f, till/f
+		if model.sense < 0 {
+			cost, from, till = 0-cost, 0-till, 0-from
+		}
+		sensitivity.Objective = append(sensitivity.Objective, CostRange{
+			v, cost, from, till, rowOf[j] >= 0})
+
+		// in the scale of the model
+		bounds := BoundRange{v, math.Inf(-1), self.x[j], self.x[j], math.Inf(1)}
+		if rowOf[j] < 0 && self.status[j] != columnAtZero {
+			// a change t of the bound moves x_B by -t B^-1a_j
+			self.column(j, w)
+			self.factor.ftran(w)
+			for k := range w {
+				w[k] = -w[k]
+			}
+			down, up := self.stepRange(w)
+			if self.status[j] == columnAtLower {
+				bounds.MinFrom = self.lower[j] - down
+				bounds.MinTill = math.Min(self.lower[j]+up, self.upper[j])
+				bounds.MaxFrom = self.lower[j]
+			} else {
+				bounds.MaxFrom = math.Max(self.upper[j]-down, self.lower[j])
+				bounds.MaxTill = self.upper[j] + up
+				bounds.MinTill = self.upper[j]
+			}
+		}
+		bounds.MinFrom *= f
+		bounds.MinTill *= f
+		bounds.MaxFrom *= f
+		bounds.MaxTill *= f
+		sensitivity.Bounds = append(sensitivity.Bounds, bounds)
+	}
+	return sensitivity
+}
+
+// stepRange returns how far t can decrease and increase such that the
+// basic values x_B + tw stay within their bounds.
+func (self *simplex) stepRange(w []float64) (down, up float64) {
+	down, up = math.Inf(1), math.Inf(1)
+	for i := 0; i < self.m; i++ {
+		if math.Abs(w[i]) <= self.tolerances.Pivot {
+			continue
+		}
+		k := self.head[i]
+		toLower := math.Max(self.x[k]-self.lower[k], 0) / math.Abs(w[i])
+		toUpper := math.Max(self.upper[k]-self.x[k], 0) / math.Abs(w[i])
+		if w[i] > 0 {
+			up = math.Min(up, toUpper)
+			down = math.Min(down, toLower)
+		} else {
+			up = math.Min(up, toLower)
+			down = math.Min(down, toUpper)
+		}
+	}
+	return down, up
+}
+
+// costRange returns the interval of the cost of column j over which the
+// basis stays optimal. r is the row of j in the basis or -1.
+func (self *simplex) costRange(j, r int) (from, till float64) {
+	c := self.model.c[j]
+	if r < 0 {
+		switch {
+		case self.lower[j] == self.upper[j]:
+			return math.Inf(-1), math.Inf(1)
+		case self.status[j] == columnAtLower:
+			return c - self.d[j], math.Inf(1)
+		case self.status[j] == columnAtUpper:
+			return math.Inf(-1), c - self.d[j]
+		}
+		return c, c
+	}
+
+	// a change t of c_j changes the reduced costs by -t alpha_r
+	rho := make([]float64, self.m)
+	rho[r] = 1
+	self.factor.btran(rho)
+	down, up := math.Inf(1), math.Inf(1)
+	for k := 0; k < self.n+self.m; k++ {
+		if self.status[k] == columnBasic || self.lower[k] == self.upper[k] {
+			continue
+		}
+		alpha := self.dot(rho, k)
+		if math.Abs(alpha) <= self.tolerances.Pivot {
+			continue
+		}
+		d := self.d[k]
+		switch self.status[k] {
+		case columnAtLower:
+			// d_k - t alpha >= 0
+			if alpha > 0 {
+				up = math.Min(up, math.Max(d, 0)/alpha)
+			} else {
+				down = math.Min(down, math.Max(d, 0)/-alpha)
+			}
+		case columnAtUpper:
+			// d_k - t alpha <= 0
+			if alpha > 0 {
+				down = math.Min(down, math.Max(-d, 0)/alpha)
+			} else {
+				up = math.Min(up, math.Max(-d, 0)/-alpha)
+			}
+		default:
+			return c, c
+		}
+	}
+	return c - down, c + up
+}
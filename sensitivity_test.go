@@ -0,0 +1,63 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestSensitivity(t *testing.T) {
+	fmt.Println("Test Sensitivity")
+
+	ls := NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, 3)
+	y.SetRange(0, math.Inf(1))
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 4)
+	ls.AddConstraint2([]float64{1, 3}, []*Variable{x, y}, OperatorLE, 7)
+	ls.SetObjective1([]float64{3, 2}, []*Variable{x, y}, OptMaximize)
+
+	if _, err := ls.Sensitivity(); err != ErrNotOptimal {
+		t.Errorf("sensitivity before a solve: %v", err)
+	}
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	sensitivity, err := ls.Sensitivity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Print(sensitivity)
+
+	rows := sensitivity.Constraints
+	checkValue(t, "binding row from", rows[0].From, 3)
+	checkValue(t, "binding row till", rows[0].Till, 13.0/3)
+	checkValue(t, "slack row from", rows[1].From, 6)
+	if !math.IsInf(rows[1].Till, 1) {
+		t.Errorf("slack row till = %v, want +Inf", rows[1].Till)
+	}
+
+	costs := sensitivity.Objective
+	if costs[0].Basic || !costs[1].Basic {
+		t.Errorf("basic = %v, %v, want false, true", costs[0].Basic, costs[1].Basic)
+	}
+	checkValue(t, "x cost from", costs[0].From, 2)
+	if !math.IsInf(costs[0].Till, 1) {
+		t.Errorf("x cost till = %v, want +Inf", costs[0].Till)
+	}
+	checkValue(t, "y cost from", costs[1].From, 0)
+	checkValue(t, "y cost till", costs[1].Till, 3)
+
+	bounds := sensitivity.Bounds
+	checkValue(t, "x max from", bounds[0].MaxFrom, 2.5)
+	checkValue(t, "x max till", bounds[0].MaxTill, 4)
+	checkValue(t, "y min till", bounds[1].MinTill, 1)
+	checkValue(t, "y max from", bounds[1].MaxFrom, 1)
+
+	// the layout solver has no basis
+	if _, err := NewLinearSpec().Sensitivity(); err != ErrUnsupported {
+		t.Errorf("sensitivity of the ActiveSetSolver: %v", err)
+	}
+}
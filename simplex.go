@@ -0,0 +1,881 @@
+package lp
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	simplexPivotEpsilon     = 1e-9
+	simplexRefactorInterval = 50
+	// consecutive degenerate pivots before switching to Bland's rule
+	simplexStallLimit = 20
+)
+
+// status of a column in the bounded simplex
+const (
+	columnAtLower = iota
+	columnAtUpper
+	columnAtZero
+	columnBasic
+)
+
+// simplex is a bounded-variable revised simplex method for the lpModel
+//
+//	min c^Tx  s.t.  Ax + s = b,  lower <= x <= upper
+//
+// Every row i has a logical column s_i whose bounds encode the operator
+// (LE: s_i >= 0, GE: s_i <= 0, EQ: s_i = 0) and an artificial column that
+// is only used by phase 1. Column j < n is structural, n <= j < n+m is the
+// logical of row j-n and n+m <= j is the artificial of row j-n-m.
+type simplex struct {
+	model        *lpModel
+	m, n         int
+	lower, upper []float64
+	cost         []float64
+	x            []float64
+	status       []int
+	head         []int
+	// sign of the artificial column of each row, 0 if it is not used
+	artificialSign []float64
+
+	factor  basisFactor
+	updates int
+
+	// simplex multipliers of the rows and reduced costs of the columns
+	y []float64
+	d []float64
+
+	phase           int
+	feasible        bool
+	iterations      int
+	maxIterations   int
+	degenerateSteps int
+	bland           bool
+	// direction of unboundedness over the structural columns
+	ray []float64
+	// a row that can not be satisfied, -1 if unknown
+	infeasibleRow int
+	// stops the iterations when done
+	ctx        context.Context
+	tolerances Tolerances
+}
+
+func newSimplex(model *lpModel) *simplex {
+	s := &simplex{}
+	s.model = model
+	s.m = model.rows
+	s.n = model.columns
+
+	total := s.n + 2*s.m
+	s.lower = make([]float64, total)
+	s.upper = make([]float64, total)
+	s.cost = make([]float64, total)
+	s.x = make([]float64, total)
+	s.status = make([]int, total)
+	s.head = make([]int, s.m)
+	s.artificialSign = make([]float64, s.m)
+	s.y = make([]float64, s.m)
+	s.d = make([]float64, total)
+	s.factor = newSparseLU(s.m, model.tolerances.Pivot)
+	s.infeasibleRow = -1
+	s.ctx = context.Background()
+	s.tolerances = model.tolerances
+
+	copy(s.lower, model.lower)
+	copy(s.upper, model.upper)
+	for i := 0; i < s.m; i++ {
+		s.lower[s.n+i], s.upper[s.n+i] = logicalBounds(model.ops[i])
+	}
+
+	return s
+}
+
+// logicalBounds returns the bounds of the logical column of a row with the
+// operator op.
+func logicalBounds(op int) (float64, float64) {
+	switch op {
+	case OperatorLE:
+		return 0, math.Inf(1)
+	case OperatorGE:
+		return math.Inf(-1), 0
+	}
+	return 0, 0
+}
+
+// column writes column j of [A I diag(artificialSign)] to col.
+func (self *simplex) column(j int, col []float64) {
+	for i := range col {
+		col[i] = 0
+	}
+	switch {
+	case j < self.n:
+		column := self.model.column(j)
+		for k, i := range column.index {
+			col[i] = column.value[k]
+		}
+	case j < self.n+self.m:
+		col[j-self.n] = 1
+	default:
+		col[j-self.n-self.m] = self.artificialSign[j-self.n-self.m]
+	}
+}
+
+// sparseColumn returns column j of [A I diag(artificialSign)]; the
+// structural columns are shared with the model.
+func (self *simplex) sparseColumn(j int) sparseVector {
+	switch {
+	case j < self.n:
+		return *self.model.column(j)
+	case j < self.n+self.m:
+		return sparseVector{[]int{j - self.n}, []float64{1}}
+	}
+	i := j - self.n - self.m
+	return sparseVector{[]int{i}, []float64{self.artificialSign[i]}}
+}
+
+// dot returns v^Ta_j.
+func (self *simplex) dot(v []float64, j int) float64 {
+	switch {
+	case j < self.n:
+		return self.model.column(j).dot(v)
+	case j < self.n+self.m:
+		return v[j-self.n]
+	}
+	return v[j-self.n-self.m] * self.artificialSign[j-self.n-self.m]
+}
+
+// setNonbasic puts a nonbasic column on its lower bound, on its upper
+// bound if there is no lower one, or at zero if it is free.
+func (self *simplex) setNonbasic(j int) {
+	lower, upper := self.lower[j], self.upper[j]
+	switch {
+	case !math.IsInf(lower, -1):
+		self.status[j] = columnAtLower
+		self.x[j] = lower
+	case !math.IsInf(upper, 1):
+		self.status[j] = columnAtUpper
+		self.x[j] = upper
+	default:
+		self.status[j] = columnAtZero
+		self.x[j] = 0
+	}
+}
+
+// coldStart sets up the slack basis; rows whose logical cannot take the
+// residual get a basic artificial column. Returns false if the basis can
+// not be factorized.
+func (self *simplex) coldStart() bool {
+	for j := 0; j < self.n+self.m; j++ {
+		self.setNonbasic(j)
+	}
+
+	residual := make([]float64, self.m)
+	copy(residual, self.model.b)
+	for j := 0; j < self.n; j++ {
+		if self.x[j] == 0 {
+			continue
+		}
+		column := self.model.column(j)
+		for k, i := range column.index {
+			residual[i] -= column.value[k] * self.x[j]
+		}
+	}
+
+	for i := 0; i < self.m; i++ {
+		logical := self.n + i
+		artificial := self.n + self.m + i
+		self.lower[artificial] = 0
+		self.upper[artificial] = 0
+		self.artificialSign[i] = 0
+
+		if residual[i] >= self.lower[logical]-self.tolerances.PrimalFeasibility &&
+			residual[i] <= self.upper[logical]+self.tolerances.PrimalFeasibility {
+			self.head[i] = logical
+			self.status[logical] = columnBasic
+			self.x[logical] = residual[i]
+			self.status[artificial] = columnAtLower
+			self.x[artificial] = 0
+			continue
+		}
+
+		// the logical stays on the bound next to the residual
+		if residual[i] > self.upper[logical] {
+			self.status[logical] = columnAtUpper
+			self.x[logical] = self.upper[logical]
+		} else {
+			self.status[logical] = columnAtLower
+			self.x[logical] = self.lower[logical]
+		}
+		rest := residual[i] - self.x[logical]
+		self.artificialSign[i] = 1
+		if rest < 0 {
+			self.artificialSign[i] = -1
+		}
+		self.upper[artificial] = math.Inf(1)
+		self.head[i] = artificial
+		self.status[artificial] = columnBasic
+		self.x[artificial] = math.Abs(rest)
+	}
+
+	return self.refactor()
+}
+
+func (self *simplex) hasArtificials() bool {
+	for i := 0; i < self.m; i++ {
+		if self.artificialSign[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *simplex) refactor() bool {
+	columns := make([]sparseVector, self.m)
+	for k := 0; k < self.m; k++ {
+		columns[k] = self.sparseColumn(self.head[k])
+	}
+	self.updates = 0
+	return self.factor.factorize(columns)
+}
+
+// computeValues computes the basic values x_B = B^-1(b - Nx_N).
+func (self *simplex) computeValues() {
+	rhs := make([]float64, self.m)
+	copy(rhs, self.model.b)
+	for j := 0; j < self.n; j++ {
+		if self.status[j] == columnBasic || self.x[j] == 0 {
+			continue
+		}
+		column := self.model.column(j)
+		for k, i := range column.index {
+			rhs[i] -= column.value[k] * self.x[j]
+		}
+	}
+	for i := 0; i < self.m; i++ {
+		logical := self.n + i
+		if self.status[logical] != columnBasic {
+			rhs[i] -= self.x[logical]
+		}
+		artificial := self.n + self.m + i
+		if self.status[artificial] != columnBasic {
+			rhs[i] -= self.artificialSign[i] * self.x[artificial]
+		}
+	}
+	self.factor.ftran(rhs)
+	for i := 0; i < self.m; i++ {
+		self.x[self.head[i]] = rhs[i]
+	}
+}
+
+// computeDuals computes y = B^-Tc_B and the reduced costs d = c - A^Ty.
+func (self *simplex) computeDuals() {
+	for i := 0; i < self.m; i++ {
+		self.y[i] = self.cost[self.head[i]]
+	}
+	self.factor.btran(self.y)
+	for j := range self.d {
+		if self.status[j] == columnBasic {
+			self.d[j] = 0
+			continue
+		}
+		self.d[j] = self.cost[j] - self.dot(self.y, j)
+	}
+}
+
+// price selects the entering column and the direction it moves in, or -1
+// if the basis is optimal. Dantzig's rule is used unless the method stalls,
+// then Bland's rule prevents cycling.
+func (self *simplex) price() (int, float64) {
+	entering := -1
+	direction := 0.0
+	best := 0.0
+	for j := range self.d {
+		if self.status[j] == columnBasic || self.lower[j] == self.upper[j] {
+			continue
+		}
+		dj := self.d[j]
+		dir := 0.0
+		switch self.status[j] {
+		case columnAtLower:
+			if dj < -self.tolerances.DualFeasibility {
+				dir = 1
+			}
+		case columnAtUpper:
+			if dj > self.tolerances.DualFeasibility {
+				dir = -1
+			}
+		case columnAtZero:
+			if dj < -self.tolerances.DualFeasibility {
+				dir = 1
+			} else if dj > self.tolerances.DualFeasibility {
+				dir = -1
+			}
+		}
+		if dir == 0 {
+			continue
+		}
+		if self.bland {
+			return j, dir
+		}
+		if math.Abs(dj) > best {
+			best = math.Abs(dj)
+			entering = j
+			direction = dir
+		}
+	}
+	return entering, direction
+}
+
+// ratioTest returns the step length for moving the entering column q in
+// direction dir, and the basis position that leaves or -1 if q just moves
+// to its opposite bound. w holds B^-1a_q, and its entries up to tolerance
+// are no pivots.
+func (self *simplex) ratioTest(q int, dir float64, w []float64, tolerance float64) (float64, int) {
+	theta := math.Inf(1)
+	leaving := -1
+	if !math.IsInf(self.lower[q], -1) && !math.IsInf(self.upper[q], 1) {
+		theta = self.upper[q] - self.lower[q]
+	}
+
+	for i := 0; i < self.m; i++ {
+		alpha := dir * w[i]
+		if math.Abs(alpha) <= tolerance {
+			continue
+		}
+		k := self.head[i]
+		t := 0.0
+		if alpha > 0 {
+			if math.IsInf(self.lower[k], -1) {
+				continue
+			}
+			t = (self.x[k] - self.lower[k]) / alpha
+		} else {
+			if math.IsInf(self.upper[k], 1) {
+				continue
+			}
+			t = (self.upper[k] - self.x[k]) / -alpha
+		}
+		if t < 0 {
+			t = 0
+		}
+
+		if t < theta-self.tolerances.Pivot {
+			theta = t
+			leaving = i
+		} else if t <= theta+self.tolerances.Pivot && leaving >= 0 {
+			// tie: Bland's rule takes the smallest column index,
+			// otherwise prefer the largest pivot
+			if self.bland {
+				if k < self.head[leaving] {
+					leaving = i
+				}
+			} else if math.Abs(alpha) > math.Abs(w[leaving]) {
+				leaving = i
+			}
+		}
+	}
+	return theta, leaving
+}
+
+// primal runs the primal simplex with the current costs from a primal
+// feasible basis.
+func (self *simplex) primal() int {
+	w := make([]float64, self.m)
+	self.degenerateSteps = 0
+	self.bland = false
+
+	for {
+		self.computeDuals()
+		q, dir := self.price()
+		if q < 0 {
+			return ResultOptimal
+		}
+
+		if self.iterations >= self.maxIterations {
+			return self.limitResult()
+		}
+		if result, ok := interrupted(self.ctx); ok {
+			return result
+		}
+		self.iterations++
+
+		self.column(q, w)
+		self.factor.ftran(w)
+		// the pivots of a column of small entries may be small as well
+		tolerance := self.tolerances.Pivot * math.Min(maxAbs(w), 1)
+		theta, leaving := self.ratioTest(q, dir, w, tolerance)
+		if math.IsInf(theta, 1) && !self.isRay(q, dir, w, tolerance) {
+			if self.updates > 0 {
+				// B^-1a_q is inaccurate after the updates
+				if !self.refactor() {
+					return ResultNumFailure
+				}
+				self.computeValues()
+				continue
+			}
+			// a row blocks with a pivot below the tolerance, or the
+			// direction is a ray as far as the fresh factorization tells
+			theta, leaving = self.ratioTest(q, dir, w, 0)
+		}
+		if math.IsInf(theta, 1) {
+			self.ray = make([]float64, self.n)
+			if q < self.n {
+				self.ray[q] = dir
+			}
+			for i := 0; i < self.m; i++ {
+				if k := self.head[i]; k < self.n {
+					self.ray[k] = -dir * w[i]
+				}
+			}
+			return ResultUnbounded
+		}
+
+		if theta < self.tolerances.Pivot {
+			self.degenerateSteps++
+			if self.degenerateSteps > simplexStallLimit {
+				self.bland = true
+			}
+		} else {
+			self.degenerateSteps = 0
+			self.bland = false
+		}
+
+		self.x[q] += dir * theta
+		if leaving < 0 {
+			if dir > 0 {
+				self.status[q] = columnAtUpper
+				self.x[q] = self.upper[q]
+			} else {
+				self.status[q] = columnAtLower
+				self.x[q] = self.lower[q]
+			}
+		} else if !self.pivot(q, leaving, dir*w[leaving] > 0, w) {
+			return ResultNumFailure
+		}
+		self.computeValues()
+	}
+}
+
+// isRay returns whether moving column q in direction dir and the basic
+// columns by -dir B^-1a_q, given by w, goes towards no finite bound. The
+// logical columns follow from A instead of w, so that an inaccurate B^-1
+// does not make a ray of a direction that violates the rows. The entries of
+// w up to tolerance and the logicals up to the pivot tolerance relative to
+// the largest term of their row count as zero.
+func (self *simplex) isRay(q int, dir float64, w []float64, tolerance float64) bool {
+	d := make([]float64, len(self.x))
+	d[q] = dir
+	for i := 0; i < self.m; i++ {
+		d[self.head[i]] = -dir * w[i]
+	}
+	// the logicals take up the rest of Ad
+	column := make([]float64, self.m)
+	size := make([]float64, self.m)
+	for i := 0; i < self.m; i++ {
+		d[self.n+i] = 0
+	}
+	for j, value := range d {
+		if value == 0 || (j >= self.n && j < self.n+self.m) {
+			continue
+		}
+		self.column(j, column)
+		for i, a := range column {
+			d[self.n+i] -= a * value
+			size[i] = math.Max(size[i], math.Abs(a*value))
+		}
+	}
+
+	for j, value := range d {
+		zero := tolerance
+		if j >= self.n && j < self.n+self.m {
+			zero = self.tolerances.Pivot * size[j-self.n]
+		}
+		if (value < -zero && !math.IsInf(self.lower[j], -1)) ||
+			(value > zero && !math.IsInf(self.upper[j], 1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// objectiveValue returns c^Tx of the minimization including the soft
+// constraint penalties.
+func (self *simplex) objectiveValue() float64 {
+	value := self.model.offset
+	for j := 0; j < self.n; j++ {
+		value += self.model.c[j] * self.x[j]
+	}
+	return value
+}
+
+// limitResult returns the result for reaching the iteration limit.
+func (self *simplex) limitResult() int {
+	if self.degenerateSteps > 0 {
+		return ResultDegenerate
+	}
+	if self.feasible {
+		return ResultSubOptimal
+	}
+	return ResultNumFailure
+}
+
+// pivot makes column q basic at position r; the leaving column goes to
+// its lower bound if toLower is true and to its upper bound otherwise.
+func (self *simplex) pivot(q, r int, toLower bool, w []float64) bool {
+	k := self.head[r]
+	if toLower {
+		self.status[k] = columnAtLower
+		self.x[k] = self.lower[k]
+	} else {
+		self.status[k] = columnAtUpper
+		self.x[k] = self.upper[k]
+	}
+	self.head[r] = q
+	self.status[q] = columnBasic
+
+	self.updates++
+	if self.updates >= simplexRefactorInterval || !self.factor.update(r, w) {
+		return self.refactor()
+	}
+	return true
+}
+
+// removeArtificials fixes all artificial columns at zero and pivots the
+// basic ones out of the basis where possible. Artificials of redundant
+// rows stay basic at zero.
+func (self *simplex) removeArtificials() bool {
+	rho := make([]float64, self.m)
+	w := make([]float64, self.m)
+	for i := 0; i < self.m; i++ {
+		self.upper[self.n+self.m+i] = 0
+	}
+
+	for r := 0; r < self.m; r++ {
+		k := self.head[r]
+		if k < self.n+self.m {
+			continue
+		}
+		for i := range rho {
+			rho[i] = 0
+		}
+		rho[r] = 1
+		self.factor.btran(rho)
+
+		entering := -1
+		best := self.tolerances.Pivot
+		for j := 0; j < self.n+self.m; j++ {
+			if self.status[j] == columnBasic {
+				continue
+			}
+			if alpha := math.Abs(self.dot(rho, j)); alpha > best {
+				best = alpha
+				entering = j
+			}
+		}
+		if entering < 0 {
+			continue
+		}
+		self.column(entering, w)
+		self.factor.ftran(w)
+		if !self.pivot(entering, r, true, w) {
+			return false
+		}
+	}
+	self.computeValues()
+	return true
+}
+
+// activeRows returns the number of rows that hold with equality.
+func (self *simplex) activeRows() int {
+	count := 0
+	for i := 0; i < self.m; i++ {
+		logical := self.n + i
+		if math.Abs(self.x[logical]) <= self.tolerances.PrimalFeasibility {
+			count++
+		}
+	}
+	return count
+}
+
+// infeasibleConstraint returns the constraint of the row that could not be
+// satisfied by the last infeasible solve, or nil.
+func (self *simplex) infeasibleConstraint() *Constraint {
+	if self.infeasibleRow < 0 {
+		return nil
+	}
+	return self.model.constraints[self.infeasibleRow]
+}
+
+// solve runs the two-phase primal simplex method.
+func (self *simplex) solve() int {
+	if self.maxIterations <= 0 {
+		self.maxIterations = max(1000, 20*(self.n+2*self.m))
+	}
+	self.phase = 1
+	if !self.coldStart() {
+		return ResultNumFailure
+	}
+
+	if self.hasArtificials() {
+		for j := range self.cost {
+			self.cost[j] = 0
+		}
+		// the artificials cost their violation in unscaled units, so a
+		// row scaled down does not end phase 1 early; the cheapest costs 1
+		largest := 0.0
+		for i := 0; i < self.m; i++ {
+			largest = math.Max(largest, self.model.rowFactor(i))
+		}
+		for i := 0; i < self.m; i++ {
+			self.cost[self.n+self.m+i] = largest / self.model.rowFactor(i)
+		}
+		result := self.primal()
+		if result != ResultOptimal {
+			return result
+		}
+
+		infeasibility := 0.0
+		worst := 0.0
+		for i := 0; i < self.m; i++ {
+			value := self.x[self.n+self.m+i] / self.model.rowFactor(i)
+			infeasibility += value
+			if value > worst {
+				worst = value
+				self.infeasibleRow = i
+			}
+		}
+		if infeasibility > self.tolerances.PrimalFeasibility {
+			return ResultInfeasible
+		}
+		self.infeasibleRow = -1
+		if !self.removeArtificials() {
+			return ResultNumFailure
+		}
+	}
+
+	self.phase = 2
+	self.feasible = true
+	for j := range self.cost {
+		self.cost[j] = 0
+	}
+	copy(self.cost, self.model.c)
+	return self.primal()
+}
+
+// SimplexSolver solves the linear program given by the objective function,
+// the constraints and the variable bounds of a LinearSpec with the
+// two-phase bounded-variable primal simplex method. Soft constraints may be
+// violated at the cost of their penalties, which are added to the
+// objective.
+//
+// Solve returns ResultOptimal, ResultInfeasible or ResultUnbounded. If the
+// iteration limit is reached while the method stalls on degenerate pivots
+// the result is ResultDegenerate, otherwise ResultSubOptimal for a
+// feasible point and ResultNumFailure if no feasible point was found yet.
+type SimplexSolver struct {
+	ls            *LinearSpec
+	maxIterations int
+	iterations    int
+	infeasible    *Constraint
+	stats         SolveStats
+	// the last optimal basis for the sensitivity analysis
+	optimal *simplex
+	ray     *UnboundedRay
+}
+
+func NewSimplexSolver(ls *LinearSpec) *SimplexSolver {
+	ss := &SimplexSolver{}
+	ss.ls = ls
+
+	return ss
+}
+
+// SetMaxIterations sets the iteration limit; 0 selects a limit based on
+// the size of the problem.
+func (self *SimplexSolver) SetMaxIterations(iterations int) {
+	self.maxIterations = iterations
+}
+
+// Iterations gets the number of simplex iterations of the last solve.
+func (self *SimplexSolver) Iterations() int {
+	return self.iterations
+}
+
+func (self *SimplexSolver) Solve() int {
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves like Solve but stops with ResultTimeout or
+// ResultUserAbort when ctx is done. The variables get the last feasible
+// point if the second phase was reached.
+func (self *SimplexSolver) SolveContext(ctx context.Context) int {
+	return self.solve(ctx, (*simplex).solve)
+}
+
+// solve presolves the specification, runs method on the simplex of the
+// reduced problem and writes its outcome back.
+func (self *SimplexSolver) solve(ctx context.Context, method func(*simplex) int) int {
+	self.iterations = 0
+	self.optimal = nil
+	self.ray = nil
+	presolved := newPresolve(self.ls)
+	self.infeasible = presolved.infeasible
+	self.stats = SolveStats{PresolvedRows: presolved.removedRowCount,
+		PresolvedColumns: presolved.removedColumnCount}
+	if presolved.result != ResultOptimal {
+		solved := presolved.result == ResultPresolve
+		presolved.postsolve(solved, solved)
+		return presolved.result
+	}
+
+	s := newSimplex(presolved.model())
+	s.maxIterations = self.maxIterations
+	s.ctx = ctx
+	result := method(s)
+	self.iterations = s.iterations
+	self.infeasible = presolved.original(s.infeasibleConstraint())
+	if result == ResultUnbounded {
+		self.ray = presolved.postsolveRay(s.model.unboundedRay(s.ray))
+	}
+	self.stats.SimplexIterations = s.iterations
+	if s.feasible {
+		self.stats.ActiveConstraints = s.activeRows()
+	}
+
+	values := s.feasible && result != ResultNumFailure
+	if values {
+		s.model.setValues(s.x)
+	}
+	if result == ResultOptimal {
+		s.model.setDuals(s.y, s.d)
+		self.optimal = s
+	} else {
+		s.model.setDuals(nil, nil)
+	}
+	presolved.postsolve(values, result == ResultOptimal)
+	return result
+}
+
+// InfeasibleConstraint gets a constraint that could not be satisfied by the
+// last solve if it was infeasible.
+func (self *SimplexSolver) InfeasibleConstraint() *Constraint {
+	return self.infeasible
+}
+
+// Stats gets the statistics of the last solve.
+func (self *SimplexSolver) Stats() SolveStats {
+	return self.stats
+}
+
+// UnboundedRay gets the direction of unboundedness of the last solve, nil
+// if it was not unbounded.
+func (self *SimplexSolver) UnboundedRay() *UnboundedRay {
+	return self.ray
+}
+
+// Sensitivity gets the sensitivity analysis of the last solve, nil if it
+// was not optimal.
+func (self *SimplexSolver) Sensitivity() *Sensitivity {
+	if self.optimal == nil {
+		return nil
+	}
+	return self.optimal.sensitivity()
+}
+
+func (self *SimplexSolver) VariableAdded(variable *Variable) bool {
+	return true
+}
+
+func (self *SimplexSolver) VariableRemoved(variable *Variable) bool {
+	return true
+}
+
+func (self *SimplexSolver) VariableRangeChanged(variable *Variable) bool {
+	return true
+}
+
+func (self *SimplexSolver) ConstraintAdded(constraint *Constraint) bool {
+	return true
+}
+
+func (self *SimplexSolver) ConstraintRemoved(constraint *Constraint) bool {
+	return true
+}
+
+func (self *SimplexSolver) LeftSideChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *SimplexSolver) RightSideChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *SimplexSolver) OperatorChanged(constraint *Constraint) bool {
+	return true
+}
+
+func (self *SimplexSolver) ObjectiveChanged() bool {
+	return true
+}
+
+// SaveModel writes the specification in LP format.
+func (self *SimplexSolver) SaveModel(fileName string) error {
+	return saveLP(self.ls, fileName)
+}
+
+// MinSize minimizes width and height independently subject to the hard
+// constraints. An unbounded dimension is reported as -Inf.
+func (self *SimplexSolver) MinSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, 1, self.maxIterations)
+}
+
+// MaxSize maximizes width and height independently subject to the hard
+// constraints. An unbounded dimension is reported as +Inf.
+func (self *SimplexSolver) MaxSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, -1, self.maxIterations)
+}
+
+// optimizeSize minimizes sense * width and sense * height independently
+// over the constraints of model.
+func optimizeSize(model *lpModel, width, height *Variable, sense float64,
+	maxIterations int) (Size, error) {
+	w, err := optimizeVariable(model, width, sense, maxIterations)
+	if err != nil {
+		return Size{}, err
+	}
+	h, err := optimizeVariable(model, height, sense, maxIterations)
+	if err != nil {
+		return Size{}, err
+	}
+	return Size{w, h}, nil
+}
+
+// optimizeVariable minimizes sense * v over the constraints of model. An
+// unbounded v is -Inf for a minimization and +Inf for a maximization.
+func optimizeVariable(model *lpModel, v *Variable, sense float64,
+	maxIterations int) (float64, error) {
+	index := v.GlobalIndex()
+	if index < 0 {
+		return 0, &VariableError{v, ErrInvalidVariable}
+	}
+	objective := *model
+	objective.c = make([]float64, model.columns)
+	objective.c[index] = sense
+	objective.sense = 1
+
+	s := newSimplex(&objective)
+	s.maxIterations = maxIterations
+	result := s.solve()
+	switch result {
+	case ResultOptimal:
+		return s.x[index] * model.columnFactor(index), nil
+	case ResultUnbounded:
+		return math.Inf(-int(sense)), nil
+	case ResultInfeasible:
+		if constraint := s.infeasibleConstraint(); constraint != nil {
+			return 0, &ConstraintError{constraint, ErrInfeasible}
+		}
+	}
+	return 0, resultError(result)
+}
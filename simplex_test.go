@@ -0,0 +1,217 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func newSimplexSpec() *LinearSpec {
+	ls := NewLinearSpec()
+	ls.SetSolver(NewSimplexSolver(ls))
+	return ls
+}
+
+func checkValue(t *testing.T, name string, got, want float64) {
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("%v = %v, want %v", name, got, want)
+	}
+}
+
+func TestSimplexMaximize(t *testing.T) {
+	fmt.Println("Test Simplex Maximize")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, math.Inf(1))
+	y.SetRange(0, math.Inf(1))
+
+	ls.AddConstraint2([]float64{1.0}, []*Variable{x}, OperatorLE, 4)
+	ls.AddConstraint2([]float64{2.0}, []*Variable{y}, OperatorLE, 12)
+	ls.AddConstraint2([]float64{3.0, 2.0}, []*Variable{x, y}, OperatorLE, 18)
+	ls.SetObjective1([]float64{3.0, 5.0}, []*Variable{x, y}, OptMaximize)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 2)
+	checkValue(t, "y", y.Value(), 6)
+	checkValue(t, "objective", ls.ObjectiveValue(), 36)
+}
+
+func TestSimplexMinimize(t *testing.T) {
+	fmt.Println("Test Simplex Minimize")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, math.Inf(1))
+	y.SetRange(0, math.Inf(1))
+
+	ls.AddConstraint2([]float64{1.0, 2.0}, []*Variable{x, y}, OperatorEQ, 4)
+	ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{x, y}, OperatorLE, 1)
+	ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorGE, 1)
+	ls.SetObjective1([]float64{1.0, 1.0}, []*Variable{x, y}, OptMinimize)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 0)
+	checkValue(t, "y", y.Value(), 2)
+	checkValue(t, "objective", ls.ObjectiveValue(), 2)
+}
+
+func TestSimplexInfeasible(t *testing.T) {
+	fmt.Println("Test Simplex Infeasible")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+
+	ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorLE, 1)
+	ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x, y}, OperatorGE, 3)
+
+	if result, _ := ls.Solve(); result != ResultInfeasible {
+		t.Errorf("result = %v, want %v", result, ResultInfeasible)
+	}
+}
+
+func TestSimplexUnbounded(t *testing.T) {
+	fmt.Println("Test Simplex Unbounded")
+
+	ls := newSimplexSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	x.SetRange(0, math.Inf(1))
+	y.SetRange(0, math.Inf(1))
+
+	ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{x, y}, OperatorLE, 1)
+	ls.SetObjective1([]float64{1.0}, []*Variable{x}, OptMaximize)
+
+	if result, _ := ls.Solve(); result != ResultUnbounded {
+		t.Errorf("result = %v, want %v", result, ResultUnbounded)
+	}
+
+	size, _ := ls.MaxSize(x, y)
+	if !math.IsInf(size.W, 1) || !math.IsInf(size.H, 1) {
+		t.Errorf("MaxSize = %v, want unbounded", size)
+	}
+	size, _ = ls.MinSize(x, y)
+	checkValue(t, "min width", size.W, 0)
+	checkValue(t, "min height", size.H, 0)
+
+	x.SetMin(math.Inf(-1))
+	y.SetMin(math.Inf(-1))
+	size, _ = ls.MinSize(x, y)
+	if !math.IsInf(size.W, -1) || !math.IsInf(size.H, -1) {
+		t.Errorf("MinSize = %v, want unbounded", size)
+	}
+}
+
+// TestSimplexSmallPivots solves a badly scaled LP whose entering column
+// first meets no blocking row above the pivot tolerance, although the
+// optimum is finite.
+func TestSimplexSmallPivots(t *testing.T) {
+	fmt.Println("Test Simplex SmallPivots")
+
+	build := func(ls *LinearSpec) {
+		x := make([]*Variable, 5)
+		for i := range x {
+			x[i], _ = ls.AddVariable(nil)
+		}
+		x[0].SetRange(0, math.Inf(1))
+		x[1].SetRange(-0.003, 0.5)
+		x[2].SetRange(-0.02, 1e4)
+		x[3].SetRange(math.Inf(-1), math.Inf(1))
+		x[4].SetRange(0, math.Inf(1))
+		ls.AddConstraint2([]float64{-7e-4, 20, 0, 0, 8e-3}, x, OperatorEQ, 0.07)
+		ls.AddConstraint2([]float64{-5e-6, 9e6, -30, -1, 0}, x, OperatorLE, 9e-5)
+		ls.AddConstraint2([]float64{0.08, 6e-4, 0, 9e-5, 0.03}, x, OperatorGE, -4e6)
+		ls.AddConstraint2([]float64{6e6, -2e6, 1e6, 0, 8e-4}, x, OperatorGE, 9e-3)
+		ls.AddConstraint2([]float64{3, 0, 0, 0, 0}, x, OperatorGE, -1e7)
+		ls.AddConstraint2([]float64{0, 3e-5, 0, 0, 5e-4}, x, OperatorLE, 7e5)
+		ls.SetObjective1([]float64{-1000, 2, -7e-3, 5e-4, -50}, x, OptMinimize)
+	}
+	exact := NewLinearSpec()
+	exact.SetSolver(NewExactSolver(exact))
+	build(exact)
+	if result, _ := exact.Solve(); result != ResultOptimal {
+		t.Fatalf("exact result = %v, want %v", result, ResultOptimal)
+	}
+	want := exact.ObjectiveValue()
+
+	for _, solver := range []string{"primal", "dual", "branch and bound"} {
+		ls := NewLinearSpec()
+		switch solver {
+		case "primal":
+			ls.SetSolver(NewSimplexSolver(ls))
+		case "dual":
+			ls.SetSolver(NewDualSimplexSolver(ls))
+		default:
+			ls.SetSolver(NewBranchAndBoundSolver(ls))
+		}
+		build(ls)
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Errorf("%v: result = %v, want %v", solver, result, ResultOptimal)
+			continue
+		}
+		if got := ls.ObjectiveValue(); math.Abs(got-want) > 1e-9*math.Abs(want) {
+			t.Errorf("%v: objective = %v, want %v", solver, got, want)
+		}
+	}
+}
+
+// Beale's example cycles with the textbook pivoting rules.
+func newBealeSpec() (*LinearSpec, []*Variable) {
+	ls := newSimplexSpec()
+	x := make([]*Variable, 4)
+	for i := range x {
+		x[i], _ = ls.AddVariable(nil)
+		x[i].SetRange(0, math.Inf(1))
+	}
+	ls.AddConstraint2([]float64{0.25, -8, -1, 9}, x, OperatorLE, 0)
+	ls.AddConstraint2([]float64{0.5, -12, -0.5, 3}, x, OperatorLE, 0)
+	ls.AddConstraint2([]float64{1}, x[2:3], OperatorLE, 1)
+	ls.SetObjective1([]float64{-0.75, 20, -0.5, 6}, x, OptMinimize)
+
+	return ls, x
+}
+
+func TestSimplexDegenerate(t *testing.T) {
+	fmt.Println("Test Simplex Degenerate")
+
+	ls, x := newBealeSpec()
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "objective", ls.ObjectiveValue(), -1.25)
+	checkValue(t, "x1", x[0].Value(), 1)
+	checkValue(t, "x3", x[2].Value(), 1)
+
+	// the first pivot of Beale's example is degenerate
+	ls, _ = newBealeSpec()
+	ls.Solver().(*SimplexSolver).SetMaxIterations(1)
+	if result, _ := ls.Solve(); result != ResultDegenerate {
+		t.Errorf("result = %v, want %v", result, ResultDegenerate)
+	}
+}
+
+func TestSimplexSoftConstraints(t *testing.T) {
+	fmt.Println("Test Simplex SoftConstraints")
+
+	for _, test := range []struct {
+		penalty, want float64
+	}{{5, 3}, {1, 10}} {
+		ls := newSimplexSpec()
+		x, _ := ls.AddVariable(nil)
+		x.SetRange(0, 10)
+		ls.AddConstraint4([]float64{1.0}, []*Variable{x}, OperatorLE, 3, test.penalty, 0)
+		ls.SetObjective1([]float64{2.0}, []*Variable{x}, OptMaximize)
+
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Fatalf("result = %v, want %v", result, ResultOptimal)
+		}
+		checkValue(t, "x", x.Value(), test.want)
+	}
+}
@@ -1,7 +1,7 @@
 package lp
 
+import "context"
 import "math"
-import "fmt"
 
 type softInEqData struct {
 	slack              *Summand
@@ -33,10 +33,18 @@ func (self *QPSolver) ConstraintAdded(constraint *Constraint) bool {
 		coeff = 1
 	}
 
-	data.slack = &Summand{coeff, self.ls.AddVariable(nil)}
-	data.slack.Var().SetRange(0, 20000)
-	data.minSlackConstraint = self.ls.AddConstraint4([]float64{1.0}, []*Variable{data.slack.Var()},
+	slack, err := self.ls.AddVariable(nil)
+	if err != nil {
+		return false
+	}
+	data.slack = &Summand{coeff, slack}
+	data.slack.Var().SetRange(0, math.Inf(1))
+	data.minSlackConstraint, err = self.ls.AddConstraint4([]float64{1.0}, []*Variable{data.slack.Var()},
 		OperatorEQ, 0.0, constraint.PenaltyNeg(), constraint.PenaltyPos())
+	if err != nil {
+		self.ls.RemoveVariable(slack)
+		return false
+	}
 
 	data.constraint = constraint
 	self.inEqSlackConstraints.AddItem(data)
@@ -77,6 +85,16 @@ func (self *QPSolver) destroyData(data *softInEqData) {
 	data.slack = nil
 }
 
+// softInEqSlacks marks the slack variables of the soft inequalities and
+// their penalized rows.
+func (self *QPSolver) softInEqSlacks(slacks map[*Variable]bool, rows map[*Constraint]bool) {
+	for i := 0; i < self.inEqSlackConstraints.Len(); i++ {
+		data := self.inEqSlackConstraints.GetAt(i)
+		slacks[data.slack.Var()] = true
+		rows[data.minSlackConstraint] = true
+	}
+}
+
 func (self *QPSolver) isSoftInequality(constraint *Constraint) bool {
 	if constraint.PenaltyNeg() <= 0 && constraint.PenaltyPos() <= 0 {
 		return false
@@ -89,12 +107,27 @@ func (self *QPSolver) isSoftInequality(constraint *Constraint) bool {
 
 type ActiveSetSolver struct {
 	*QPSolver
-	variables             *VariableList
-	constraints           *ConstraintList
-	variableGEConstraints *ConstraintList
-	variableLEConstraints *ConstraintList
+	variables   *VariableList
+	constraints *ConstraintList
+	stats       SolveStats
+	farkas      *FarkasCertificate
+	residuals   *Residuals
+	// maximum number of iterative refinement steps
+	refinementSteps int
+	// whether MinSize solves, without the objective function
+	sizing bool
 }
 
+// how solveEq, which only finds non-negative solutions, sees a variable
+const (
+	// x = min + p
+	boundShifted = iota
+	// x = max - p
+	boundMirrored
+	// x = p - q
+	boundSplit
+)
+
 func NewActiveSetSolver(ls *LinearSpec) *ActiveSetSolver {
 	ass := &ActiveSetSolver{}
 	ass.QPSolver = newQPSolver(ls)
@@ -102,38 +135,103 @@ func NewActiveSetSolver(ls *LinearSpec) *ActiveSetSolver {
 	ass.variables = ls.UsedVariables()
 	ass.constraints = ls.Constraints()
 
-	ass.variableGEConstraints = newConstraintList()
-	ass.variableLEConstraints = newConstraintList()
-
 	return ass
 }
 
 func (self *ActiveSetSolver) Solve() int {
-	nConstraints := self.constraints.Len()
+	return self.SolveContext(context.Background())
+}
+
+// SolveContext solves like Solve but stops with ResultTimeout or
+// ResultUserAbort when ctx is done. Once a feasible solution was found the
+// variables get the last one of the active set iterations. Values that
+// violate a hard constraint or a bound give ResultSubOptimal or
+// ResultNumFailure, see SetRefinementSteps and Residuals.
+func (self *ActiveSetSolver) SolveContext(ctx context.Context) int {
+	self.stats = SolveStats{}
+	self.farkas = nil
+	self.residuals = nil
+	presolved := newPresolve(self.ls)
+	self.stats.PresolvedRows = presolved.removedRowCount
+	self.stats.PresolvedColumns = presolved.removedColumnCount
+	if presolved.result != ResultOptimal {
+		solved := presolved.result == ResultPresolve
+		presolved.postsolve(solved, solved)
+		if solved {
+			return self.checkResiduals(presolved.result)
+		}
+		return presolved.result
+	}
+	nConstraints := presolved.constraints.Len()
 	nVariables := self.variables.Len()
 
-	if nVariables > nConstraints {
-		return ResultInfeasible
+	// Every variable is written with non-negative p and q as x = min + p,
+	// x = max - p or, if it is free, x = p - q. A variable with both bounds
+	// keeps p <= max - min as an upper bound of its column.
+	transform := make([]int, nVariables)
+	offset := make([]float64, nVariables)
+	negativeColumn := make([]int, nVariables)
+	nColumns := nVariables
+	lower := make([]float64, nVariables)
+	upper := make([]float64, nVariables)
+	for i := 0; i < nVariables; i++ {
+		lower[i], upper[i] = presolved.bounds(self.variables.GetAt(i))
+		switch {
+		case !math.IsInf(lower[i], -1):
+			transform[i] = boundShifted
+			offset[i] = lower[i]
+		case !math.IsInf(upper[i], 1):
+			transform[i] = boundMirrored
+			offset[i] = upper[i]
+		default:
+			transform[i] = boundSplit
+			negativeColumn[i] = nColumns
+			nColumns++
+		}
 	}
+	nColumns += nConstraints
 
 	// First find an initial solution and the optimize it using the
 	// active set method
-	system := NewEquationSystem(nConstraints, nVariables+nConstraints)
+	system := NewEquationSystem(nConstraints, nColumns)
+	system.SetTolerances(self.ls.Tolerances())
+	for i := 0; i < nVariables; i++ {
+		if transform[i] == boundShifted && !math.IsInf(upper[i], 1) {
+			system.SetUpper(i, upper[i]-lower[i])
+		}
+	}
 
-	slackIndex := nVariables
+	slackIndex := nColumns - nConstraints
 	// set constraint matrix and add slack variables if necessary
 	rowIndex := 0
+	hardConstraints := newConstraintList()
 	for c := 0; c < nConstraints; c++ {
-		constraint := self.constraints.GetAt(c)
+		constraint := presolved.constraints.GetAt(c)
 		if constraint.IsSoft() {
 			continue
 		}
+		hardConstraints.AddItem(constraint)
 		leftSide := constraint.LeftSide()
-		*(system.B(rowIndex)) = constraint.RightSide()
+		rightSide := constraint.RightSide()
 		for sIndex := 0; sIndex < leftSide.Len(); sIndex++ {
 			summand := leftSide.GetAt(sIndex)
 			coefficient := summand.Coeff()
-			*(system.A(rowIndex, summand.VariableIndex())) = coefficient
+			variable := summand.VariableIndex()
+			rightSide -= coefficient * offset[variable]
+			switch transform[variable] {
+			case boundMirrored:
+				*(system.A(rowIndex, variable)) = -coefficient
+			case boundSplit:
+				*(system.A(rowIndex, variable)) = coefficient
+				*(system.A(rowIndex, negativeColumn[variable])) = -coefficient
+			default:
+				*(system.A(rowIndex, variable)) = coefficient
+			}
+		}
+		*(system.B(rowIndex)) = rightSide
+		// a penalized equality gives way to the hard rows
+		if constraint.PenaltyNeg() > 0 || constraint.PenaltyPos() > 0 {
+			system.SetSoft(rowIndex)
 		}
 		if constraint.Op() == OperatorLE {
 			*(system.A(rowIndex, slackIndex)) = 1.0
@@ -146,25 +244,130 @@ func (self *ActiveSetSolver) Solve() int {
 	}
 
 	system.SetRows(rowIndex)
+	system.Scale(self.ls.Scaling())
 	system.RemoveLinearlyDependentRows()
+	self.stats.RemovedRows = rowIndex - system.Rows()
+	if system.InfeasibleRow() >= 0 {
+		self.setFarkas(system, hardConstraints, presolved)
+		return ResultInfeasible
+	}
 	system.RemoveUnusedVariables()
+	self.stats.RemovedColumns = nColumns - system.Columns()
 
-	if !solveEq(system) {
+	iterations, solved := solveEq(ctx, system)
+	self.stats.EquationIterations = iterations
+	if !solved {
+		if result, ok := interrupted(ctx); ok {
+			return result
+		}
+		self.setFarkas(system, hardConstraints, presolved)
 		return ResultInfeasible
 	}
 
-	results := make([]float64, nVariables+nConstraints)
-	system.Results(results, nVariables+nConstraints)
-	optimizer := NewLayoutOptimizer(self.constraints, nVariables)
-	optimizer.Solve(results)
-    fmt.Println(results)
+	results := make([]float64, nColumns)
+	system.Results(results, nColumns)
+	for i := 0; i < nVariables; i++ {
+		switch transform[i] {
+		case boundShifted:
+			results[i] += offset[i]
+		case boundMirrored:
+			results[i] = offset[i] - results[i]
+		default:
+			results[i] -= results[negativeColumn[i]]
+		}
+	}
+
+	constraints := presolved.constraints
+	optimizer := NewLayoutOptimizer(constraints, nVariables)
+	optimizer.SetBounds(lower, upper)
+	optimizer.SetTolerances(self.ls.Tolerances())
+	if self.ls.HasQuadraticObjective() && !self.sizing {
+		optimizer.SetObjective(self.ls.quadraticObjective())
+	}
+	solved = optimizer.SolveContext(ctx, results)
+	self.stats.ActiveSetIterations = optimizer.Iterations()
+	self.stats.ActiveConstraints = optimizer.ActiveConstraints()
 
 	// back to the variables
+	for c := 0; c < constraints.Len(); c++ {
+		constraints.GetAt(c).dual = 0
+		if solved {
+			constraints.GetAt(c).dual = optimizer.Duals()[c]
+		}
+	}
 	for i := 0; i < nVariables; i++ {
-		self.variables.GetAt(i).SetValue(results[i])
+		variable := self.variables.GetAt(i)
+		variable.SetValue(results[i])
+		variable.reducedCost = 0
+		if solved {
+			variable.reducedCost = optimizer.BoundDuals()[i]
+		}
+	}
+	presolved.postsolve(true, solved)
+
+	result := self.checkResiduals(ResultOptimal)
+	if interruption, ok := interrupted(ctx); ok {
+		return interruption
+	}
+	return result
+}
+
+// setFarkas keeps the multipliers of the infeasible row of the system as
+// the Farkas certificate, unless the presolve changed the constraints.
+func (self *ActiveSetSolver) setFarkas(system *EquationSystem, hardConstraints *ConstraintList,
+	presolved *presolve) {
+	if row := system.InfeasibleRow(); row >= 0 && !presolved.active {
+		self.farkas = &FarkasCertificate{hardConstraints,
+			copyVector(system.Multipliers(row)[:hardConstraints.Len()]), self.ls.tolerances}
 	}
+}
+
+// checkResiduals computes the residuals of the values and returns result
+// if they hold within the primal feasibility tolerance. Otherwise the
+// values are refined while steps are left, and the result is
+// ResultSubOptimal if that made them hold, as they moved from the
+// optimum, and ResultNumFailure if not.
+func (self *ActiveSetSolver) checkResiduals(result int) int {
+	tolerances := self.ls.Tolerances()
+	self.residuals = newResiduals(self.ls.Constraints())
+	if self.residuals.MaxViolation() <= tolerances.PrimalFeasibility {
+		return result
+	}
+	for step := 1; step <= self.refinementSteps; step++ {
+		if !self.residuals.refine(tolerances) {
+			break
+		}
+		self.residuals = newResiduals(self.ls.Constraints())
+		self.residuals.RefinementSteps = step
+		if self.residuals.MaxViolation() <= tolerances.PrimalFeasibility {
+			return ResultSubOptimal
+		}
+	}
+	return ResultNumFailure
+}
 
-	return ResultOptimal
+// SetRefinementSteps sets the maximum number of iterative refinement steps
+// for values that violate a hard constraint or a bound; 0, the default,
+// only checks them.
+func (self *ActiveSetSolver) SetRefinementSteps(steps int) {
+	self.refinementSteps = steps
+}
+
+// Residuals gets the residuals of the values of the last solve, nil if it
+// found none.
+func (self *ActiveSetSolver) Residuals() *Residuals {
+	return self.residuals
+}
+
+// Stats gets the statistics of the last solve.
+func (self *ActiveSetSolver) Stats() SolveStats {
+	return self.stats
+}
+
+// FarkasCertificate gets the proof of infeasibility of the last solve, nil
+// if it was not infeasible or solveEq found no proof.
+func (self *ActiveSetSolver) FarkasCertificate() *FarkasCertificate {
+	return self.farkas
 }
 
 func (self *ActiveSetSolver) VariableAdded(variable *Variable) bool {
@@ -172,45 +375,10 @@ func (self *ActiveSetSolver) VariableAdded(variable *Variable) bool {
 }
 
 func (self *ActiveSetSolver) VariableRemoved(variable *Variable) bool {
-	self.variableGEConstraints.RemoveItemAt(variable.GlobalIndex())
-	self.variableLEConstraints.RemoveItemAt(variable.GlobalIndex())
 	return true
 }
 
 func (self *ActiveSetSolver) VariableRangeChanged(variable *Variable) bool {
-	min := variable.Min()
-	max := variable.Max()
-	variableIndex := variable.GlobalIndex()
-
-	constraintGE := self.variableGEConstraints.GetAt(variableIndex)
-	constraintLE := self.variableLEConstraints.GetAt(variableIndex)
-
-	if constraintGE == nil && min > -20000 {
-		constraintGE = self.ls.AddConstraint2([]float64{1.0}, []*Variable{variable},
-			OperatorGE, 0.0)
-		if constraintGE == nil {
-			return false
-		}
-		self.variableGEConstraints.RemoveItemAt(variableIndex)
-		self.variableGEConstraints.AddItemAt(constraintGE, variableIndex)
-	}
-
-	if constraintLE == nil && max < 20000 {
-		constraintLE = self.ls.AddConstraint2([]float64{1.0}, []*Variable{variable},
-			OperatorLE, 20000)
-		if constraintLE == nil {
-			return false
-		}
-		self.variableLEConstraints.RemoveItemAt(variableIndex)
-		self.variableLEConstraints.AddItemAt(constraintLE, variableIndex)
-	}
-
-	if constraintGE != nil {
-		constraintGE.SetRightSide(min)
-	}
-	if constraintLE != nil {
-		constraintLE.SetRightSide(max)
-	}
 	return true
 }
 
@@ -234,8 +402,22 @@ func (self *ActiveSetSolver) OperatorChanged(constraint *Constraint) bool {
 	return true
 }
 
-func (self *ActiveSetSolver) SaveModel(fileName string) bool {
-	return false
+// ObjectiveChanged refuses linear objective functions: the active set
+// method only minimizes the soft constraint penalties of a layout and a
+// quadratic objective.
+func (self *ActiveSetSolver) ObjectiveChanged() bool {
+	return !self.ls.HasObjective()
+}
+
+// QuadraticObjectiveChanged accepts convex quadratic objective functions,
+// which are minimized together with the soft constraint penalties.
+func (self *ActiveSetSolver) QuadraticObjectiveChanged() bool {
+	return true
+}
+
+// SaveModel writes the specification in LP format.
+func (self *ActiveSetSolver) SaveModel(fileName string) error {
+	return saveLP(self.ls, fileName)
 }
 
 func (self *ActiveSetSolver) removeSoftConstraint(list *ConstraintList) {
@@ -245,7 +427,7 @@ func (self *ActiveSetSolver) removeSoftConstraint(list *ConstraintList) {
 		if !constraint.IsSoft() {
 			continue
 		}
-		if self.ls.RemoveConstraint(constraint) == true {
+		if self.ls.RemoveConstraint(constraint) == nil {
 			list.AddItem(constraint)
 		}
 	}
@@ -254,58 +436,55 @@ func (self *ActiveSetSolver) removeSoftConstraint(list *ConstraintList) {
 func (self *ActiveSetSolver) addSoftConstraint(list *ConstraintList) {
 	for i := 0; i < list.Len(); i++ {
 		constraint := list.GetAt(i)
-		if self.ls.AddConstraint(constraint) == false {
+		if self.ls.AddConstraint(constraint) != nil {
 			constraint = nil
 		}
 	}
 }
 
-func (self *ActiveSetSolver) MinSize(width, height *Variable) Size {
+// solveSize solves the hard constraints with width and height softly set
+// to value, ignoring the objective function.
+func (self *ActiveSetSolver) solveSize(width, height *Variable, value float64) (int, error) {
+	self.sizing = true
+	defer func() { self.sizing = false }()
 	softConstraints := newConstraintList()
 	self.removeSoftConstraint(softConstraints)
+	defer self.addSoftConstraint(softConstraints)
 
-	heightConstraint := self.ls.AddConstraint4([]float64{1.0}, []*Variable{height},
-		OperatorEQ, 0, 5, 5)
-	widthConstraint := self.ls.AddConstraint4([]float64{1.0}, []*Variable{width},
-		OperatorEQ, 0, 5, 5)
-	result := self.Solve()
-	self.ls.RemoveConstraint(heightConstraint)
-	self.ls.RemoveConstraint(widthConstraint)
-
-	self.addSoftConstraint(softConstraints)
-
-	if result == ResultUnbounded {
-		return Size{0, 0}
+	heightConstraint, err := self.ls.AddConstraint4([]float64{1.0}, []*Variable{height},
+		OperatorEQ, value, 5, 5)
+	if err != nil {
+		return ResultError, err
 	}
-	if result != ResultOptimal {
-		fmt.Println("Could not solve the layout specification (%d).", result)
+	defer self.ls.RemoveConstraint(heightConstraint)
+	widthConstraint, err := self.ls.AddConstraint4([]float64{1.0}, []*Variable{width},
+		OperatorEQ, value, 5, 5)
+	if err != nil {
+		return ResultError, err
 	}
+	defer self.ls.RemoveConstraint(widthConstraint)
 
-	return Size{width.Value(), height.Value()}
+	return self.Solve(), nil
 }
 
-func (self *ActiveSetSolver) MaxSize(width, height *Variable) Size {
-	softConstraints := newConstraintList()
-	self.removeSoftConstraint(softConstraints)
-
-	hugeValue := 32000.00
-	heightConstraint := self.ls.AddConstraint4([]float64{1.0}, []*Variable{height},
-		OperatorEQ, hugeValue, 5, 5)
-	widthConstraint := self.ls.AddConstraint4([]float64{1.0}, []*Variable{width},
-		OperatorEQ, hugeValue, 5, 5)
-	result := self.Solve()
-	self.ls.RemoveConstraint(heightConstraint)
-	self.ls.RemoveConstraint(widthConstraint)
-
-	self.addSoftConstraint(softConstraints)
-
+func (self *ActiveSetSolver) MinSize(width, height *Variable) (Size, error) {
+	result, err := self.solveSize(width, height, 0)
+	if err != nil {
+		return Size{}, err
+	}
 	if result == ResultUnbounded {
-		return Size{math.MaxFloat64, math.MaxFloat64}
+		return Size{0, 0}, nil
 	}
 	if result != ResultOptimal {
-		fmt.Println("Could not solve the layout specification (%d).", result)
+		return Size{}, resultError(result)
 	}
 
-	return Size{width.Value(), height.Value()}
+	return Size{width.Value(), height.Value()}, nil
+}
 
+// MaxSize maximizes width and height independently subject to the hard
+// constraints and the variable bounds. An unbounded dimension is reported
+// as +Inf.
+func (self *ActiveSetSolver) MaxSize(width, height *Variable) (Size, error) {
+	return optimizeSize(newLPModel(self.ls, false), width, height, -1, 0)
 }
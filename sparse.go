@@ -0,0 +1,280 @@
+package lp
+
+import (
+	"math"
+	"sort"
+)
+
+// sparseVector stores the non-zero entries of a vector sorted by index.
+type sparseVector struct {
+	index []int
+	value []float64
+}
+
+// search returns the position of the first entry with an index >= j.
+func (self *sparseVector) search(j int) int {
+	return sort.SearchInts(self.index, j)
+}
+
+func (self *sparseVector) at(j int) float64 {
+	k := self.search(j)
+	if k < len(self.index) && self.index[k] == j {
+		return self.value[k]
+	}
+	return 0
+}
+
+// ref returns a pointer to entry j and inserts it if it is missing.
+func (self *sparseVector) ref(j int) *float64 {
+	k := self.search(j)
+	if k == len(self.index) || self.index[k] != j {
+		self.index = append(self.index, 0)
+		self.value = append(self.value, 0)
+		copy(self.index[k+1:], self.index[k:])
+		copy(self.value[k+1:], self.value[k:])
+		self.index[k] = j
+		self.value[k] = 0
+	}
+	return &self.value[k]
+}
+
+// remove drops entry j.
+func (self *sparseVector) remove(j int) {
+	k := self.search(j)
+	if k < len(self.index) && self.index[k] == j {
+		self.index = append(self.index[:k], self.index[k+1:]...)
+		self.value = append(self.value[:k], self.value[k+1:]...)
+	}
+}
+
+// dropZeros removes the entries that are zero.
+func (self *sparseVector) dropZeros() {
+	k := 0
+	for l, j := range self.index {
+		if self.value[l] != 0 {
+			self.index[k], self.value[k] = j, self.value[l]
+			k++
+		}
+	}
+	self.index, self.value = self.index[:k], self.value[:k]
+}
+
+func (self *sparseVector) divide(q float64) {
+	for k := range self.value {
+		self.value[k] /= q
+	}
+}
+
+// axpy adds q * x and drops the entries that cancel out.
+func (self *sparseVector) axpy(q float64, x *sparseVector) {
+	index := make([]int, 0, len(self.index)+len(x.index))
+	value := make([]float64, 0, len(self.index)+len(x.index))
+	i, k := 0, 0
+	for i < len(self.index) || k < len(x.index) {
+		var j int
+		var v float64
+		switch {
+		case k == len(x.index) || (i < len(self.index) && self.index[i] < x.index[k]):
+			j, v = self.index[i], self.value[i]
+			i++
+		case i == len(self.index) || x.index[k] < self.index[i]:
+			j, v = x.index[k], x.value[k]*q
+			k++
+		default:
+			j, v = self.index[i], self.value[i]+x.value[k]*q
+			i++
+			k++
+		}
+		if v != 0 {
+			index = append(index, j)
+			value = append(value, v)
+		}
+	}
+	self.index, self.value = index, value
+}
+
+// dot returns the inner product with the dense vector x.
+func (self *sparseVector) dot(x []float64) float64 {
+	sum := 0.0
+	for k, j := range self.index {
+		sum += self.value[k] * x[j]
+	}
+	return sum
+}
+
+func (self *sparseVector) copy() sparseVector {
+	return sparseVector{append([]int(nil), self.index...),
+		append([]float64(nil), self.value...)}
+}
+
+// sparseMatrix is a compressed row (CSR) matrix whose rows may grow during
+// an elimination. The transpose is the compressed column (CSC) form.
+type sparseMatrix struct {
+	m, n int
+	rows []sparseVector
+}
+
+func newSparseMatrix(m, n int) *sparseMatrix {
+	return &sparseMatrix{m, n, make([]sparseVector, m)}
+}
+
+func (self *sparseMatrix) at(i, j int) float64 {
+	return self.rows[i].at(j)
+}
+
+func (self *sparseMatrix) ref(i, j int) *float64 {
+	return self.rows[i].ref(j)
+}
+
+// multiplyVector computes y = Ax.
+func (self *sparseMatrix) multiplyVector(x, y []float64) {
+	for i := range self.rows {
+		y[i] = self.rows[i].dot(x)
+	}
+}
+
+func (self *sparseMatrix) transpose() *sparseMatrix {
+	t := newSparseMatrix(self.n, self.m)
+	for i := range self.rows {
+		row := &self.rows[i]
+		for k, j := range row.index {
+			t.rows[j].index = append(t.rows[j].index, i)
+			t.rows[j].value = append(t.rows[j].value, row.value[k])
+		}
+	}
+	return t
+}
+
+func (self *sparseMatrix) copy() *sparseMatrix {
+	c := newSparseMatrix(self.m, self.n)
+	for i := range self.rows {
+		c.rows[i] = self.rows[i].copy()
+	}
+	return c
+}
+
+// sparseDependencies marks the linearly independent rows of a and returns
+// their number. It eliminates the columns in order with the largest entry
+// as pivot and skips columns without an entry that is not zero. a is
+// overwritten. If combinations is not nil its rows get the same row
+// operations as those of a, so the dependent rows of a identity matrix end
+// up as the combinations of the rows of a that vanish.
+func sparseDependencies(a *sparseMatrix, independent []bool, zero float64, combinations *sparseMatrix) int {
+	eliminated := make([]bool, a.m)
+	columnRows := make([][]int, a.n)
+	for i := range a.rows {
+		independent[i] = false
+		for _, j := range a.rows[i].index {
+			columnRows[j] = append(columnRows[j], i)
+		}
+	}
+
+	count := 0
+	for column := 0; column < a.n && count < a.m; column++ {
+		// the largest entry of the column
+		pivotRow := -1
+		pivotValue := 0.0
+		for _, i := range columnRows[column] {
+			if value := math.Abs(a.at(i, column)); !eliminated[i] && value > pivotValue {
+				pivotRow = i
+				pivotValue = value
+			}
+		}
+		if pivotValue < zero {
+			continue
+		}
+
+		eliminated[pivotRow] = true
+		independent[pivotRow] = true
+		count++
+
+		pivot := &a.rows[pivotRow]
+		for _, i := range columnRows[column] {
+			row := &a.rows[i]
+			value := row.at(column)
+			if eliminated[i] || value == 0 {
+				continue
+			}
+			// register the fill-in
+			for _, j := range pivot.index {
+				if j > column && row.at(j) == 0 {
+					columnRows[j] = append(columnRows[j], i)
+				}
+			}
+			q := -value / pivot.at(column)
+			row.axpy(q, pivot)
+			row.remove(column)
+			if combinations != nil {
+				combinations.rows[i].axpy(q, &combinations.rows[pivotRow])
+			}
+		}
+	}
+	return count
+}
+
+// sparseOrderedDependencies marks the linearly independent rows of a like
+// sparseDependencies, but takes the rows in order: a row is dependent if it
+// is a combination of the independent rows before it. a is overwritten.
+func sparseOrderedDependencies(a *sparseMatrix, independent []bool, zero float64) int {
+	// the independent rows by the column of their pivot, each without an
+	// entry in the pivot columns of the rows before it
+	pivotOf := make(map[int]int)
+	pivotColumn := make([]int, a.m)
+	count := 0
+	for i := range a.rows {
+		row := &a.rows[i]
+		independent[i] = false
+		for {
+			pivotRow := -1
+			for _, j := range row.index {
+				if p, ok := pivotOf[j]; ok && (pivotRow < 0 || p < pivotRow) {
+					pivotRow = p
+				}
+			}
+			if pivotRow < 0 {
+				break
+			}
+			pivot := &a.rows[pivotRow]
+			column := pivotColumn[pivotRow]
+			row.axpy(-row.at(column)/pivot.at(column), pivot)
+			row.remove(column)
+		}
+
+		// the largest entry of the rest
+		column := -1
+		pivotValue := 0.0
+		for k, j := range row.index {
+			if value := math.Abs(row.value[k]); value > pivotValue {
+				column = j
+				pivotValue = value
+			}
+		}
+		if pivotValue < zero {
+			continue
+		}
+		pivotOf[column] = i
+		pivotColumn[i] = column
+		independent[i] = true
+		count++
+	}
+	return count
+}
+
+// removeSparseDependentRows removes the linearly dependent rows of a and
+// marks the kept rows in independentRows.
+func removeSparseDependentRows(a *sparseMatrix, independentRows []bool, zero float64) {
+	count := sparseDependencies(a.copy(), independentRows, zero, nil)
+	if count == a.m {
+		return
+	}
+
+	index := 0
+	for i := 0; i < a.m; i++ {
+		if independentRows[i] {
+			a.rows[index] = a.rows[i]
+			index++
+		}
+	}
+	a.rows = a.rows[:count]
+	a.m = count
+}
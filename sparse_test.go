@@ -0,0 +1,117 @@
+package lp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSparseSolve(t *testing.T) {
+	fmt.Println("Test SparseSolve")
+
+	// needs a row exchange in the first column
+	a := newSparseMatrix(3, 3)
+	*a.ref(0, 1) = 2
+	*a.ref(0, 2) = 1
+	*a.ref(1, 0) = 1
+	*a.ref(1, 2) = 1
+	*a.ref(2, 0) = 4
+	*a.ref(2, 1) = 1
+	b := []float64{7, 4, 6}
+	if !sparseSolve(a, b, EqualsEpsilon) {
+		t.Fatal("regular system not solved")
+	}
+	checkValue(t, "x0", b[0], 1)
+	checkValue(t, "x1", b[1], 2)
+	checkValue(t, "x2", b[2], 3)
+
+	a = newSparseMatrix(3, 4)
+	*a.ref(0, 0) = 1
+	*a.ref(0, 3) = 2
+	*a.ref(1, 1) = 1
+	*a.ref(2, 0) = 2
+	*a.ref(2, 3) = 4
+	independent := make([]bool, 3)
+	removeSparseDependentRows(a, independent, EqualsEpsilon)
+	if a.m != 2 || !independent[1] || independent[0] == independent[2] {
+		t.Errorf("independent rows = %v", independent)
+	}
+
+	// the later of two dependent rows is dropped, even with the smaller
+	// entries
+	a = newSparseMatrix(3, 2)
+	*a.ref(0, 0) = 1
+	*a.ref(0, 1) = 1
+	*a.ref(1, 0) = 1
+	*a.ref(2, 0) = 3
+	*a.ref(2, 1) = 4
+	if count := sparseOrderedDependencies(a, independent, EqualsEpsilon); count != 2 ||
+		!independent[0] || !independent[1] || independent[2] {
+		t.Errorf("ordered independent rows = %v", independent)
+	}
+}
+
+func TestSparseLayout(t *testing.T) {
+	fmt.Println("Test SparseLayout")
+
+	// a row of tabs, each constraint touches two of them
+	const n = 200
+	ls := NewLinearSpec()
+	tabs := make([]*Variable, n)
+	for i := range tabs {
+		tabs[i], _ = ls.AddVariable(nil)
+	}
+	ls.AddConstraint2([]float64{1}, []*Variable{tabs[0]}, OperatorEQ, 0)
+	for i := 1; i < n; i++ {
+		ls.AddConstraint2([]float64{1, -1}, []*Variable{tabs[i], tabs[i-1]}, OperatorGE, 10)
+		ls.AddConstraint4([]float64{1, -1}, []*Variable{tabs[i], tabs[i-1]}, OperatorGE, 20, 1, 1)
+	}
+	ls.AddConstraint2([]float64{1}, []*Variable{tabs[n-1]}, OperatorEQ, 15*(n-1))
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	for i := 1; i < n; i++ {
+		if tabs[i].Value()-tabs[i-1].Value() < 10-EqualsEpsilon {
+			t.Fatalf("tab %v is %v from its neighbour", i, tabs[i].Value()-tabs[i-1].Value())
+		}
+	}
+	checkValue(t, "middle tab", tabs[n/2].Value(), 15*(n/2))
+}
+
+func TestDependentRows(t *testing.T) {
+	fmt.Println("Test DependentRows")
+
+	// the duplicated equality is dropped, the slacks of the kept rows stay
+	ls := NewLinearSpec()
+	x, _ := ls.AddVariable(nil)
+	y, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{-1, 1}, []*Variable{x, y}, OperatorEQ, 20)
+	ls.AddConstraint2([]float64{-2, 2}, []*Variable{x, y}, OperatorEQ, 40)
+	ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorLE, 30)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 100)
+	ls.SetObjective1([]float64{-1}, []*Variable{x}, OptMinimize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("duplicated equality: result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 30)
+	checkValue(t, "y", y.Value(), 50)
+
+	// an empty row before an inequality
+	ls = NewLinearSpec()
+	x, _ = ls.AddVariable(nil)
+	y, _ = ls.AddVariable(nil)
+	x.SetRange(0, 1000)
+	y.SetRange(0, 1000)
+	ls.AddConstraint2([]float64{0}, []*Variable{x}, OperatorEQ, 0)
+	ls.AddConstraint2([]float64{1, -1}, []*Variable{x, y}, OperatorEQ, 10)
+	ls.AddConstraint2([]float64{1, 1}, []*Variable{x, y}, OperatorLE, 100)
+	ls.SetObjective1([]float64{1, 1}, []*Variable{x, y}, OptMinimize)
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("empty row: result = %v, want %v", result, ResultOptimal)
+	}
+	checkValue(t, "x", x.Value(), 10)
+	checkValue(t, "y", y.Value(), 0)
+	if stats := ls.Stats(); stats.RemovedRows != 1 {
+		t.Errorf("removed rows = %v, want 1", stats.RemovedRows)
+	}
+}
@@ -0,0 +1,38 @@
+package lp
+
+import "time"
+
+// SolveStats describes the work of the last solve. Counters that do not
+// apply to a solver are zero.
+type SolveStats struct {
+	// wall time of the solve
+	Time time.Duration
+
+	// pivots of solveEq while searching a feasible solution
+	EquationIterations int
+	// iterations of the active set method in the LayoutOptimizer
+	ActiveSetIterations int
+	// rows removed by RemoveLinearlyDependentRows
+	RemovedRows int
+	// columns removed by RemoveUnusedVariables
+	RemovedColumns int
+	// rows and columns removed by the presolve
+	PresolvedRows    int
+	PresolvedColumns int
+
+	// constraints that hold with equality at the solution
+	ActiveConstraints int
+
+	// simplex iterations, summed over all nodes of a branch and bound
+	SimplexIterations int
+	// iterations of the interior point method before the crossover
+	BarrierIterations int
+	// nodes explored by branch and bound
+	Nodes int
+}
+
+// StatsReporter is implemented by solvers that count their work. The
+// statistics of the last solve are available from LinearSpec.Stats.
+type StatsReporter interface {
+	Stats() SolveStats
+}
@@ -0,0 +1,61 @@
+package lp
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStatsActiveSet(t *testing.T) {
+	fmt.Println("Test Stats ActiveSet")
+
+	ls := NewLinearSpec()
+	x1, _ := ls.AddVariable(nil)
+	x2, _ := ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x1, x2}, OperatorGE, 10)
+	ls.AddConstraint2([]float64{1.0}, []*Variable{x1}, OperatorLE, 4)
+	ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{x1, x2}, OperatorLE, 2)
+	ls.AddConstraint4([]float64{1.0}, []*Variable{x2}, OperatorEQ, 50, 1, 1)
+
+	if result, _ := ls.Solve(); result != ResultOptimal {
+		t.Fatalf("result = %v, want %v", result, ResultOptimal)
+	}
+	stats := ls.Stats()
+	if stats.EquationIterations != 1 || stats.ActiveSetIterations < 1 ||
+		stats.ActiveConstraints != 2 || stats.RemovedRows != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if stats.Time <= 0 || ls.SolvingTime() != stats.Time.Seconds() {
+		t.Errorf("time = %v, solving time = %v", stats.Time, ls.SolvingTime())
+	}
+
+	// a multiple of an equation is linearly dependent
+	ls = NewLinearSpec()
+	x1, _ = ls.AddVariable(nil)
+	x2, _ = ls.AddVariable(nil)
+	ls.AddConstraint2([]float64{1.0, 1.0}, []*Variable{x1, x2}, OperatorEQ, 10)
+	ls.AddConstraint2([]float64{2.0, 2.0}, []*Variable{x1, x2}, OperatorEQ, 20)
+	ls.AddConstraint4([]float64{1.0}, []*Variable{x2}, OperatorEQ, 5, 1, 1)
+	ls.Solve()
+	if stats := ls.Stats(); stats.RemovedRows != 1 {
+		t.Errorf("removed rows = %v, want 1", stats.RemovedRows)
+	}
+}
+
+func TestStatsSimplex(t *testing.T) {
+	fmt.Println("Test Stats Simplex")
+
+	ls, _ := newBealeSpec()
+	ls.Solve()
+	stats := ls.Stats()
+	if stats.SimplexIterations != ls.Solver().(*SimplexSolver).Iterations() ||
+		stats.ActiveConstraints != 2 || stats.Nodes != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	ls, solver, _ := newKnapsackSpec()
+	ls.Solve()
+	stats = ls.Stats()
+	if stats.Nodes != solver.Nodes() || stats.SimplexIterations != solver.Iterations() {
+		t.Errorf("stats = %+v", stats)
+	}
+}
@@ -0,0 +1,39 @@
+package lp
+
+import "math"
+
+// Tolerances are the numerical tolerances of the solvers. The defaults
+// suit layouts in pixels; models with much smaller or larger numbers need
+// other ones.
+type Tolerances struct {
+	// largest violation of a constraint or bound that counts as satisfied
+	PrimalFeasibility float64
+	// largest reduced cost or Lagrange multiplier of the wrong sign that
+	// counts as optimal
+	DualFeasibility float64
+	// least absolute value of a pivot of the simplex solvers and of their
+	// basis factorization
+	Pivot float64
+	// largest absolute value that counts as zero in the eliminations of the
+	// EquationSystem and the LayoutOptimizer
+	Zero float64
+}
+
+// DefaultTolerances returns the tolerances of a new LinearSpec.
+func DefaultTolerances() Tolerances {
+	return Tolerances{EqualsEpsilon, EqualsEpsilon, simplexPivotEpsilon, EqualsEpsilon}
+}
+
+func (self Tolerances) valid() bool {
+	for _, value := range []float64{self.PrimalFeasibility, self.DualFeasibility,
+		self.Pivot, self.Zero} {
+		if !(value > 0) || math.IsInf(value, 1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (self Tolerances) isZero(value float64) bool {
+	return math.Abs(value) < self.Zero
+}
@@ -0,0 +1,50 @@
+package lp
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestTolerances(t *testing.T) {
+	fmt.Println("Test Tolerances")
+
+	ls := NewLinearSpec()
+	if ls.Tolerances() != DefaultTolerances() {
+		t.Errorf("tolerances of a new specification = %+v", ls.Tolerances())
+	}
+	for _, invalid := range []Tolerances{
+		{0, 1e-6, 1e-9, 1e-6},
+		{1e-6, -1, 1e-9, 1e-6},
+		{1e-6, 1e-6, math.NaN(), 1e-6},
+		{1e-6, 1e-6, 1e-9, math.Inf(1)},
+	} {
+		if err := ls.SetTolerances(invalid); err != ErrInvalidTolerances {
+			t.Errorf("SetTolerances(%+v) = %v", invalid, err)
+		}
+	}
+
+	// a violation that only counts for a strict primal feasibility tolerance
+	strict := DefaultTolerances()
+	strict.PrimalFeasibility = 1e-9
+	for _, solver := range []string{"active set", "simplex"} {
+		ls := NewLinearSpec()
+		if solver == "simplex" {
+			ls.SetSolver(NewSimplexSolver(ls))
+		}
+		x, _ := ls.AddVariable(nil)
+		x.SetMin(0)
+		ls.AddConstraint2([]float64{1}, []*Variable{x}, OperatorLE, -5e-7)
+		if result, _ := ls.Solve(); result != ResultOptimal {
+			t.Errorf("%v: result = %v with the default tolerances", solver, result)
+		}
+
+		if err := ls.SetTolerances(strict); err != nil {
+			t.Fatal(err)
+		}
+		if result, _ := ls.Solve(); result != ResultInfeasible {
+			t.Errorf("%v: result = %v with strict tolerances, want %v", solver, result,
+				ResultInfeasible)
+		}
+	}
+}
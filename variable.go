@@ -9,7 +9,9 @@ type Variable struct {
 	min, max, value float64
 	label           string
 	isValid         bool
+	integer         bool
     reference       int
+	reducedCost     float64
 }
 
 func newVariable(ls *LinearSpec) *Variable {
@@ -17,8 +19,8 @@ func newVariable(ls *LinearSpec) *Variable {
 	v.ls = ls
 	v.label = ""
 	v.value = math.NaN()
-	v.min = -20000
-	v.max = 20000
+	v.min = math.Inf(-1)
+	v.max = math.Inf(1)
     v.reference = 0
 	v.isValid = false
 
@@ -59,14 +61,21 @@ func (self *Variable) SetValue(val float64) {
 	self.value = val
 }
 
+// ReducedCost gets the change of the objective per unit increase of the
+// variable at the last successful solve. It is 0 unless the variable is
+// held at one of its bounds.
+func (self *Variable) ReducedCost() float64 {
+	return self.reducedCost
+}
+
 // Min gets the minimum value of the variable
 func (self *Variable) Min() float64 {
 	return self.min
 }
 
 // SetMin sets the minimum value of the variable
-func (self *Variable) SetMin(min float64) {
-	self.SetRange(min, self.max)
+func (self *Variable) SetMin(min float64) error {
+	return self.SetRange(min, self.max)
 }
 
 // Max gets the maximum value of the variable
@@ -75,19 +84,55 @@ func (self *Variable) Max() float64 {
 }
 
 // SetMax sets the maximum value of the variable
-func (self *Variable) SetMax(max float64) {
-	self.SetRange(self.min, max)
+func (self *Variable) SetMax(max float64) error {
+	return self.SetRange(self.min, max)
 }
 
-// SetRange sets the minimum and maximum values of the variable
-func (self *Variable) SetRange(min, max float64) {
+// SetRange sets the minimum and maximum values of the variable; infinite
+// values leave the variable unbounded in that direction. A minimum above
+// the maximum or a NaN bound is rejected and leaves the range unchanged.
+func (self *Variable) SetRange(min, max float64) error {
 	if !self.isValid {
-		return
+		return &VariableError{self, ErrInvalidVariable}
+	}
+	if !(min <= max) {
+		return &VariableError{self, ErrInvalidRange}
 	}
 
 	self.min = min
 	self.max = max
-	self.ls.UpdateRange(self)
+	return self.ls.UpdateRange(self)
+}
+
+// SetFree removes both bounds of the variable.
+func (self *Variable) SetFree() error {
+	return self.SetRange(math.Inf(-1), math.Inf(1))
+}
+
+// IsInteger returns true if the variable may only take integer values.
+func (self *Variable) IsInteger() bool {
+	return self.integer
+}
+
+// SetInteger sets whether the variable may only take integer values.
+// Integrality is honored by the BranchAndBoundSolver.
+func (self *Variable) SetInteger(integer bool) {
+	self.integer = integer
+}
+
+// IsBinary returns true if the variable is an integer variable with range
+// [0, 1].
+func (self *Variable) IsBinary() bool {
+	return self.integer && self.min == 0 && self.max == 1
+}
+
+// SetBinary makes the variable an integer variable with range [0, 1].
+func (self *Variable) SetBinary() error {
+	if err := self.SetRange(0, 1); err != nil {
+		return err
+	}
+	self.SetInteger(true)
+	return nil
 }
 
 // Label returns Variable label
@@ -100,6 +145,14 @@ func (self *Variable) SetLabel(label string) {
 	self.label = label
 }
 
+// name returns the label of the variable or its index.
+func (self *Variable) name() string {
+	if self.label != "" {
+		return self.label
+	}
+	return "#" + strconv.Itoa(self.GlobalIndex())
+}
+
 // Returns index of variable as String
 func (self *Variable) String() string {
 	resStr := ""
@@ -121,46 +174,46 @@ func (self *Variable) String() string {
 	return resStr
 }
 
-func (self *Variable) IsEqual(v *Variable) *Constraint {
+func (self *Variable) IsEqual(v *Variable) (*Constraint, error) {
 	if !self.isValid {
-		return nil
+		return nil, &VariableError{self, ErrInvalidVariable}
 	}
 	return self.ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{self, v}, OperatorEQ, 0.0)
 }
 
-func (self *Variable) IsSmallerOrEqual(v *Variable) *Constraint {
+func (self *Variable) IsSmallerOrEqual(v *Variable) (*Constraint, error) {
 	if !self.isValid {
-		return nil
+		return nil, &VariableError{self, ErrInvalidVariable}
 	}
 	return self.ls.AddConstraint2([]float64{1.0, -1.0}, []*Variable{self, v}, OperatorLE, 0.0)
 }
 
-func (self *Variable) IsGreaterOrEqual(v *Variable) *Constraint {
+func (self *Variable) IsGreaterOrEqual(v *Variable) (*Constraint, error) {
 	if !self.isValid {
-		return nil
+		return nil, &VariableError{self, ErrInvalidVariable}
 	}
 	return self.ls.AddConstraint2([]float64{-1.0, 1.0}, []*Variable{v, self}, OperatorGE, 0.0)
 }
 
-func (self *Variable) IsEqual1(v *Variable, penaltyNeg, penaltyPos float64) *Constraint {
+func (self *Variable) IsEqual1(v *Variable, penaltyNeg, penaltyPos float64) (*Constraint, error) {
 	if !self.isValid {
-		return nil
+		return nil, &VariableError{self, ErrInvalidVariable}
 	}
 	return self.ls.AddConstraint4([]float64{1.0, -1.0}, []*Variable{self, v}, OperatorEQ, 0.0,
 		penaltyNeg, penaltyPos)
 }
 
-func (self *Variable) IsSmallerOrEqual1(v *Variable, penaltyNeg, penaltyPos float64) *Constraint {
+func (self *Variable) IsSmallerOrEqual1(v *Variable, penaltyNeg, penaltyPos float64) (*Constraint, error) {
 	if !self.isValid {
-		return nil
+		return nil, &VariableError{self, ErrInvalidVariable}
 	}
 	return self.ls.AddConstraint4([]float64{1.0, -1.0}, []*Variable{self, v}, OperatorLE, 0.0,
 		penaltyNeg, penaltyPos)
 }
 
-func (self *Variable) IsGreaterOrEqual1(v *Variable, penaltyNeg, penaltyPos float64) *Constraint {
+func (self *Variable) IsGreaterOrEqual1(v *Variable, penaltyNeg, penaltyPos float64) (*Constraint, error) {
 	if !self.isValid {
-		return nil
+		return nil, &VariableError{self, ErrInvalidVariable}
 	}
 	return self.ls.AddConstraint4([]float64{-1.0, 1.0}, []*Variable{v, self}, OperatorGE, 0.0,
 		penaltyNeg, penaltyPos)